@@ -33,8 +33,14 @@ echo   - Output: bin\server.exe
 echo   - Mode: Release build
 echo.
 
+:: Build information reported by GET /version
+set VERSION_PKG=github.com/1DeliDolu/ki-ai-go/internal/version
+set GIT_COMMIT=unknown
+for /f %%i in ('git rev-parse --short HEAD 2^>nul') do set GIT_COMMIT=%%i
+for /f %%i in ('powershell -NoProfile -Command "(Get-Date).ToUniversalTime().ToString('yyyy-MM-ddTHH:mm:ssZ')"') do set BUILD_TIME=%%i
+
 :: Build with optimizations
-go build -ldflags "-s -w -X main.version=1.0.0" -trimpath -o bin/server.exe ./cmd/server
+go build -ldflags "-s -w -X %VERSION_PKG%.Version=1.0.0 -X %VERSION_PKG%.Commit=%GIT_COMMIT% -X %VERSION_PKG%.BuildTime=%BUILD_TIME%" -trimpath -o bin/server.exe ./cmd/server
 
 if %ERRORLEVEL% == 0 (
     echo.
@@ -32,8 +32,13 @@ echo "   - Output: bin/server"
 echo "   - Mode: Release build"
 echo ""
 
+# Build information reported by GET /version
+VERSION_PKG="github.com/1DeliDolu/ki-ai-go/internal/version"
+GIT_COMMIT=$(git rev-parse --short HEAD 2>/dev/null || echo unknown)
+BUILD_TIME=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+
 # Build with optimizations (no CGO dependencies)
-if go build -ldflags "-s -w -X main.version=1.0.0" -trimpath -o bin/server ./cmd/server; then
+if go build -ldflags "-s -w -X $VERSION_PKG.Version=1.0.0 -X $VERSION_PKG.Commit=$GIT_COMMIT -X $VERSION_PKG.BuildTime=$BUILD_TIME" -trimpath -o bin/server ./cmd/server; then
     echo ""
     echo "✅ Build successful! (No CGO dependencies)"
     
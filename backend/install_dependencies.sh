@@ -59,6 +59,30 @@ else
     echo "❌ DOCX library failed"
 fi
 
+echo ""
+echo "Trying xuri/excelize for XLSX processing..."
+if go get github.com/xuri/excelize/v2@latest; then
+    echo "✅ XLSX library added successfully"
+else
+    echo "❌ XLSX library failed"
+fi
+
+echo ""
+echo "Trying yaml.v3 and BurntSushi/toml for config file processing..."
+if go get gopkg.in/yaml.v3@latest && go get github.com/BurntSushi/toml@latest; then
+    echo "✅ YAML and TOML libraries added successfully"
+else
+    echo "❌ YAML/TOML libraries failed"
+fi
+
+echo ""
+echo "Checking OCR tools for scanned PDFs (ENABLE_OCR=true)..."
+if command -v pdftoppm >/dev/null 2>&1 && command -v tesseract >/dev/null 2>&1; then
+    echo "✅ pdftoppm and tesseract found"
+else
+    echo "⚠️  OCR tools missing - install poppler-utils and tesseract-ocr to enable OCR"
+fi
+
 echo ""
 echo "🔄 Running go mod tidy..."
 go mod tidy
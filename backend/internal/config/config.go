@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
@@ -22,6 +23,119 @@ type Config struct {
 	LlamaContextSize int
 	LlamaThreads     int
 	LlamaGPULayers   int
+	// Audit settings
+	AuditLogEnabled bool
+	AuditLogPath    string
+	// AI settings
+	ResponseLanguage  string  // "auto" detects from query and documents
+	DefaultModel      string  // Loaded on startup when set
+	MinRelevanceScore float64 // Share of query terms (0-1) a document must contain to be used as context
+	MaxContextChars   int     // Document context budget per prompt, 0 is unlimited
+	AnswerTokens      int     // Default answer length (num_predict), kept free in the context window
+	// Debug settings
+	DebugPrompts        bool   // Let admins request the assembled prompt with ?debug=true
+	DebugToken          string // Lets any caller sending it in X-Debug-Token use ?debug=true
+	DebugPromptMaxChars int
+	LogDocumentContent  bool // Log document names, queries and snippets instead of only IDs and counts
+	// Concurrency settings
+	MaxConcurrentOperations int
+	ConcurrencyQueueTimeout int // Seconds to wait for a free slot, 0 rejects immediately
+	// Generation queueing per Ollama model
+	GenerationConcurrency  int // Generations running at once per model
+	GenerationQueueDepth   int // Generations waiting per model before new ones are rejected
+	GenerationQueueTimeout int // Seconds a generation waits for its model, 0 rejects immediately
+	// Conversation history for follow-up questions
+	ConversationMaxMessages int // Messages kept per conversation, 0 disables history
+	ConversationMaxCount    int // Conversations kept before the longest idle one is dropped
+	// Background jobs
+	JobWorkers    int // Jobs running at once
+	JobQueueDepth int // Jobs waiting before new ones are rejected
+	JobRetention  int // Seconds finished jobs can still be polled
+	// Processing stats persistence
+	ProcessingStatsPath         string
+	ProcessingStatsSaveInterval int // Seconds between stats saves
+	// Memory database persistence, an empty path keeps it in memory only
+	MemoryDBSnapshotPath     string
+	MemoryDBSnapshotInterval int // Seconds between snapshots of unsaved changes, 0 only saves on shutdown
+	// Ollama timeouts in seconds, 0 disables the timeout
+	OllamaPingTimeout     int // Health pings and model listing
+	OllamaGenerateTimeout int // Text generation
+	OllamaPullTimeout     int // Model pulls
+	ModelDownloadTimeout  int // Seconds a direct model file download may take, 0 disables the timeout
+	// Retries of Ollama requests failing with connection errors or 5xx responses
+	OllamaMaxAttempts  int
+	OllamaRetryBackoff int // Milliseconds before the first retry, doubled for every further one
+	// Access control
+	AdminUsers        []string // Users who can see every document
+	DefaultVisibility string   // Visibility for uploads that don't specify one
+	// Upload settings
+	BatchUploadConcurrency int
+	BatchUploadRetries     int    // Extra attempts for files that fail with a storage error
+	BatchUploadRetryDelay  int    // Milliseconds before the first retry, doubled for every further one
+	FilenameStrategy       string // timestamp, hash or uuid
+	// Document listing, requests can override both
+	DocumentListing       string // flat, or name to show the latest document per name
+	DocumentListingDedupe bool   // Fold documents with identical content into their latest upload
+	// Idempotency-Key handling for uploads and model downloads
+	IdempotencyTTL     int // Seconds a completed result is replayed
+	IdempotencyMaxKeys int
+	// Preview highlighting markers
+	HighlightPreTag  string
+	HighlightPostTag string
+	// Seconds an advanced search may run before it returns what it found, 0 is unlimited
+	SearchTimeout int
+	// Upload malware scanning
+	UploadScanEnabled bool
+	ClamAVNetwork     string // tcp or unix
+	ClamAVAddress     string
+	ClamAVTimeout     int // Seconds
+	// Storage limits, 0 means unlimited
+	MaxDocuments    int
+	MaxStorageBytes int64
+	StorageEviction string // "reject" refuses uploads at the limit, "oldest" evicts the uploader's oldest documents
+	// Processing callbacks
+	WebhookSecret     string // HMAC-SHA256 key for the X-Signature-256 header
+	WebhookTimeout    int    // Seconds per delivery attempt
+	WebhookMaxRetries int
+	WebhookBackoff    int  // Seconds before the first retry, doubled after each attempt
+	WebhookAllowLocal bool // Allow callbacks to loopback, link-local and private addresses
+	// Text files larger than this are truncated after extraction
+	MaxTextBytes int64
+	// CSV delimiter: auto, comma, semicolon, tab or a single character
+	CSVDelimiter string
+	// Fail PDF extraction instead of storing placeholder text
+	PDFStrictMode bool
+	// Limits for ZIP and gzip uploads, against zip bombs
+	ArchiveMaxEntries int
+	ArchiveMaxBytes   int64 // Total bytes extracted from one archive
+	// Extractor fallback order per type, empty keeps the processor default
+	PDFExtractors  []string // ledongthuc, ocr, basic
+	DOCXExtractors []string // docx, basic
+	// Types that skip their parsing library and always use basic extraction, e.g. pdf, docx, html
+	BasicExtractionTypes []string
+	// OCR for scanned PDFs, requires pdftoppm and tesseract
+	EnableOCR   bool
+	OCRLanguage string // Tesseract language codes, e.g. "eng" or "deu+eng"
+	// Documents processed at once in batch processing, 0 uses the CPU count
+	ProcessingWorkers int
+	// Cache of extracted content, a 0 limit disables it
+	ContentCacheMaxEntries int
+	ContentCacheMaxBytes   int64
+	ContentCacheTTL        int // Seconds an entry stays valid, 0 keeps entries until evicted
+	// Chunking and embeddings
+	EmbeddingModel string
+	ChunkSize      int    // Runes per chunk
+	ChunkOverlap   int    // Runes shared by consecutive chunks
+	ChunkStrategy  string // fixed, sentence or paragraph
+	EmbeddingTopK  int    // Chunks retrieved per document for a query
+	// Re-ranking retrieved chunks by asking a model to rate them, one generation per candidate
+	RerankEnabled    bool
+	RerankCandidates int    // Chunks retrieved per document for the model to rate, EmbeddingTopK of them are kept
+	RerankModel      string // Rates the chunks, empty uses the loaded model
+	// Wikipedia search
+	WikiLanguage        string // Wikipedia edition, e.g. "de" or "en"
+	WikiCacheTTL        int    // Seconds a search result is reused, 0 disables the cache
+	WikiCacheMaxEntries int
 }
 
 func Load() *Config {
@@ -60,13 +174,123 @@ func Load() *Config {
 		TestDocumentsPath: filepath.Join(appDir, "test_documents"), // Frontend dokümanları
 		DatabasePath:      dbPath,
 		OllamaURL:         getEnv("OLLAMA_URL", "http://localhost:11434"),
-		MaxFileSize:       50 * 1024 * 1024, // 50MB
+		MaxFileSize:       int64(getEnvInt("MAX_FILE_MB", 50)) * 1024 * 1024,
 		AllowedTypes:      []string{".pdf", ".txt", ".docx", ".md"},
 		// Llama settings
 		LlamaModelPath:   filepath.Join(appDir, "models"),
 		LlamaContextSize: getEnvInt("LLAMA_CONTEXT_SIZE", 2048),
 		LlamaThreads:     getEnvInt("LLAMA_THREADS", threads),
 		LlamaGPULayers:   getEnvInt("LLAMA_GPU_LAYERS", 0), // 0 = CPU only
+		// Audit settings
+		AuditLogEnabled: getEnvBool("AUDIT_LOG_ENABLED", true),
+		AuditLogPath:    getEnv("AUDIT_LOG_PATH", filepath.Join(appDir, "data", "audit.log")),
+		// AI settings
+		ResponseLanguage:  getEnv("RESPONSE_LANGUAGE", "auto"),
+		DefaultModel:      getEnv("DEFAULT_MODEL", ""),
+		MinRelevanceScore: getEnvFloat("MIN_RELEVANCE_SCORE", 0.2),
+		MaxContextChars:   getEnvInt("MAX_CONTEXT_CHARS", 32000),
+		AnswerTokens:      getEnvInt("ANSWER_TOKENS", 512),
+		// Debug settings
+		DebugPrompts:        getEnvBool("DEBUG_PROMPTS", false),
+		DebugToken:          getEnv("DEBUG_TOKEN", ""),
+		DebugPromptMaxChars: getEnvInt("DEBUG_PROMPT_MAX_CHARS", 8000),
+		LogDocumentContent:  getEnvBool("LOG_DOCUMENT_CONTENT", false),
+		// Concurrency settings
+		MaxConcurrentOperations: getEnvInt("MAX_CONCURRENT_OPERATIONS", threads),
+		ConcurrencyQueueTimeout: getEnvInt("CONCURRENCY_QUEUE_TIMEOUT", 30),
+		// Generation queueing
+		GenerationConcurrency:  getEnvInt("GENERATION_CONCURRENCY", 1),
+		GenerationQueueDepth:   getEnvInt("GENERATION_QUEUE_DEPTH", 8),
+		GenerationQueueTimeout: getEnvInt("GENERATION_QUEUE_TIMEOUT", 60),
+		// Conversation history
+		ConversationMaxMessages: getEnvInt("CONVERSATION_MAX_MESSAGES", 20),
+		ConversationMaxCount:    getEnvInt("CONVERSATION_MAX_COUNT", 1000),
+		// Background jobs
+		JobWorkers:    getEnvInt("JOB_WORKERS", 2),
+		JobQueueDepth: getEnvInt("JOB_QUEUE_DEPTH", 32),
+		JobRetention:  getEnvInt("JOB_RETENTION", 3600),
+		// Processing stats persistence
+		ProcessingStatsPath:         getEnv("PROCESSING_STATS_PATH", filepath.Join(appDir, "data", "processing_stats.json")),
+		ProcessingStatsSaveInterval: getEnvInt("PROCESSING_STATS_SAVE_INTERVAL", 60),
+		// Memory database persistence
+		MemoryDBSnapshotPath:     getEnv("MEMORY_DB_SNAPSHOT_PATH", filepath.Join(appDir, "data", "memorydb.json")),
+		MemoryDBSnapshotInterval: getEnvInt("MEMORY_DB_SNAPSHOT_INTERVAL", 30),
+		// Ollama timeouts
+		OllamaPingTimeout:     getEnvInt("OLLAMA_PING_TIMEOUT", 5),
+		OllamaGenerateTimeout: getEnvInt("OLLAMA_GENERATE_TIMEOUT", 120),
+		OllamaPullTimeout:     getEnvInt("OLLAMA_PULL_TIMEOUT", 0), // Large models can take a long time
+		ModelDownloadTimeout:  getEnvInt("MODEL_DOWNLOAD_TIMEOUT", 1800),
+		// Ollama retries
+		OllamaMaxAttempts:  getEnvInt("OLLAMA_MAX_ATTEMPTS", 3),
+		OllamaRetryBackoff: getEnvInt("OLLAMA_RETRY_BACKOFF", 500),
+		// Access control
+		AdminUsers:        getEnvList("ADMIN_USERS", nil),
+		DefaultVisibility: getEnv("DEFAULT_VISIBILITY", "private"),
+		// Upload settings
+		BatchUploadConcurrency: getEnvInt("BATCH_UPLOAD_CONCURRENCY", 4),
+		BatchUploadRetries:     getEnvInt("BATCH_UPLOAD_RETRIES", 2),
+		BatchUploadRetryDelay:  getEnvInt("BATCH_UPLOAD_RETRY_DELAY", 200),
+		FilenameStrategy:       getEnv("FILENAME_STRATEGY", "timestamp"),
+		// Document listing
+		DocumentListing:       getEnv("DOCUMENT_LISTING", "flat"),
+		DocumentListingDedupe: getEnvBool("DOCUMENT_LISTING_DEDUPE", false),
+		// Idempotency keys
+		IdempotencyTTL:     getEnvInt("IDEMPOTENCY_TTL", 24*60*60),
+		IdempotencyMaxKeys: getEnvInt("IDEMPOTENCY_MAX_KEYS", 1000),
+		// Preview highlighting markers
+		HighlightPreTag:  getEnv("HIGHLIGHT_PRE_TAG", "<mark>"),
+		HighlightPostTag: getEnv("HIGHLIGHT_POST_TAG", "</mark>"),
+		// Search
+		SearchTimeout: getEnvInt("SEARCH_TIMEOUT", 60),
+		// Upload malware scanning
+		UploadScanEnabled: getEnvBool("UPLOAD_SCAN_ENABLED", false),
+		ClamAVNetwork:     getEnv("CLAMAV_NETWORK", "tcp"),
+		ClamAVAddress:     getEnv("CLAMAV_ADDRESS", "localhost:3310"),
+		ClamAVTimeout:     getEnvInt("CLAMAV_TIMEOUT", 30),
+		// Storage limits
+		MaxDocuments:    getEnvInt("MAX_DOCUMENTS", 0),
+		MaxStorageBytes: int64(getEnvInt("MAX_STORAGE_MB", 0)) * 1024 * 1024,
+		StorageEviction: getEnv("STORAGE_EVICTION", "reject"),
+		// Processing callbacks
+		WebhookSecret:     getEnv("WEBHOOK_SECRET", ""),
+		WebhookTimeout:    getEnvInt("WEBHOOK_TIMEOUT", 10),
+		WebhookMaxRetries: getEnvInt("WEBHOOK_MAX_RETRIES", 3),
+		WebhookBackoff:    getEnvInt("WEBHOOK_BACKOFF", 2),
+		WebhookAllowLocal: getEnvBool("WEBHOOK_ALLOW_LOCAL", false),
+		// Text extraction
+		MaxTextBytes: int64(getEnvInt("MAX_TEXT_MB", 10)) * 1024 * 1024,
+		CSVDelimiter: getEnv("CSV_DELIMITER", "auto"),
+		// Archives
+		ArchiveMaxEntries: getEnvInt("ARCHIVE_MAX_ENTRIES", 1000),
+		ArchiveMaxBytes:   int64(getEnvInt("ARCHIVE_MAX_MB", 200)) * 1024 * 1024,
+		// PDF extraction
+		PDFStrictMode:  getEnvBool("PDF_STRICT_MODE", false),
+		PDFExtractors:  getEnvList("PDF_EXTRACTORS", nil),
+		DOCXExtractors: getEnvList("DOCX_EXTRACTORS", nil),
+		EnableOCR:      getEnvBool("ENABLE_OCR", false),
+		OCRLanguage:    getEnv("OCR_LANGUAGE", "eng"),
+		// Basic extraction
+		BasicExtractionTypes: getEnvList("BASIC_EXTRACTION_TYPES", nil),
+		// Batch processing
+		ProcessingWorkers: getEnvInt("PROCESSING_WORKERS", 0),
+		// Content cache
+		ContentCacheMaxEntries: getEnvInt("CONTENT_CACHE_MAX_ENTRIES", 256),
+		ContentCacheMaxBytes:   int64(getEnvInt("CONTENT_CACHE_MAX_MB", 256)) * 1024 * 1024,
+		ContentCacheTTL:        getEnvInt("CONTENT_CACHE_TTL", 600),
+		// Chunking and embeddings
+		EmbeddingModel: getEnv("EMBEDDING_MODEL", "nomic-embed-text"),
+		ChunkSize:      getEnvInt("CHUNK_SIZE", 1000),
+		ChunkOverlap:   getEnvInt("CHUNK_OVERLAP", 200),
+		ChunkStrategy:  getEnv("CHUNK_STRATEGY", "fixed"),
+		EmbeddingTopK:  getEnvInt("EMBEDDING_TOP_K", 4),
+		// Re-ranking
+		RerankEnabled:    getEnvBool("RERANK_ENABLED", false),
+		RerankCandidates: getEnvInt("RERANK_CANDIDATES", 12),
+		RerankModel:      getEnv("RERANK_MODEL", ""),
+		// Wikipedia search
+		WikiLanguage:        getEnv("WIKI_LANGUAGE", "de"),
+		WikiCacheTTL:        getEnvInt("WIKI_CACHE_TTL", 3600),
+		WikiCacheMaxEntries: getEnvInt("WIKI_CACHE_MAX_ENTRIES", 1000),
 	}
 }
 
@@ -89,3 +313,36 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
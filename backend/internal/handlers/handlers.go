@@ -2,6 +2,9 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,8 +14,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/1DeliDolu/ki-ai-go/internal/logging"
+	"github.com/1DeliDolu/ki-ai-go/internal/processors"
 	"github.com/1DeliDolu/ki-ai-go/internal/services"
+	"github.com/1DeliDolu/ki-ai-go/internal/storage"
 	"github.com/1DeliDolu/ki-ai-go/internal/utils"
+	"github.com/1DeliDolu/ki-ai-go/internal/version"
 	"github.com/1DeliDolu/ki-ai-go/pkg/types"
 	"github.com/gin-gonic/gin"
 )
@@ -23,27 +30,174 @@ type Handler struct {
 	wikiService     *services.WikiService
 	aiService       *services.AIService
 	cleanupService  *services.CleanupService
+	auditService    *services.AuditService
+	limiter         *services.ConcurrencyLimiter
+	idempotency     *services.IdempotencyStore
 }
 
 func New(modelService *services.ModelService, documentService *services.DocumentService,
-	wikiService *services.WikiService, aiService *services.AIService, cleanupService *services.CleanupService) *Handler {
+	wikiService *services.WikiService, aiService *services.AIService, cleanupService *services.CleanupService,
+	auditService *services.AuditService, limiter *services.ConcurrencyLimiter,
+	idempotency *services.IdempotencyStore) *Handler {
+	aiService.SetChunkRetriever(documentService)
 	return &Handler{
 		modelService:    modelService,
 		documentService: documentService,
 		wikiService:     wikiService,
 		aiService:       aiService,
 		cleanupService:  cleanupService,
+		auditService:    auditService,
+		limiter:         limiter,
+		idempotency:     idempotency,
 	}
 }
 
+// requestID returns the caller-supplied request ID or generates one
+func requestID(c *gin.Context) string {
+	if id := c.GetHeader("X-Request-ID"); id != "" {
+		return id
+	}
+	return fmt.Sprintf("req_%d", time.Now().UnixNano())
+}
+
+// accessContext identifies the requesting user from the X-User header. The header is not verified
+// here: the server must run behind an authenticating proxy that sets X-User and drops any value
+// sent by the client, otherwise callers can claim to be any user, including an admin.
+func (h *Handler) accessContext(c *gin.Context) types.AccessContext {
+	user := strings.TrimSpace(c.GetHeader("X-User"))
+	return types.AccessContext{
+		User:    user,
+		IsAdmin: h.documentService.IsAdmin(user),
+	}
+}
+
+// acquireSlot reserves a slot for a heavy operation and responds with 429 when the server is saturated.
+// It gives up without a response when the client disconnects while waiting.
+func (h *Handler) acquireSlot(c *gin.Context) bool {
+	if h.limiter.Acquire(c.Request.Context()) {
+		return true
+	}
+	if c.Request.Context().Err() != nil {
+		log.Printf("Client %s went away while waiting for a slot for %s", c.ClientIP(), c.FullPath())
+		return false
+	}
+
+	log.Printf("⚠️ Rejecting %s from %s: too many concurrent operations", c.FullPath(), c.ClientIP())
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Server is busy, please retry later"})
+	return false
+}
+
+// beginIdempotent claims the request's Idempotency-Key within scope. It returns the key to pass to
+// completeIdempotent and releaseIdempotent, "" when the request has no key, and false when the
+// request was already answered from a previous attempt or rejected.
+func (h *Handler) beginIdempotent(c *gin.Context, scope, fingerprint string) (string, bool) {
+	header := strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+	if header == "" {
+		return "", true
+	}
+
+	// Keys are per user so clients can't collide with each other
+	key := scope + ":" + h.accessContext(c).User + ":" + header
+	result, err := h.idempotency.Begin(key, fingerprint)
+	switch {
+	case errors.Is(err, services.ErrIdempotencyInProgress):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return "", false
+	case errors.Is(err, services.ErrIdempotencyMismatch):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return "", false
+	case result != nil:
+		log.Printf("🔁 Replaying %s result for idempotency key %s", scope, header)
+		c.Header("Idempotent-Replayed", "true")
+		c.JSON(result.Status, result.Body)
+		return "", false
+	}
+	return key, true
+}
+
+// completeIdempotent sends the response and stores it under key for retries
+func (h *Handler) completeIdempotent(c *gin.Context, key string, status int, body gin.H) {
+	if key != "" {
+		h.idempotency.Complete(key, status, body)
+	}
+	c.JSON(status, body)
+}
+
+// releaseIdempotent frees a key whose request didn't complete; it is a no-op after completeIdempotent
+func (h *Handler) releaseIdempotent(key string) {
+	if key != "" {
+		h.idempotency.Release(key)
+	}
+}
+
+// extractionContext returns the request context with the ?basic_extraction=pdf,html override applied,
+// responding with 400 for types without a basic extraction path
+func (h *Handler) extractionContext(c *gin.Context) (context.Context, bool) {
+	var fileTypes []string
+	for _, fileType := range strings.Split(c.Query("basic_extraction"), ",") {
+		if fileType = strings.TrimSpace(fileType); fileType != "" {
+			fileTypes = append(fileTypes, fileType)
+		}
+	}
+
+	ctx, err := h.documentService.BasicExtractionContext(c.Request.Context(), fileTypes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return ctx, true
+}
+
+// audit records a document event with the request's ID, client IP and user
+func (h *Handler) audit(c *gin.Context, documentID, event, details string) {
+	h.auditService.Record(documentID, event, requestID(c), c.ClientIP(), h.accessContext(c).User, details)
+}
+
 // Health check
 func (h *Handler) HealthCheck(c *gin.Context) {
 	log.Printf("Health check requested from %s", c.ClientIP())
 	c.Header("Access-Control-Allow-Origin", "*")
 	c.JSON(http.StatusOK, gin.H{
-		"status":    "ok",
-		"timestamp": time.Now().Unix(),
-		"message":   "Local AI Project API is running",
+		"status":        "ok",
+		"timestamp":     time.Now().Unix(),
+		"message":       "Local AI Project API is running",
+		"concurrency":   h.limiter.Stats(),
+		"generation":    h.aiService.GenerationStats(),
+		"jobs":          h.documentService.JobStats(),
+		"default_model": h.aiService.DefaultModelStatus(),
+	})
+}
+
+// ReadinessCheck reports whether the data directories are writable (GET /ready), with 503 when
+// any of them isn't. Missing tools of optional features are listed without failing the check.
+func (h *Handler) ReadinessCheck(c *gin.Context) {
+	checks := h.documentService.CheckStorage()
+
+	status, ready := http.StatusOK, true
+	for _, check := range checks {
+		if !check.Writable {
+			status, ready = http.StatusServiceUnavailable, false
+		}
+	}
+
+	c.JSON(status, gin.H{
+		"ready":   ready,
+		"storage": checks,
+		"tools":   processors.CheckExternalTools(),
+	})
+}
+
+// GetVersion reports which build of the backend is running
+func (h *Handler) GetVersion(c *gin.Context) {
+	log.Printf("Version requested from %s", c.ClientIP())
+
+	info := version.Get()
+	c.JSON(http.StatusOK, gin.H{
+		"version":    info.Version,
+		"commit":     info.Commit,
+		"build_time": info.BuildTime,
+		"go_version": info.GoVersion,
+		"processors": h.documentService.GetProcessorVersions(),
 	})
 }
 
@@ -51,7 +205,19 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 func (h *Handler) ListModels(c *gin.Context) {
 	log.Printf("ListModels requested from %s", c.ClientIP())
 
-	models, err := h.modelService.ListModels()
+	sortBy := c.Query("sort")
+	if sortBy != "" && sortBy != "name" && sortBy != "size" && sortBy != "modified" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of name, size, modified"})
+		return
+	}
+
+	models, err := h.modelService.ListModelsWithOptions(services.ModelListOptions{
+		SortBy:       sortBy,
+		Order:        c.Query("order"),
+		Family:       c.Query("family"),
+		Type:         c.Query("type"),
+		Quantization: c.Query("quantization"),
+	})
 	if err != nil {
 		log.Printf("Error listing models: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -76,6 +242,12 @@ func (h *Handler) DownloadModel(c *gin.Context) {
 		return
 	}
 
+	key, proceed := h.beginIdempotent(c, "download", req.Name+"\n"+req.URL)
+	if !proceed {
+		return
+	}
+	defer h.releaseIdempotent(key)
+
 	log.Printf("Downloading model %s from %s", req.Name, req.URL)
 	if err := h.modelService.DownloadModel(req.Name, req.URL); err != nil {
 		log.Printf("Error downloading model: %v", err)
@@ -83,7 +255,7 @@ func (h *Handler) DownloadModel(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Model downloaded successfully"})
+	h.completeIdempotent(c, key, http.StatusOK, gin.H{"message": "Model downloaded successfully"})
 }
 
 func (h *Handler) LoadModel(c *gin.Context) {
@@ -168,11 +340,44 @@ func (h *Handler) GetModelInfo(c *gin.Context) {
 	})
 }
 
-// GetAvailableModelTypes returns all available model types
+// GetModelDetails returns the template, system prompt, parameters and modelfile Ollama uses for a model
+func (h *Handler) GetModelDetails(c *gin.Context) {
+	modelName := c.Param("name")
+	if modelName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Model name is required"})
+		return
+	}
+
+	details, err := h.modelService.GetModelDetails(modelName)
+	if errors.Is(err, services.ErrModelNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Model not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error getting model details: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"details": details,
+	})
+}
+
+// GetAvailableModelTypes returns all model types plus the task types each available model supports
 func (h *Handler) GetAvailableModelTypes(c *gin.Context) {
 	types := h.modelService.GetAvailableModelTypes()
+
+	capabilities, err := h.modelService.GetModelCapabilities()
+	if err != nil {
+		log.Printf("Error getting model capabilities: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"model_types": types,
+		"model_types":        types,
+		"model_capabilities": capabilities,
 	})
 }
 
@@ -220,11 +425,83 @@ func (h *Handler) ListDocuments(c *gin.Context) {
 		return
 	}
 
-	log.Printf("Returning %d documents (test_only: %v)", len(documents), testOnly)
+	documents = h.documentService.FilterAccessible(documents, h.accessContext(c))
+	// meta[key]=value filters on user-supplied metadata
+	documents = h.documentService.FilterByUserMetadata(documents, c.QueryMap("meta"))
+
+	// listing=flat|name and dedupe=true|false override the configured presentation
+	layout, dedupe := h.documentService.ListingDefaults()
+	layout = c.DefaultQuery("listing", layout)
+	if value := c.Query("dedupe"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dedupe must be true or false"})
+			return
+		}
+		dedupe = parsed
+	}
+
+	if layout == services.ListingFlat && !dedupe {
+		log.Printf("Returning %d documents (test_only: %v)", len(documents), testOnly)
+		c.JSON(http.StatusOK, gin.H{
+			"documents": documents,
+			"test_only": testOnly,
+			"count":     len(documents),
+		})
+		return
+	}
+
+	listing, err := h.documentService.ArrangeListing(documents, layout, dedupe)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("Returning %d of %d documents (test_only: %v, listing: %s, dedupe: %v)",
+		len(listing), len(documents), testOnly, layout, dedupe)
 	c.JSON(http.StatusOK, gin.H{
-		"documents": documents,
+		"documents": listing,
 		"test_only": testOnly,
-		"count":     len(documents),
+		"count":     len(listing),
+		"total":     len(documents),
+		"listing":   layout,
+		"dedupe":    dedupe,
+	})
+}
+
+// QueryDocuments finds documents matching a combination of filters, with sorting and pagination
+func (h *Handler) QueryDocuments(c *gin.Context) {
+	log.Printf("QueryDocuments requested from %s", c.ClientIP())
+
+	var query types.DocumentQuery
+	if err := c.ShouldBindJSON(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := query.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Text filters extract document content
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
+	result, err := h.documentService.QueryDocuments(query, h.accessContext(c))
+	if err != nil {
+		log.Printf("Error querying documents: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"documents": result.Documents,
+		"total":     result.Total,
+		"count":     len(result.Documents),
+		"offset":    result.Offset,
+		"limit":     result.Limit,
 	})
 }
 
@@ -238,8 +515,71 @@ func (h *Handler) UploadDocument(c *gin.Context) {
 		return
 	}
 
-	log.Printf("Uploading file: %s (%d bytes)", file.Filename, file.Size)
-	document, err := h.documentService.UploadDocument(file)
+	visibility := c.PostForm("visibility")
+	if visibility != "" && !types.IsValidVisibility(visibility) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Visibility must be one of private, shared, public"})
+		return
+	}
+
+	userMetadata, err := services.SanitizeUserMetadata(c.PostFormMap("meta"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	callbackURL := c.PostForm("callback_url")
+	if callbackURL != "" {
+		if err := services.ValidateCallbackURL(callbackURL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	chunkStrategy := c.PostForm("chunk_strategy")
+	if chunkStrategy != "" && !utils.ValidChunkStrategy(chunkStrategy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidChunkStrategy.Error()})
+		return
+	}
+
+	externalID := c.PostForm("external_id")
+	if externalID != "" {
+		if err := services.ValidateExternalID(externalID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Retries must send the same file with the same settings, so the key covers the content
+	var fingerprint string
+	if strings.TrimSpace(c.GetHeader("Idempotency-Key")) != "" {
+		contentHash, err := services.HashUpload(file)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		fingerprint = strings.Join([]string{contentHash, file.Filename, h.accessContext(c).User, visibility, externalID}, "\n")
+	}
+
+	key, proceed := h.beginIdempotent(c, "upload", fingerprint)
+	if !proceed {
+		return
+	}
+	defer h.releaseIdempotent(key)
+
+	log.Printf("Uploading file: %s (%d bytes)", logging.File(file.Filename), file.Size)
+	document, err := h.documentService.UploadDocument(file, h.accessContext(c).User, visibility, externalID, userMetadata)
+	if errors.Is(err, storage.ErrDuplicateExternalID) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrMalwareDetected) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrStorageLimitReached) {
+		c.JSON(http.StatusInsufficientStorage, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		log.Printf("Error uploading document: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -247,16 +587,209 @@ func (h *Handler) UploadDocument(c *gin.Context) {
 	}
 
 	log.Printf("Document uploaded successfully: ID %s", document.ID)
-	c.JSON(http.StatusOK, gin.H{
+
+	if chunkStrategy != "" {
+		if err := h.documentService.SetChunkStrategy(document.ID, chunkStrategy); err != nil {
+			log.Printf("Error setting chunk strategy of %s: %v", document.ID, err)
+		} else if document, err = h.documentService.GetDocument(document.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if c.PostForm("embeddings_only") == "true" {
+		// The document is discarded if ingestion fails
+		if err := h.documentService.IngestEmbeddingsOnly(document.ID); err != nil {
+			log.Printf("Error ingesting embeddings-only document: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if document, err = h.documentService.GetDocument(document.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if callbackURL != "" {
+		if err := h.documentService.ProcessWithCallback(document.ID, callbackURL); err != nil {
+			log.Printf("Error queueing processing callback: %v", err)
+			h.completeIdempotent(c, key, http.StatusOK, gin.H{
+				"message":        "Document uploaded successfully",
+				"document":       document,
+				"callback_error": err.Error(),
+			})
+			return
+		}
+	}
+
+	h.completeIdempotent(c, key, http.StatusOK, gin.H{
 		"message":  "Document uploaded successfully",
 		"document": document,
 	})
 }
 
-func (h *Handler) DeleteDocument(c *gin.Context) {
-	idStr := c.Param("id")
-	if idStr == "" {
+// documentParam returns the internal ID of the document in the :id path parameter, which may
+// also be the external ID the document was uploaded with
+func (h *Handler) documentParam(c *gin.Context) string {
+	id := c.Param("id")
+	if id == "" {
+		return ""
+	}
+	return h.documentService.ResolveDocumentID(id)
+}
+
+// resolveDocumentIDs maps document IDs from a request body, internal or external, to internal IDs
+func (h *Handler) resolveDocumentIDs(ids []string) []string {
+	resolved := make([]string, len(ids))
+	for i, id := range ids {
+		resolved[i] = h.documentService.ResolveDocumentID(id)
+	}
+	return resolved
+}
+
+// accessibleDocument returns the internal ID of the :id document, responding with 400 when it is
+// missing and 404 when the requester can't see it
+func (h *Handler) accessibleDocument(c *gin.Context) (string, bool) {
+	documentID := h.documentParam(c)
+	if documentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Document ID is required"})
+		return "", false
+	}
+	if _, err := h.documentService.GetDocumentsByID([]string{documentID}, h.accessContext(c)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return documentID, true
+}
+
+// ownedDocument is accessibleDocument for changes, responding with 403 when the requester can see
+// the document but isn't allowed to change it
+func (h *Handler) ownedDocument(c *gin.Context) (string, bool) {
+	documentID := h.documentParam(c)
+	if documentID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Document ID is required"})
+		return "", false
+	}
+	access := h.accessContext(c)
+	docs, err := h.documentService.GetDocumentsByID([]string{documentID}, access)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return "", false
+	}
+	if !access.CanModify(&docs[0]) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner of a document can change it"})
+		return "", false
+	}
+	return documentID, true
+}
+
+// documentErrorStatus maps an error reading a document to a response status: 409 when the
+// operation needs the source file of an embeddings-only document, 500 otherwise
+func documentErrorStatus(err error) int {
+	if errors.Is(err, services.ErrSourceRemoved) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// requireAdmin responds with 403 unless the requester is a configured administrator
+func (h *Handler) requireAdmin(c *gin.Context) bool {
+	if h.accessContext(c).IsAdmin {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "Administrator access required"})
+	return false
+}
+
+// GetStorageUsage returns current storage usage against the configured limits
+func (h *Handler) GetStorageUsage(c *gin.Context) {
+	usage, err := h.documentService.GetStorageUsage()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"storage": usage})
+}
+
+// UploadDocumentsBatch uploads all files sent in the "files" form field
+func (h *Handler) UploadDocumentsBatch(c *gin.Context) {
+	log.Printf("UploadDocumentsBatch requested from %s", c.ClientIP())
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		log.Printf("Error parsing multipart form: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid multipart form"})
+		return
+	}
+
+	files := form.File["files"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No files uploaded"})
+		return
+	}
+
+	visibility := c.PostForm("visibility")
+	if visibility != "" && !types.IsValidVisibility(visibility) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Visibility must be one of private, shared, public"})
+		return
+	}
+
+	userMetadata, err := services.SanitizeUserMetadata(c.PostFormMap("meta"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
+	results := h.documentService.UploadDocuments(files, h.accessContext(c).User, visibility, userMetadata)
+
+	succeeded, retried, validationErrors, storageErrors, storageLimited, malwareDetected := 0, 0, 0, 0, 0, 0
+	for _, result := range results {
+		switch result.Outcome {
+		case types.UploadOutcomeSuccess:
+			succeeded++
+		case types.UploadOutcomeRetried:
+			succeeded++
+			retried++
+		case types.UploadOutcomeValidationError:
+			validationErrors++
+		case types.UploadOutcomeStorageError:
+			storageErrors++
+		case types.UploadOutcomeStorageLimit:
+			storageLimited++
+		case types.UploadOutcomeMalwareDetected:
+			malwareDetected++
+		}
+	}
+
+	// Like a single upload, a batch that couldn't store anything for lack of space is a 507
+	status := http.StatusOK
+	if storageLimited > 0 && succeeded == 0 {
+		status = http.StatusInsufficientStorage
+	}
+
+	log.Printf("Batch upload finished: %d of %d files succeeded (%d retried)", succeeded, len(results), retried)
+	c.JSON(status, gin.H{
+		"results":           results,
+		"total":             len(results),
+		"succeeded":         succeeded,
+		"failed":            len(results) - succeeded,
+		"retried":           retried,
+		"validation_errors": validationErrors,
+		"storage_errors":    storageErrors,
+		"storage_limited":   storageLimited,
+		"malware_detected":  malwareDetected,
+	})
+}
+
+func (h *Handler) DeleteDocument(c *gin.Context) {
+	idStr, ok := h.ownedDocument(c)
+	if !ok {
 		return
 	}
 
@@ -265,124 +798,788 @@ func (h *Handler) DeleteDocument(c *gin.Context) {
 		return
 	}
 
+	h.audit(c, idStr, services.AuditEventDelete, "")
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":   "Document deleted successfully",
 		"deletedId": idStr,
 	})
 }
 
-// GetDocumentContent returns the processed content of a document
-func (h *Handler) GetDocumentContent(c *gin.Context) {
-	documentID := c.Param("id")
-	if documentID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Document ID is required"})
+// UpdateDocument changes the status, indexing state, visibility or user metadata of a document
+// (PATCH /documents/:id)
+func (h *Handler) UpdateDocument(c *gin.Context) {
+	documentID, ok := h.ownedDocument(c)
+	if !ok {
 		return
 	}
 
-	content, err := h.documentService.GetDocumentContent(documentID)
-	if err != nil {
-		log.Printf("Error getting document content: %v", err)
+	var update services.DocumentUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	document, err := h.documentService.UpdateDocument(documentID, update)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrInvalidUpdate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	default:
+		log.Printf("Error updating document %s: %v", documentID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"content": content,
-	})
-}
+	h.audit(c, documentID, services.AuditEventUpdate, "")
 
-// GetSupportedDocumentTypes returns all supported document types
-func (h *Handler) GetSupportedDocumentTypes(c *gin.Context) {
-	types := h.documentService.GetSupportedDocumentTypes()
 	c.JSON(http.StatusOK, gin.H{
-		"supported_types": types,
+		"message":  "Document updated successfully",
+		"document": document,
 	})
 }
 
-// GetDocumentProcessingStats returns document processing statistics
-func (h *Handler) GetDocumentProcessingStats(c *gin.Context) {
-	stats := h.documentService.GetDocumentProcessingStats()
-	c.JSON(http.StatusOK, gin.H{
-		"processing_stats": stats,
-	})
-}
+// CreateJob queues a processing job for a document (POST /jobs) and responds with 202 and the
+// job, whose state can then be polled
+func (h *Handler) CreateJob(c *gin.Context) {
+	log.Printf("CreateJob requested from %s", c.ClientIP())
 
-// ProcessMultipleDocuments processes multiple documents in batch
-func (h *Handler) ProcessMultipleDocuments(c *gin.Context) {
 	var req struct {
-		DocumentIDs []string `json:"document_ids" binding:"required"`
+		Type       string `json:"type" binding:"required"` // process, reprocess_pages or embed
+		DocumentID string `json:"document_id" binding:"required"`
+		Pages      string `json:"pages"` // Page range of reprocess_pages jobs, e.g. "3-5,8"
 	}
-
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Get document paths
-	var paths []string
-	for _, id := range req.DocumentIDs {
-		doc, err := h.documentService.GetDocument(id)
-		if err == nil && doc.Path != "" {
-			paths = append(paths, doc.Path)
-		}
+	req.DocumentID = h.documentService.ResolveDocumentID(req.DocumentID)
+	access := h.accessContext(c)
+	docs, err := h.documentService.GetDocumentsByID([]string{req.DocumentID}, access)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
 	}
-
-	if len(paths) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid documents found"})
+	// Reprocessing and embedding replace the document's chunks, so they need the owner
+	modifies := req.Type == services.JobTypeReprocessPages || req.Type == services.JobTypeEmbed
+	if modifies && !access.CanModify(&docs[0]) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner of a document can change it"})
 		return
 	}
 
-	// For now, return a placeholder response with document info
-	c.JSON(http.StatusOK, gin.H{
-		"message":         "Batch processing completed",
-		"processed_paths": paths,
-		"total_files":     len(paths),
-		"note":            "Enhanced batch processing with DocumentManager coming soon",
+	job, err := h.documentService.SubmitJob(req.Type, req.DocumentID, req.Pages, access.User)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrInvalidJob):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrJobQueueFull):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	default:
+		log.Printf("Error submitting %s job for %s: %v", req.Type, req.DocumentID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"job": job})
+}
+
+// GetJob returns the state of a job (GET /jobs/:id)
+func (h *Handler) GetJob(c *gin.Context) {
+	job, err := h.documentService.GetJob(c.Param("id"), h.accessContext(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
+
+// CancelJob cancels a queued or running job (DELETE /jobs/:id). Only the user who submitted it,
+// the owner of its document or an admin may cancel a job.
+func (h *Handler) CancelJob(c *gin.Context) {
+	log.Printf("CancelJob requested from %s", c.ClientIP())
+
+	job, err := h.documentService.CancelJob(c.Param("id"), h.accessContext(c))
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrJobForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrJobFinished):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "job": job})
+		return
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Job cancellation requested",
+		"job":     job,
+	})
+}
+
+// GetDocumentContent returns the processed content of a document
+func (h *Handler) GetDocumentContent(c *gin.Context) {
+	documentID, ok := h.accessibleDocument(c)
+	if !ok {
+		return
+	}
+
+	ctx, ok := h.extractionContext(c)
+	if !ok {
+		return
+	}
+
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
+	content, err := h.documentService.GetDocumentContentContext(ctx, documentID)
+	if err != nil {
+		if c.Request.Context().Err() != nil {
+			log.Printf("Document content request for %s cancelled by client", documentID)
+			return
+		}
+		log.Printf("Error getting document content: %v", err)
+		c.JSON(documentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.audit(c, documentID, services.AuditEventView, "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"content":            content,
+		"extraction_method":  content.ExtractionMethod,
+		"extraction_quality": content.ExtractionQuality,
+	})
+}
+
+// DownloadDocument serves the original file of a document (GET /documents/:id/download)
+func (h *Handler) DownloadDocument(c *gin.Context) {
+	documentID, ok := h.accessibleDocument(c)
+	if !ok {
+		return
+	}
+
+	doc, err := h.documentService.GetDocument(documentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if doc.Path == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": services.ErrSourceRemoved.Error()})
+		return
+	}
+	if _, err := os.Stat(doc.Path); err != nil {
+		log.Printf("Error opening file of %s: %v", documentID, err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "document file not found"})
+		return
+	}
+
+	h.audit(c, documentID, services.AuditEventDownload, "")
+	c.FileAttachment(doc.Path, doc.Name)
+}
+
+// ReprocessDocumentPages re-extracts a page range of a PDF document (POST /documents/:id/reprocess?pages=3-5)
+func (h *Handler) ReprocessDocumentPages(c *gin.Context) {
+	log.Printf("ReprocessDocumentPages requested from %s", c.ClientIP())
+
+	// Reprocessing replaces the document's chunks, so only its owner may do it
+	documentID, ok := h.ownedDocument(c)
+	if !ok {
+		return
+	}
+	pages, err := processors.ParsePageRange(c.Query("pages"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
+	content, err := h.documentService.ReprocessPages(c.Request.Context(), documentID, pages)
+	switch {
+	case err == nil:
+	case c.Request.Context().Err() != nil:
+		log.Printf("Reprocessing pages of %s cancelled by client", documentID)
+		return
+	case errors.Is(err, services.ErrPagesNotSupported), errors.Is(err, services.ErrSourceRemoved):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	default:
+		log.Printf("Error reprocessing pages %s of %s: %v", pages, documentID, err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.audit(c, documentID, services.AuditEventView, "pages "+pages.String())
+
+	c.JSON(http.StatusOK, gin.H{
+		"document_id":        documentID,
+		"pages":              pages.String(),
+		"content":            content,
+		"extraction_method":  content.ExtractionMethod,
+		"extraction_quality": content.ExtractionQuality,
+	})
+}
+
+// SplitDocument copies a page range of a PDF into a new document (POST /documents/:id/split?pages=3-5)
+func (h *Handler) SplitDocument(c *gin.Context) {
+	log.Printf("SplitDocument requested from %s", c.ClientIP())
+
+	documentID := h.documentParam(c)
+	if documentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Document ID is required"})
+		return
+	}
+	pages, err := processors.ParsePageRange(c.Query("pages"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	access := h.accessContext(c)
+	if _, err := h.documentService.GetDocumentsByID([]string{documentID}, access); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
+	document, err := h.documentService.SplitDocument(c.Request.Context(), documentID, pages, access.User)
+	switch {
+	case err == nil:
+	case c.Request.Context().Err() != nil:
+		log.Printf("Splitting %s cancelled by client", documentID)
+		return
+	case errors.Is(err, processors.ErrInvalidPageRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrPagesNotSupported), errors.Is(err, services.ErrSourceRemoved):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrStorageLimitReached):
+		c.JSON(http.StatusInsufficientStorage, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, processors.ErrToolNotInstalled):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		return
+	default:
+		log.Printf("Error splitting pages %s of %s: %v", pages, documentID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.audit(c, documentID, services.AuditEventSplit, fmt.Sprintf("pages %s -> %s", pages, document.ID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Document split successfully",
+		"document": document,
+	})
+}
+
+// MergeDocuments concatenates documents of the same type, in the listed order, into a new document (POST /documents/merge)
+func (h *Handler) MergeDocuments(c *gin.Context) {
+	log.Printf("MergeDocuments requested from %s", c.ClientIP())
+
+	var req struct {
+		DocumentIDs []string `json:"document_ids" binding:"required"`
+		Name        string   `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.DocumentIDs = h.resolveDocumentIDs(req.DocumentIDs)
+	access := h.accessContext(c)
+	if _, err := h.documentService.GetDocumentsByID(req.DocumentIDs, access); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
+	document, err := h.documentService.MergeDocuments(c.Request.Context(), req.DocumentIDs, req.Name, access.User)
+	switch {
+	case err == nil:
+	case c.Request.Context().Err() != nil:
+		log.Printf("Merging %d documents cancelled by client", len(req.DocumentIDs))
+		return
+	case errors.Is(err, services.ErrMergeNotSupported):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrSourceRemoved):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrStorageLimitReached):
+		c.JSON(http.StatusInsufficientStorage, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, processors.ErrToolNotInstalled):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		return
+	default:
+		log.Printf("Error merging documents %v: %v", req.DocumentIDs, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i, documentID := range req.DocumentIDs {
+		h.audit(c, documentID, services.AuditEventMerge, fmt.Sprintf("part %d of %d -> %s", i+1, len(req.DocumentIDs), document.ID))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Documents merged successfully",
+		"document": document,
+	})
+}
+
+// GetSupportedDocumentTypes returns all supported document types
+func (h *Handler) GetSupportedDocumentTypes(c *gin.Context) {
+	types := h.documentService.GetSupportedDocumentTypes()
+	c.JSON(http.StatusOK, gin.H{
+		"supported_types": types,
+		"features":        processors.AvailableFeatures(processors.CheckExternalTools()),
+	})
+}
+
+// GetDocumentProcessingStats returns document processing statistics
+func (h *Handler) GetDocumentProcessingStats(c *gin.Context) {
+	stats := h.documentService.GetDocumentProcessingStats()
+	c.JSON(http.StatusOK, gin.H{
+		"processing_stats": stats,
+		"content_cache":    h.documentService.GetContentCacheStats(),
+	})
+}
+
+// ClearCaches drops all cached extractions and Wikipedia search results (POST /admin/cache/clear)
+func (h *Handler) ClearCaches(c *gin.Context) {
+	log.Printf("ClearCaches requested from %s", c.ClientIP())
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	cleared := h.documentService.ClearContentCache()
+	wikiCleared := h.wikiService.ClearCache()
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Caches cleared successfully",
+		"cleared":      cleared,
+		"wiki_cleared": wikiCleared,
+	})
+}
+
+// ResetDocumentProcessingStats clears the persisted processing statistics
+func (h *Handler) ResetDocumentProcessingStats(c *gin.Context) {
+	log.Printf("ResetDocumentProcessingStats requested from %s", c.ClientIP())
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	h.documentService.ResetDocumentProcessingStats()
+	c.JSON(http.StatusOK, gin.H{"message": "Processing stats reset successfully"})
+}
+
+// GetStaleDocuments lists documents extracted with an outdated processor version
+func (h *Handler) GetStaleDocuments(c *gin.Context) {
+	documents, err := h.documentService.FindStaleDocuments()
+	if err != nil {
+		log.Printf("Error finding stale documents: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	documents = h.documentService.FilterAccessible(documents, h.accessContext(c))
+
+	c.JSON(http.StatusOK, gin.H{
+		"documents": documents,
+		"count":     len(documents),
+	})
+}
+
+// ReprocessStaleDocuments queues stale documents for background reprocessing
+func (h *Handler) ReprocessStaleDocuments(c *gin.Context) {
+	log.Printf("ReprocessStaleDocuments requested from %s", c.ClientIP())
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	queued, err := h.documentService.QueueStaleDocuments()
+	if err != nil {
+		log.Printf("Error queueing stale documents: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Stale documents queued for reprocessing",
+		"queued":  queued,
+	})
+}
+
+// ProcessMultipleDocuments processes multiple documents in batch
+func (h *Handler) ProcessMultipleDocuments(c *gin.Context) {
+	var req struct {
+		DocumentIDs []string `json:"document_ids" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.DocumentIDs = h.resolveDocumentIDs(req.DocumentIDs)
+	if _, err := h.documentService.GetDocumentsByID(req.DocumentIDs, h.accessContext(c)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, ok := h.extractionContext(c)
+	if !ok {
+		return
+	}
+
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
+	result := h.documentService.ProcessDocumentsContext(ctx, req.DocumentIDs)
+
+	processed := make([]string, 0, len(result.Processed))
+	for path := range result.Processed {
+		processed = append(processed, path)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Batch processing completed",
+		"processed_paths": processed,
+		"skipped":         result.Skipped,
+		"failed":          result.Failed,
+		"total_files":     len(req.DocumentIDs),
+		"summary": gin.H{
+			"processed": len(processed),
+			"skipped":   len(result.Skipped),
+			"failed":    len(result.Failed),
+		},
+	})
+}
+
+// Wiki handlers
+func (h *Handler) SearchWiki(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'q' is required"})
+		return
+	}
+
+	results, err := h.wikiService.Search(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// AI Query handler
+func (h *Handler) Query(c *gin.Context) {
+	log.Printf("Query requested from %s", c.ClientIP())
+
+	var req types.QueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding JSON: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	options, err := services.GenerationOptions(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := services.ValidateConversationID(req.ConversationID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.NewConversation && req.ConversationID != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "new_conversation can't be combined with conversation_id"})
+		return
+	}
+
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
+	log.Printf("Processing query: %s", logging.Text(req.Query))
+	startTime := time.Now()
+
+	// Check if AI service has a model loaded
+	if !h.aiService.IsModelLoaded() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No model loaded. Please load a model first."})
+		return
+	}
+
+	documents, wikiResults, ok := h.queryContext(c, req)
+	if !ok {
+		return
+	}
+
+	// Generate AI response with enhanced context
+	owner := h.accessContext(c).User
+	history, ok := h.conversationHistory(c, owner, req.ConversationID)
+	if !ok {
+		return
+	}
+	response, prompt, selection, err := h.aiService.GenerateResponseWithHistory(c.Request.Context(), history, req.Query,
+		documents, wikiResults, req.Language, len(req.DocumentIDs) > 0, options)
+	if c.Request.Context().Err() != nil {
+		log.Printf("Query cancelled by %s", c.ClientIP())
+		return
+	}
+	if errors.Is(err, services.ErrGenerationQueueFull) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error generating AI response: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate response: " + err.Error()})
+		return
+	}
+
+	conversationID := h.recordConversationTurn(owner, req, response)
+
+	processingTime := time.Since(startTime).Seconds()
+
+	// Only the documents that made it into the prompt are reported as sources
+	included := make(map[string]bool, len(selection.Included))
+	for _, id := range selection.Included {
+		included[id] = true
+	}
+	sources := make([]types.Document, 0, len(selection.Included))
+	for _, doc := range documents {
+		if included[doc.ID] {
+			sources = append(sources, doc)
+		}
+	}
+
+	result := types.QueryResponse{
+		Response:       response,
+		ModelUsed:      h.aiService.GetCurrentModel(),
+		ProcessingTime: processingTime,
+		Context:        &selection,
+		ConversationID: conversationID,
+	}
+	result.Sources.Documents = sources
+	result.Sources.Wiki = wikiResults
+
+	if h.aiService.IsDebugAllowed(c.Query("debug") == "true", c.GetHeader("X-Debug-Token"), h.accessContext(c)) {
+		result.Debug = h.aiService.BuildQueryDebug(prompt, sources)
+	}
+
+	log.Printf("Query processed successfully in %.2f seconds with %d of %d documents",
+		processingTime, len(sources), len(documents))
+	c.JSON(http.StatusOK, result)
+}
+
+// QueryStream answers a query like Query but streams the response as server-sent events
+// (POST /query/stream). Every token is sent as a data frame {"token": ...}, followed by a final
+// {"done": true, ...} frame with the model, sources and timing, or {"error": ...} if generation
+// fails midway. Errors before the first token are returned as regular JSON responses.
+func (h *Handler) QueryStream(c *gin.Context) {
+	log.Printf("QueryStream requested from %s", c.ClientIP())
+
+	var req types.QueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	options, err := services.GenerationOptions(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := services.ValidateConversationID(req.ConversationID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.NewConversation && req.ConversationID != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "new_conversation can't be combined with conversation_id"})
+		return
+	}
+
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
+	startTime := time.Now()
+
+	if !h.aiService.IsModelLoaded() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No model loaded. Please load a model first."})
+		return
+	}
+
+	documents, wikiResults, ok := h.queryContext(c, req)
+	if !ok {
+		return
+	}
+
+	streaming := false
+	onToken := func(token string) error {
+		if !streaming {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			c.Status(http.StatusOK)
+			streaming = true
+		}
+		return writeSSEData(c, gin.H{"token": token})
+	}
+
+	owner := h.accessContext(c).User
+	history, ok := h.conversationHistory(c, owner, req.ConversationID)
+	if !ok {
+		return
+	}
+	response, _, selection, err := h.aiService.GenerateResponseStream(c.Request.Context(), history, req.Query,
+		documents, wikiResults, req.Language, len(req.DocumentIDs) > 0, options, onToken)
+	switch {
+	case err == nil:
+	case c.Request.Context().Err() != nil:
+		log.Printf("Streaming query cancelled by %s", c.ClientIP())
+		return
+	case streaming:
+		writeSSEData(c, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrGenerationQueueFull):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate response: " + err.Error()})
+		return
+	}
+
+	conversationID := h.recordConversationTurn(owner, req, response)
+
+	included := make(map[string]bool, len(selection.Included))
+	for _, id := range selection.Included {
+		included[id] = true
+	}
+	sources := make([]types.Document, 0, len(selection.Included))
+	for _, doc := range documents {
+		if included[doc.ID] {
+			sources = append(sources, doc)
+		}
+	}
+
+	if !streaming { // The model produced no text
+		c.Header("Content-Type", "text/event-stream")
+		c.Status(http.StatusOK)
+	}
+	writeSSEData(c, gin.H{
+		"done":            true,
+		"modelUsed":       h.aiService.GetCurrentModel(),
+		"processingTime":  time.Since(startTime).Seconds(),
+		"sources":         gin.H{"documents": sources, "wiki": wikiResults},
+		"context":         selection,
+		"conversation_id": conversationID,
 	})
 }
 
-// Wiki handlers
-func (h *Handler) SearchWiki(c *gin.Context) {
-	query := c.Query("q")
-	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'q' is required"})
-		return
+// conversationHistory returns the prior turns of the conversation a query continues, if any,
+// responding with 404 when owner has no conversation with that ID
+func (h *Handler) conversationHistory(c *gin.Context, owner, conversationID string) ([]types.ChatMessage, bool) {
+	if conversationID == "" {
+		return nil, true
 	}
-
-	results, err := h.wikiService.Search(query)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	history, ok := h.aiService.ConversationHistory(owner, conversationID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return nil, false
 	}
+	return history, true
+}
 
-	c.JSON(http.StatusOK, gin.H{"results": results})
+// recordConversationTurn adds an answered query to the conversation it continues or starts and
+// returns the conversation's ID, "" when the query isn't part of one
+func (h *Handler) recordConversationTurn(owner string, req types.QueryRequest, response string) string {
+	conversationID := req.ConversationID
+	if req.NewConversation {
+		conversationID = h.aiService.StartConversation(owner)
+	}
+	if conversationID != "" {
+		h.aiService.RecordConversationTurn(owner, conversationID, req.Query, response)
+	}
+	return conversationID
 }
 
-// AI Query handler
-func (h *Handler) Query(c *gin.Context) {
-	log.Printf("Query requested from %s", c.ClientIP())
+// DeleteConversation forgets the history of one of the caller's conversations
+// (DELETE /conversations/:id)
+func (h *Handler) DeleteConversation(c *gin.Context) {
+	log.Printf("DeleteConversation requested from %s", c.ClientIP())
 
-	var req types.QueryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("Error binding JSON: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	id := c.Param("id")
+	if !h.aiService.DeleteConversation(h.accessContext(c).User, id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"message": "Conversation deleted", "conversation_id": id})
+}
 
-	log.Printf("Processing query: %s", req.Query)
-	startTime := time.Now()
-
-	// Check if AI service has a model loaded
-	if !h.aiService.IsModelLoaded() {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No model loaded. Please load a model first."})
-		return
+// writeSSEData writes v as a JSON server-sent event data frame and flushes it to the client
+func writeSSEData(c *gin.Context, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", data); err != nil {
+		return err
 	}
+	c.Writer.Flush()
+	return nil
+}
 
+// queryContext gathers the documents and wiki results a query is answered from. It responds with
+// an error and returns false when requested documents are unavailable.
+func (h *Handler) queryContext(c *gin.Context, req types.QueryRequest) ([]types.Document, []types.WikiResult, bool) {
 	// Search documents if requested - ENHANCED TO GET ACTUAL CONTENT
+	access := h.accessContext(c)
 	var documents []types.Document
-	if req.IncludeDocuments {
+	if len(req.DocumentIDs) > 0 {
+		if req.MaxSources > 0 && len(req.DocumentIDs) > req.MaxSources {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("%d documents requested, max_sources is %d", len(req.DocumentIDs), req.MaxSources),
+			})
+			return nil, nil, false
+		}
+
+		docs, err := h.documentService.GetDocumentsByID(h.resolveDocumentIDs(req.DocumentIDs), access)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return nil, nil, false
+		}
+		documents = docs
+		for _, doc := range documents {
+			h.audit(c, doc.ID, services.AuditEventView, req.Query)
+		}
+		log.Printf("📄 Using %d requested documents for AI context", len(documents))
+	} else if req.IncludeDocuments {
 		docs, err := h.documentService.SearchDocuments(req.Query)
 		if err == nil {
+			docs = h.documentService.FilterAccessible(docs, access)
 			// Enhance documents with actual content access
 			for i := range docs {
 				// Ensure document has proper path for content reading
@@ -394,6 +1591,9 @@ func (h *Handler) Query(c *gin.Context) {
 				}
 			}
 			documents = docs
+			for _, doc := range documents {
+				h.audit(c, doc.ID, services.AuditEventSearchHit, req.Query)
+			}
 			log.Printf("📄 Found %d documents for AI context", len(documents))
 		} else {
 			log.Printf("⚠️ Error searching documents: %v", err)
@@ -401,14 +1601,14 @@ func (h *Handler) Query(c *gin.Context) {
 	}
 
 	// If no specific search was done, include demo.txt if it exists
-	if len(documents) == 0 && req.IncludeDocuments {
+	if len(documents) == 0 && req.IncludeDocuments && len(req.DocumentIDs) == 0 {
 		log.Println("🔍 No documents found via search, checking for demo.txt...")
 		allDocs, err := h.documentService.ListDocuments()
 		if err == nil {
-			for _, doc := range allDocs {
+			for _, doc := range h.documentService.FilterAccessible(allDocs, access) {
 				if strings.Contains(strings.ToLower(doc.Name), "demo") {
 					documents = append(documents, doc)
-					log.Printf("📄 Added demo document: %s", doc.Name)
+					log.Printf("📄 Added demo document: %s", logging.Document(doc.Name, doc.ID))
 				}
 			}
 		}
@@ -418,36 +1618,22 @@ func (h *Handler) Query(c *gin.Context) {
 	var wikiResults []types.WikiResult
 	if req.IncludeWiki {
 		wiki, err := h.wikiService.Search(req.Query)
-		if err == nil {
+		if err != nil {
+			log.Printf("⚠️ Wikipedia search failed, answering without it: %v", err)
+		} else {
 			wikiResults = wiki
 		}
 	}
 
-	// Generate AI response with enhanced context
-	response, err := h.aiService.GenerateResponse(req.Query, documents, wikiResults)
-	if err != nil {
-		log.Printf("Error generating AI response: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate response: " + err.Error()})
-		return
-	}
-
-	processingTime := time.Since(startTime).Seconds()
-
-	result := types.QueryResponse{
-		Response:       response,
-		ModelUsed:      h.aiService.GetCurrentModel(),
-		ProcessingTime: processingTime,
-	}
-	result.Sources.Documents = documents
-	result.Sources.Wiki = wikiResults
-
-	log.Printf("Query processed successfully in %.2f seconds with %d documents", processingTime, len(documents))
-	c.JSON(http.StatusOK, result)
+	return documents, wikiResults, true
 }
 
 // Cleanup handlers
 func (h *Handler) CleanupAll(c *gin.Context) {
 	log.Printf("CleanupAll requested from %s", c.ClientIP())
+	if !h.requireAdmin(c) {
+		return
+	}
 
 	if err := h.cleanupService.CleanupAll(); err != nil {
 		log.Printf("Error during cleanup: %v", err)
@@ -460,6 +1646,9 @@ func (h *Handler) CleanupAll(c *gin.Context) {
 
 func (h *Handler) CleanupDocuments(c *gin.Context) {
 	log.Printf("CleanupDocuments requested from %s", c.ClientIP())
+	if !h.requireAdmin(c) {
+		return
+	}
 
 	if err := h.cleanupService.CleanupDocuments(); err != nil {
 		log.Printf("Error during document cleanup: %v", err)
@@ -472,15 +1661,15 @@ func (h *Handler) CleanupDocuments(c *gin.Context) {
 
 // ConvertDocument converts a document to specified format
 func (h *Handler) ConvertDocument(c *gin.Context) {
-	documentID := c.Param("id")
-	if documentID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Document ID is required"})
+	documentID, ok := h.accessibleDocument(c)
+	if !ok {
 		return
 	}
 
 	var req struct {
 		Format     string `json:"format" binding:"required"`
 		OutputPath string `json:"output_path"`
+		Encoding   string `json:"encoding"` // Output encoding, e.g. windows-1252; UTF-8 when empty
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -488,6 +1677,12 @@ func (h *Handler) ConvertDocument(c *gin.Context) {
 		return
 	}
 
+	encoding, err := utils.NormalizeOutputEncoding(req.Encoding)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Generate output path if not provided
 	if req.OutputPath == "" {
 		doc, err := h.documentService.GetDocument(documentID)
@@ -499,35 +1694,55 @@ func (h *Handler) ConvertDocument(c *gin.Context) {
 		req.OutputPath = fmt.Sprintf("./converted/%s.%s", basename, req.Format)
 	}
 
-	err := h.documentService.ConvertDocument(documentID, req.Format, req.OutputPath)
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
+	err = h.documentService.ConvertDocument(documentID, req.Format, req.OutputPath, encoding)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	h.audit(c, documentID, services.AuditEventConvert, req.Format)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":     "Document converted successfully",
 		"output_path": req.OutputPath,
 		"format":      req.Format,
+		"encoding":    encoding,
 	})
 }
 
 // SearchInDocument searches within a specific document
 func (h *Handler) SearchInDocument(c *gin.Context) {
-	documentID := c.Param("id")
 	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'q' is required"})
+		return
+	}
 
-	if documentID == "" || query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Document ID and query are required"})
+	documentID, ok := h.accessibleDocument(c)
+	if !ok {
 		return
 	}
 
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
 	matches, err := h.documentService.SearchInDocumentContent(documentID, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if len(matches) > 0 {
+		h.audit(c, documentID, services.AuditEventSearchHit, query)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"query":       query,
 		"matches":     matches,
@@ -540,6 +1755,9 @@ func (h *Handler) AdvancedSearch(c *gin.Context) {
 	var req struct {
 		Query   string              `json:"query" binding:"required"`
 		Options utils.SearchOptions `json:"options"`
+		// Shorthand for options.offset and options.limit
+		Offset *int `json:"offset"`
+		Limit  *int `json:"limit"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -551,9 +1769,30 @@ func (h *Handler) AdvancedSearch(c *gin.Context) {
 	if req.Options.MaxMatches == 0 {
 		req.Options.MaxMatches = 100
 	}
+	if req.Offset != nil {
+		req.Options.Offset = *req.Offset
+	}
+	if req.Limit != nil {
+		req.Options.Limit = *req.Limit
+	}
+	if req.Options.Offset < 0 || req.Options.Limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset and limit must not be negative"})
+		return
+	}
 
-	results, err := h.documentService.AdvancedSearch(req.Query, req.Options)
-	if err != nil {
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
+	results, err := h.documentService.AdvancedSearch(c.Request.Context(), req.Query, req.Options, h.accessContext(c))
+	if c.Request.Context().Err() != nil {
+		log.Printf("Advanced search cancelled by %s", c.ClientIP())
+		return
+	}
+	// A search that ran out of time still answers with what it found
+	partial := errors.Is(err, context.DeadlineExceeded)
+	if err != nil && !partial {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -562,18 +1801,164 @@ func (h *Handler) AdvancedSearch(c *gin.Context) {
 	searcher := utils.NewDocumentSearcher()
 	stats := searcher.GetSearchStatistics(results)
 
+	// Paged requests get a flat slice of matches instead of every match grouped by document
+	if req.Options.Limit > 0 || req.Options.Offset > 0 {
+		page := searcher.PaginateResults(results, req.Options)
+		c.JSON(http.StatusOK, gin.H{
+			"query":           req.Query,
+			"matches":         page.Matches,
+			"offset":          page.Offset,
+			"limit":           page.Limit,
+			"total_available": page.TotalAvailable,
+			"has_more":        page.HasMore,
+			"statistics":      stats,
+			"partial":         partial,
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"query":      req.Query,
 		"results":    results,
 		"statistics": stats,
+		"partial":    partial,
+	})
+}
+
+// StreamAdvancedSearch runs an advanced search as server-sent events (POST /documents/search/stream).
+// Each document with matches is sent as a "result" event as soon as it is searched, in no particular
+// order, followed by a "done" event with totals.
+func (h *Handler) StreamAdvancedSearch(c *gin.Context) {
+	log.Printf("Streaming search requested from %s", c.ClientIP())
+
+	var req struct {
+		Query   string              `json:"query" binding:"required"`
+		Options utils.SearchOptions `json:"options"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Options.MaxMatches == 0 {
+		req.Options.MaxMatches = 100
+	}
+
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
+	stream, err := h.documentService.StreamAdvancedSearch(c.Request.Context(), req.Query, req.Options, h.accessContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	matchedDocuments, totalMatches := 0, 0
+	for result := range stream.Results {
+		matchedDocuments++
+		totalMatches += result.TotalMatches
+		c.SSEvent("result", result)
+		c.Writer.Flush()
+	}
+
+	if c.Request.Context().Err() != nil {
+		log.Printf("Streaming search cancelled by %s", c.ClientIP())
+		return
+	}
+
+	// Like AdvancedSearch, a timed out search still reports what it found
+	partial := errors.Is(stream.Err(), context.DeadlineExceeded)
+	if partial {
+		log.Printf("⚠️ Streaming search for %s timed out", c.ClientIP())
+		c.SSEvent("error", gin.H{"error": "search timed out, results are incomplete"})
+	}
+
+	c.SSEvent("done", gin.H{
+		"query":              req.Query,
+		"documents_searched": stream.Searched,
+		"documents_matched":  matchedDocuments,
+		"total_matches":      totalMatches,
+		"partial":            partial,
+	})
+	c.Writer.Flush()
+}
+
+// SearchChunks searches stored chunks directly (POST /chunks/search) so retrieval can be checked
+// independently of generation
+func (h *Handler) SearchChunks(c *gin.Context) {
+	log.Printf("Chunk search requested from %s", c.ClientIP())
+
+	var req struct {
+		Query   string              `json:"query" binding:"required"`
+		Mode    string              `json:"mode"` // auto, vector or text
+		Limit   int                 `json:"limit"`
+		Options utils.SearchOptions `json:"options"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
+	results, mode, err := h.documentService.SearchChunks(req.Query, req.Mode, req.Options, req.Limit, h.accessContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":   req.Query,
+		"mode":    mode,
+		"results": results,
+		"count":   len(results),
+	})
+}
+
+// SearchSuggestions returns autocomplete suggestions for a partial search query (GET /search/suggest?q=)
+func (h *Handler) SearchSuggestions(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'q' is required"})
+		return
+	}
+
+	limit := 10
+	if value := c.Query("limit"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			limit = min(parsed, 50)
+		}
+	}
+
+	suggestions := h.documentService.SuggestTerms(query, limit, h.accessContext(c))
+	c.JSON(http.StatusOK, gin.H{
+		"query":       query,
+		"suggestions": suggestions,
 	})
 }
 
 // GetDocumentPreview returns a preview of document content
 func (h *Handler) GetDocumentPreview(c *gin.Context) {
-	documentID := c.Param("id")
-	if documentID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Document ID is required"})
+	documentID, ok := h.accessibleDocument(c)
+	if !ok {
 		return
 	}
 
@@ -584,9 +1969,48 @@ func (h *Handler) GetDocumentPreview(c *gin.Context) {
 		}
 	}
 
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
+	if c.Query("highlight") == "true" {
+		query := c.Query("q")
+		if query == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'q' is required for highlighting"})
+			return
+		}
+
+		options := utils.SearchOptions{
+			CaseSensitive: c.Query("case_sensitive") == "true",
+			UseRegex:      c.Query("use_regex") == "true",
+			BooleanMode:   c.Query("boolean_mode") == "true",
+		}
+		if threshold, err := strconv.Atoi(c.Query("fuzzy_threshold")); err == nil && threshold > 0 {
+			options.FuzzyThreshold = threshold
+		}
+
+		preview, matchCount, err := h.documentService.GetHighlightedPreview(documentID, maxLines, query, options,
+			c.Query("pre_tag"), c.Query("post_tag"))
+		if err != nil {
+			c.JSON(documentErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"document_id": documentID,
+			"preview":     preview,
+			"max_lines":   maxLines,
+			"query":       query,
+			"match_count": matchCount,
+			"highlighted": true,
+		})
+		return
+	}
+
 	preview, err := h.documentService.GetDocumentPreview(documentID, maxLines)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(documentErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -599,15 +2023,19 @@ func (h *Handler) GetDocumentPreview(c *gin.Context) {
 
 // GetDocumentFileInfo returns comprehensive file information
 func (h *Handler) GetDocumentFileInfo(c *gin.Context) {
-	documentID := c.Param("id")
-	if documentID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Document ID is required"})
+	documentID, ok := h.accessibleDocument(c)
+	if !ok {
 		return
 	}
 
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
 	fileInfo, err := h.documentService.GetDocumentFileInfo(documentID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(documentErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -619,15 +2047,19 @@ func (h *Handler) GetDocumentFileInfo(c *gin.Context) {
 
 // GetDocumentAnalysis provides detailed content analysis
 func (h *Handler) GetDocumentAnalysis(c *gin.Context) {
-	documentID := c.Param("id")
-	if documentID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Document ID is required"})
+	documentID, ok := h.accessibleDocument(c)
+	if !ok {
+		return
+	}
+
+	if !h.acquireSlot(c) {
 		return
 	}
+	defer h.limiter.Release()
 
 	analysis, err := h.documentService.GetDocumentAnalysis(documentID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(documentErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -636,9 +2068,97 @@ func (h *Handler) GetDocumentAnalysis(c *gin.Context) {
 	})
 }
 
+// GetDocumentSummary returns document fields, file info, analysis and a preview in one response
+func (h *Handler) GetDocumentSummary(c *gin.Context) {
+	documentID, ok := h.accessibleDocument(c)
+	if !ok {
+		return
+	}
+
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
+	summary, err := h.documentService.GetDocumentSummary(documentID)
+	if err != nil {
+		c.JSON(documentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.audit(c, documentID, services.AuditEventView, "summary")
+
+	c.JSON(http.StatusOK, gin.H{
+		"summary": summary,
+	})
+}
+
+// CompareDocumentStats compares the statistics of two documents side by side
+func (h *Handler) CompareDocumentStats(c *gin.Context) {
+	documentA := c.Query("a")
+	documentB := c.Query("b")
+	if documentA == "" || documentB == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameters 'a' and 'b' are required"})
+		return
+	}
+	documentA = h.documentService.ResolveDocumentID(documentA)
+	documentB = h.documentService.ResolveDocumentID(documentB)
+	if _, err := h.documentService.GetDocumentsByID([]string{documentA, documentB}, h.accessContext(c)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("CompareDocumentStats requested from %s", c.ClientIP())
+
+	if !h.acquireSlot(c) {
+		return
+	}
+	defer h.limiter.Release()
+
+	comparison, err := h.documentService.CompareDocumentStatistics(documentA, documentB)
+	if err != nil {
+		c.JSON(documentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comparison": comparison,
+	})
+}
+
+// GetDocumentAudit returns the audit history of a document
+func (h *Handler) GetDocumentAudit(c *gin.Context) {
+	// The history holds other users' IPs and queries, so only the owner may read it
+	documentID, ok := h.ownedDocument(c)
+	if !ok {
+		return
+	}
+
+	if !h.auditService.IsEnabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Audit logging is disabled"})
+		return
+	}
+
+	history, err := h.auditService.GetDocumentHistory(documentID)
+	if err != nil {
+		log.Printf("Error reading audit history: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"document_id": documentID,
+		"events":      history,
+		"count":       len(history),
+	})
+}
+
 // GetTestDocuments returns only test documents
 func (h *Handler) GetTestDocuments(c *gin.Context) {
 	log.Printf("GetTestDocuments requested from %s", c.ClientIP())
+	if !h.requireAdmin(c) {
+		return
+	}
 
 	documents, err := h.documentService.GetTestDocuments()
 	if err != nil {
@@ -654,9 +2174,12 @@ func (h *Handler) GetTestDocuments(c *gin.Context) {
 	})
 }
 
-// TestDocumentContent endpoint for debugging
+// TestDocumentContent endpoint for debugging; it previews every document, so only admins may call it
 func (h *Handler) TestDocumentContent(c *gin.Context) {
 	log.Printf("TestDocumentContent requested from %s", c.ClientIP())
+	if !h.requireAdmin(c) {
+		return
+	}
 
 	documents, err := h.documentService.ListDocuments()
 	if err != nil {
@@ -670,7 +2193,6 @@ func (h *Handler) TestDocumentContent(c *gin.Context) {
 		result := map[string]interface{}{
 			"name": doc.Name,
 			"id":   doc.ID,
-			"path": doc.Path,
 			"size": doc.Size,
 		}
 
@@ -702,6 +2224,9 @@ func (h *Handler) TestDocumentContent(c *gin.Context) {
 // CleanupTestDocuments cleans only test documents
 func (h *Handler) CleanupTestDocuments(c *gin.Context) {
 	log.Printf("CleanupTestDocuments requested from %s", c.ClientIP())
+	if !h.requireAdmin(c) {
+		return
+	}
 
 	if err := h.documentService.CleanupTestDocuments(); err != nil {
 		log.Printf("Error cleaning test documents: %v", err)
@@ -0,0 +1,51 @@
+// Package logging keeps document content out of the logs unless it is explicitly allowed.
+// Log statements pass document names, file names, queries and snippets through these helpers,
+// which only reveal IDs, file types and lengths while content logging is disabled.
+package logging
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+var documentContent atomic.Bool
+
+// SetDocumentContent allows or forbids logging document content, names and queries
+func SetDocumentContent(enabled bool) {
+	documentContent.Store(enabled)
+}
+
+// DocumentContent reports whether document content may be logged
+func DocumentContent() bool {
+	return documentContent.Load()
+}
+
+// Text returns a query or a content snippet, or only its length when content may not be logged
+func Text(s string) string {
+	if DocumentContent() {
+		return s
+	}
+	return fmt.Sprintf("<%d chars>", utf8.RuneCountInString(s))
+}
+
+// Document returns a document's name, or its ID when content may not be logged
+func Document(name, id string) string {
+	if DocumentContent() {
+		return name
+	}
+	if id == "" {
+		return "<document>"
+	}
+	return "document " + id
+}
+
+// File returns the base name of a file, or only its extension when content may not be logged
+func File(path string) string {
+	if DocumentContent() {
+		return filepath.Base(path)
+	}
+	return "<file>" + strings.ToLower(filepath.Ext(path))
+}
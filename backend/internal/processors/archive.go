@@ -0,0 +1,429 @@
+package processors
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/logging"
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// Default limits of a new ArchiveProcessor
+const (
+	DefaultArchiveMaxEntries = 1000
+	DefaultArchiveMaxBytes   = 200 * 1024 * 1024
+)
+
+// errArchiveBudget is returned when an archive extracts to more than the byte limit
+var errArchiveBudget = errors.New("extracted size limit reached")
+
+// ArchiveProcessor extracts the text of the supported files inside ZIP and gzip archives with the
+// processors of their types. Bad entries (truncated, failing their checksum or not extractable)
+// are skipped and listed in the failed_entries metadata. The number of entries and the bytes
+// extracted are capped so a zip bomb can't exhaust disk or memory.
+type ArchiveProcessor struct {
+	manager    *DocumentManager
+	maxEntries int
+	maxBytes   int64
+}
+
+// ArchiveEntryFailure describes an archive entry that could not be extracted
+type ArchiveEntryFailure struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+func newArchiveProcessor(manager *DocumentManager) *ArchiveProcessor {
+	return &ArchiveProcessor{
+		manager:    manager,
+		maxEntries: DefaultArchiveMaxEntries,
+		maxBytes:   DefaultArchiveMaxBytes,
+	}
+}
+
+// SetArchiveLimits caps the entries read from an archive and the bytes extracted from it;
+// values <= 0 keep the current limit
+func (dm *DocumentManager) SetArchiveLimits(maxEntries int, maxBytes int64) {
+	processor, ok := dm.processors["zip"].(*ArchiveProcessor)
+	if !ok {
+		return
+	}
+	if maxEntries > 0 {
+		processor.maxEntries = maxEntries
+	}
+	if maxBytes > 0 {
+		processor.maxBytes = maxBytes
+	}
+}
+
+func (p *ArchiveProcessor) Read(path string) (*types.DocumentContent, error) {
+	return p.ReadContext(context.Background(), path)
+}
+
+func (p *ArchiveProcessor) ReadContext(ctx context.Context, path string) (*types.DocumentContent, error) {
+	if strings.EqualFold(filepath.Ext(path), ".gz") {
+		return p.readGzip(ctx, path)
+	}
+	return p.readZip(ctx, path)
+}
+
+func (p *ArchiveProcessor) Version() int {
+	return ArchiveProcessorVersion
+}
+
+func (p *ArchiveProcessor) GetSupportedTypes() []string {
+	return []string{"zip", "gz"}
+}
+
+// archiveEntry is a file inside an archive
+type archiveEntry struct {
+	name string
+	open func() (io.ReadCloser, error)
+}
+
+// readZip extracts every supported entry of a ZIP archive
+func (p *ArchiveProcessor) readZip(ctx context.Context, archivePath string) (*types.DocumentContent, error) {
+	log.Printf("🔄 Processing ZIP archive: %s", logging.File(archivePath))
+
+	var files []archiveEntry
+	truncated := false
+	archive, err := zip.OpenReader(archivePath)
+	if err == nil {
+		defer archive.Close()
+		for _, file := range archive.File {
+			if !file.FileInfo().IsDir() {
+				files = append(files, archiveEntry{file.Name, file.Open})
+			}
+		}
+	} else {
+		// A truncated archive loses its central directory first, the entries in front of the
+		// damage can still be found through their local headers
+		log.Printf("⚠️ %s has no readable central directory, recovering entries: %v", logging.File(archivePath), err)
+		files = p.recoverZipEntries(archivePath)
+		if len(files) == 0 {
+			return nil, fmt.Errorf("archive is truncated or corrupt: %w", err)
+		}
+		truncated = true
+	}
+
+	var (
+		text        strings.Builder
+		failures    []ArchiveEntryFailure
+		unsupported int
+		processed   int
+		entries     int
+		remaining   = p.maxBytes
+		limited     bool
+	)
+	for _, entry := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if entries == p.maxEntries {
+			log.Printf("⚠️ %s has more than %d entries, ignoring the rest", logging.File(archivePath), p.maxEntries)
+			limited = true
+			break
+		}
+		entries++
+
+		processor := p.entryProcessor(entry.name)
+		if processor == nil {
+			unsupported++
+			continue
+		}
+		if remaining <= 0 {
+			failures = append(failures, ArchiveEntryFailure{entry.name, errArchiveBudget.Error()})
+			limited = true
+			continue
+		}
+
+		content, n, err := p.extractEntry(ctx, processor, entry.name, remaining, entry.open)
+		remaining -= n
+		if err != nil {
+			log.Printf("⚠️ Skipping %s in %s: %v", logging.File(entry.name), logging.File(archivePath), err)
+			failures = append(failures, ArchiveEntryFailure{entry.name, archiveErrorReason(err)})
+			if errors.Is(err, errArchiveBudget) {
+				limited = true
+			}
+			continue
+		}
+
+		text.WriteString(fmt.Sprintf("=== %s ===\n", entry.name))
+		text.WriteString(content.Text)
+		text.WriteString("\n\n")
+		processed++
+	}
+
+	if processed == 0 {
+		if len(failures) > 0 {
+			return nil, fmt.Errorf("no entry of the archive could be read, first failure: %s: %s",
+				failures[0].Name, failures[0].Reason)
+		}
+		return nil, fmt.Errorf("archive contains no supported files")
+	}
+
+	result := archiveContent(text.String(), "zip", failures)
+	result.Metadata["entry_count"] = strconv.Itoa(entries)
+	result.Metadata["processed_entries"] = strconv.Itoa(processed)
+	result.Metadata["unsupported_entries"] = strconv.Itoa(unsupported)
+	result.Metadata["limit_reached"] = strconv.FormatBool(limited)
+	if truncated {
+		result.Metadata["truncated"] = "true"
+		result.Metadata["status"] = "partial"
+		result.ExtractionQuality = types.ExtractionQualityDegraded
+	}
+	return result, nil
+}
+
+// Signatures of the ZIP records read by recoverZipEntries
+const (
+	zipLocalHeaderSignature    = 0x04034b50
+	zipDataDescriptorSignature = 0x08074b50
+)
+
+// recoverZipEntries walks the local file headers of an archive without a central directory. It
+// stops at the first damaged entry, which is returned with an open func reporting the damage.
+func (p *ArchiveProcessor) recoverZipEntries(archivePath string) []archiveEntry {
+	data, err := os.ReadFile(archivePath)
+	if err != nil {
+		return nil
+	}
+
+	var entries []archiveEntry
+	for off := 0; off+30 <= len(data) && binary.LittleEndian.Uint32(data[off:]) == zipLocalHeaderSignature; {
+		header := data[off:]
+		flags := binary.LittleEndian.Uint16(header[6:])
+		method := binary.LittleEndian.Uint16(header[8:])
+		checksum := binary.LittleEndian.Uint32(header[14:])
+		size := int(binary.LittleEndian.Uint32(header[18:]))
+		nameLen := int(binary.LittleEndian.Uint16(header[26:]))
+		extraLen := int(binary.LittleEndian.Uint16(header[28:]))
+
+		start := off + 30 + nameLen + extraLen
+		if start > len(data) {
+			break
+		}
+		name := string(data[off+30 : off+30+nameLen])
+
+		if flags&0x8 != 0 {
+			// Sizes and checksum follow the data, only a deflate stream knows where it ends
+			if method != zip.Deflate {
+				entries = append(entries, brokenZipEntry(name, errors.New("entry size unknown without the central directory")))
+				break
+			}
+			r := bytes.NewReader(data[start:])
+			n, err := io.Copy(io.Discard, io.LimitReader(flate.NewReader(r), p.maxBytes+1))
+			if err != nil {
+				entries = append(entries, brokenZipEntry(name, err))
+				break
+			}
+			if n > p.maxBytes {
+				entries = append(entries, brokenZipEntry(name, errArchiveBudget))
+				break
+			}
+			size = len(data[start:]) - r.Len()
+
+			descriptor := start + size
+			if descriptor+4 <= len(data) && binary.LittleEndian.Uint32(data[descriptor:]) == zipDataDescriptorSignature {
+				descriptor += 4
+			}
+			if descriptor+12 > len(data) {
+				entries = append(entries, brokenZipEntry(name, io.ErrUnexpectedEOF))
+				break
+			}
+			checksum = binary.LittleEndian.Uint32(data[descriptor:])
+			off = descriptor + 12
+		} else {
+			if start+size > len(data) {
+				entries = append(entries, brokenZipEntry(name, io.ErrUnexpectedEOF))
+				break
+			}
+			off = start + size
+		}
+
+		if strings.HasSuffix(name, "/") {
+			continue // Directory
+		}
+		compressed := data[start : start+size]
+		entries = append(entries, archiveEntry{name, func() (io.ReadCloser, error) {
+			var r io.Reader = bytes.NewReader(compressed)
+			switch method {
+			case zip.Store:
+			case zip.Deflate:
+				r = flate.NewReader(r)
+			default:
+				return nil, zip.ErrAlgorithm
+			}
+			return &crcReader{r: r, hash: crc32.NewIEEE(), want: checksum}, nil
+		}})
+	}
+	return entries
+}
+
+// brokenZipEntry is an entry whose open func reports err
+func brokenZipEntry(name string, err error) archiveEntry {
+	return archiveEntry{name, func() (io.ReadCloser, error) { return nil, err }}
+}
+
+// crcReader returns zip.ErrChecksum at the end of r if the data doesn't match the checksum
+type crcReader struct {
+	r    io.Reader
+	hash hash.Hash32
+	want uint32
+}
+
+func (c *crcReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.hash.Write(b[:n])
+	if err == io.EOF && c.hash.Sum32() != c.want {
+		err = zip.ErrChecksum
+	}
+	return n, err
+}
+
+func (c *crcReader) Close() error {
+	return nil
+}
+
+// readGzip extracts the single file compressed in a gzip archive
+func (p *ArchiveProcessor) readGzip(ctx context.Context, archivePath string) (*types.DocumentContent, error) {
+	log.Printf("🔄 Processing gzip archive: %s", logging.File(archivePath))
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("archive is truncated or corrupt: %w", err)
+	}
+	defer reader.Close()
+
+	// The stored name is optional, the archive's name without .gz is the usual convention
+	name := strings.TrimSuffix(filepath.Base(archivePath), filepath.Ext(archivePath))
+	if reader.Name != "" {
+		name = path.Base(reader.Name)
+	}
+
+	processor := p.entryProcessor(name)
+	if processor == nil {
+		return nil, fmt.Errorf("unsupported file type in gzip archive: %s", name)
+	}
+
+	open := func() (io.ReadCloser, error) { return io.NopCloser(reader), nil }
+	content, _, err := p.extractEntry(ctx, processor, name, p.maxBytes, open)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract %s: %s", name, archiveErrorReason(err))
+	}
+
+	result := archiveContent(content.Text, "gzip", nil)
+	result.Metadata["entry_name"] = name
+	return result, nil
+}
+
+// entryProcessor returns the processor for an archive entry, or nil for unsupported types.
+// Nested archives are not opened so that limits can't be multiplied by nesting.
+func (p *ArchiveProcessor) entryProcessor(name string) DocumentProcessor {
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(name)), ".")
+	processor, ok := p.manager.processors[ext]
+	if !ok || processor == DocumentProcessor(p) {
+		return nil
+	}
+	return processor
+}
+
+// extractEntry copies at most limit bytes of an entry to a temporary file and extracts its text.
+// It returns the bytes copied so the caller can charge them against the archive's budget.
+func (p *ArchiveProcessor) extractEntry(ctx context.Context, processor DocumentProcessor, name string, limit int64,
+	open func() (io.ReadCloser, error)) (content *types.DocumentContent, copied int64, err error) {
+	r, err := open()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer r.Close()
+
+	tmp, err := os.CreateTemp("", "archive-entry-*"+strings.ToLower(path.Ext(name)))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	copied, err = io.Copy(tmp, io.LimitReader(r, limit+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, copied, err
+	}
+	if copied > limit {
+		return nil, limit, errArchiveBudget
+	}
+
+	// A malformed entry must not take the whole archive down with it
+	defer func() {
+		if r := recover(); r != nil {
+			content, err = nil, fmt.Errorf("extraction panicked: %v", r)
+		}
+	}()
+	content, err = readDocument(ctx, processor, tmp.Name())
+	return content, copied, err
+}
+
+// archiveErrorReason describes why an entry failed, naming the usual corruption cases
+func archiveErrorReason(err error) string {
+	switch {
+	case errors.Is(err, zip.ErrChecksum), errors.Is(err, gzip.ErrChecksum):
+		return "checksum mismatch"
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, zip.ErrFormat), errors.Is(err, gzip.ErrHeader):
+		return "truncated or corrupt"
+	case errors.Is(err, zip.ErrAlgorithm):
+		return "unsupported compression method"
+	}
+	return err.Error()
+}
+
+// archiveContent wraps the extracted text of an archive, failed entries degrade its quality
+func archiveContent(text, method string, failures []ArchiveEntryFailure) *types.DocumentContent {
+	metadata := map[string]string{
+		"word_count":  strconv.Itoa(len(strings.Fields(text))),
+		"char_count":  strconv.Itoa(len(text)),
+		"status":      "parsed",
+		"method":      method,
+		"entry_count": "1",
+	}
+	quality := types.ExtractionQualityHigh
+	if len(failures) > 0 {
+		encoded, _ := json.Marshal(failures)
+		metadata["failed_entries"] = string(encoded)
+		metadata["status"] = "partial"
+		quality = types.ExtractionQualityDegraded
+	}
+
+	return &types.DocumentContent{
+		Text:              text,
+		Type:              method,
+		Metadata:          metadata,
+		ExtractionMethod:  method,
+		ExtractionQuality: quality,
+		ProcessedAt:       time.Now(),
+	}
+}
@@ -0,0 +1,85 @@
+package processors
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// BasicReader is implemented by processors with a basic extraction path that doesn't use their
+// parsing library, so a misbehaving library can be routed around
+type BasicReader interface {
+	ReadBasic(path string) (*types.DocumentContent, error)
+}
+
+// basicExtractionKey is the context key of per-request basic extraction types
+type basicExtractionKey struct{}
+
+// WithBasicExtraction returns a context in which documents of the given types are extracted with
+// the basic path, in addition to the types configured with SetBasicExtraction
+func WithBasicExtraction(ctx context.Context, fileTypes []string) context.Context {
+	if len(fileTypes) == 0 {
+		return ctx
+	}
+	forced := make(map[string]bool, len(fileTypes))
+	for _, fileType := range fileTypes {
+		forced[normalizeBasicType(fileType)] = true
+	}
+	return context.WithValue(ctx, basicExtractionKey{}, forced)
+}
+
+// SetBasicExtraction makes documents of the given types always use the basic extraction path
+func (dm *DocumentManager) SetBasicExtraction(fileTypes []string) error {
+	if err := dm.ValidateBasicExtraction(fileTypes); err != nil {
+		return err
+	}
+
+	dm.basicTypes = make(map[string]bool, len(fileTypes))
+	for _, fileType := range fileTypes {
+		dm.basicTypes[normalizeBasicType(fileType)] = true
+	}
+	return nil
+}
+
+// ValidateBasicExtraction checks that every type has a basic extraction path
+func (dm *DocumentManager) ValidateBasicExtraction(fileTypes []string) error {
+	for _, fileType := range fileTypes {
+		if _, ok := dm.processors[normalizeBasicType(fileType)].(BasicReader); !ok {
+			return fmt.Errorf("file type %q has no basic extraction, available: %s",
+				fileType, strings.Join(dm.BasicExtractionTypes(), ", "))
+		}
+	}
+	return nil
+}
+
+// BasicExtractionTypes lists the file types with a basic extraction path
+func (dm *DocumentManager) BasicExtractionTypes() []string {
+	var fileTypes []string
+	for fileType, processor := range dm.processors {
+		if _, ok := processor.(BasicReader); ok {
+			fileTypes = append(fileTypes, fileType)
+		}
+	}
+	sort.Strings(fileTypes)
+	return fileTypes
+}
+
+// useBasicExtraction reports whether config or ctx force basic extraction for any of the
+// processor's types, so "htm" also covers .html files
+func (dm *DocumentManager) useBasicExtraction(ctx context.Context, processor DocumentProcessor) bool {
+	forced, _ := ctx.Value(basicExtractionKey{}).(map[string]bool)
+	for _, fileType := range processor.GetSupportedTypes() {
+		if dm.basicTypes[fileType] || forced[fileType] {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeBasicType lowercases a file type and drops a leading dot
+func normalizeBasicType(fileType string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(fileType), "."))
+}
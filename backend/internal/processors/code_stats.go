@@ -0,0 +1,82 @@
+package processors
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	goFuncPattern     = regexp.MustCompile(`^func\b`)
+	pythonDefPattern  = regexp.MustCompile(`^\s*(async\s+)?def\s+\w+`)
+	jsFunctionPattern = regexp.MustCompile(`\bfunction\b\s*\*?\s*[\w$]*\s*\(|(\([^()]*\)|[\w$]+)\s*=>`)
+	rustFnPattern     = regexp.MustCompile(`^\s*(pub(\([^)]*\))?\s+)?((const|async|unsafe|extern\s+"[^"]*")\s+)*fn\s+\w+`)
+	kotlinFunPattern  = regexp.MustCompile(`\bfun\s+[\w.<>]+\s*\(`)
+	swiftFuncPattern  = regexp.MustCompile(`\bfunc\s+\w+`)
+	scalaDefPattern   = regexp.MustCompile(`\bdef\s+\w+`)
+	todoPattern       = regexp.MustCompile(`\b(TODO|FIXME|XXX)\b`)
+)
+
+// functionPatterns recognize function declarations per file extension
+var functionPatterns = map[string]*regexp.Regexp{
+	".go":    goFuncPattern,
+	".py":    pythonDefPattern,
+	".js":    jsFunctionPattern,
+	".ts":    jsFunctionPattern,
+	".tsx":   jsFunctionPattern,
+	".rs":    rustFnPattern,
+	".kt":    kotlinFunPattern,
+	".swift": swiftFuncPattern,
+	".scala": scalaDefPattern,
+}
+
+// blockCommentExts are the languages with /* ... */ comments
+var blockCommentExts = map[string]bool{
+	".go": true, ".js": true, ".java": true, ".c": true, ".cpp": true,
+	".cs": true, ".php": true, ".css": true, ".sql": true, ".ts": true, ".tsx": true,
+	".rs": true, ".kt": true, ".swift": true, ".scala": true, ".dart": true,
+}
+
+// codeStats counts lines, functions and TODO markers in a source file
+type codeStats struct {
+	ext          string
+	isComment    func(line, ext string) bool
+	inBlock      bool // Inside a /* ... */ comment that started on an earlier line
+	codeLines    int
+	commentLines int
+	emptyLines   int
+	functions    int
+	todos        int
+}
+
+// add counts the next line of the file
+func (s *codeStats) add(line string) {
+	trimmed := strings.TrimSpace(line)
+	s.todos += len(todoPattern.FindAllStringIndex(line, -1))
+
+	switch {
+	case s.inBlock:
+		s.commentLines++
+		s.inBlock = !strings.Contains(trimmed, "*/")
+	case trimmed == "":
+		s.emptyLines++
+	case blockCommentExts[s.ext] && strings.HasPrefix(trimmed, "/*"):
+		s.commentLines++
+		s.inBlock = !strings.Contains(trimmed[2:], "*/")
+	case s.isComment(trimmed, s.ext):
+		s.commentLines++
+	default:
+		s.codeLines++
+		if pattern, ok := functionPatterns[s.ext]; ok {
+			s.functions += len(pattern.FindAllStringIndex(line, -1))
+		}
+		if blockCommentExts[s.ext] {
+			s.inBlock = opensBlockComment(trimmed)
+		}
+	}
+}
+
+// opensBlockComment reports whether a code line ends inside an unterminated /* comment
+func opensBlockComment(line string) bool {
+	start := strings.LastIndex(line, "/*")
+	return start >= 0 && !strings.Contains(line[start+2:], "*/")
+}
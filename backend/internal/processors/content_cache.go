@@ -0,0 +1,182 @@
+package processors
+
+import (
+	"container/list"
+	"fmt"
+	"maps"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// ContentCache keeps recently extracted documents in memory so repeated reads of an unchanged
+// file skip extraction. Entries are evicted least recently used first once either limit is
+// exceeded, and expire after the TTL.
+type ContentCache struct {
+	maxEntries int           // 0 means unlimited
+	maxBytes   int64         // 0 means unlimited
+	ttl        time.Duration // 0 means entries don't expire
+
+	mu      sync.Mutex
+	order   *list.List // Front is the most recently used *cacheEntry
+	entries map[string]*list.Element
+	bytes   int64
+	stats   CacheStats
+}
+
+// cacheEntry is a cached extraction with the size it is accounted at
+type cacheEntry struct {
+	key      string
+	content  *types.DocumentContent
+	size     int64
+	storedAt time.Time
+}
+
+// CacheStats reports the usage of a ContentCache
+type CacheStats struct {
+	Entries    int   `json:"entries"`
+	Bytes      int64 `json:"bytes"`
+	MaxEntries int   `json:"max_entries"`
+	MaxBytes   int64 `json:"max_bytes"`
+	TTLSeconds int   `json:"ttl_seconds"`
+	Hits       int64 `json:"hits"`
+	Misses     int64 `json:"misses"`
+	Evictions  int64 `json:"evictions"` // Entries dropped for the size limits or their TTL
+}
+
+// NewContentCache creates a cache with the given limits, a zero limit leaves that dimension unbounded
+func NewContentCache(maxEntries int, maxBytes int64, ttl time.Duration) *ContentCache {
+	return &ContentCache{
+		maxEntries: max(maxEntries, 0),
+		maxBytes:   max(maxBytes, 0),
+		ttl:        max(ttl, 0),
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// contentCacheKey identifies an extraction of a file, so a modified file misses the cache
+func contentCacheKey(path string, info os.FileInfo, forcedBasic bool) string {
+	return fmt.Sprintf("%s|%d|%d|%t", path, info.Size(), info.ModTime().UnixNano(), forcedBasic)
+}
+
+// Get returns a copy of the cached content for key
+func (cc *ContentCache) Get(key string) (*types.DocumentContent, bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	element, ok := cc.entries[key]
+	if ok && cc.expired(element.Value.(*cacheEntry)) {
+		cc.remove(element)
+		cc.stats.Evictions++
+		ok = false
+	}
+	if !ok {
+		cc.stats.Misses++
+		return nil, false
+	}
+
+	cc.stats.Hits++
+	cc.order.MoveToFront(element)
+	return copyContent(element.Value.(*cacheEntry).content), true
+}
+
+// Put caches a copy of content under key, evicting old entries to stay within the limits.
+// Content larger than the byte limit on its own is not cached.
+func (cc *ContentCache) Put(key string, content *types.DocumentContent) {
+	size := contentSize(content)
+	if cc.maxBytes > 0 && size > cc.maxBytes {
+		return
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if element, ok := cc.entries[key]; ok {
+		cc.remove(element)
+	}
+	entry := &cacheEntry{key: key, content: copyContent(content), size: size, storedAt: time.Now()}
+	cc.entries[key] = cc.order.PushFront(entry)
+	cc.bytes += size
+
+	for cc.order.Len() > 0 && ((cc.maxEntries > 0 && cc.order.Len() > cc.maxEntries) ||
+		(cc.maxBytes > 0 && cc.bytes > cc.maxBytes)) {
+		cc.remove(cc.order.Back())
+		cc.stats.Evictions++
+	}
+}
+
+// Clear drops every entry and returns how many there were
+func (cc *ContentCache) Clear() int {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	cleared := cc.order.Len()
+	cc.order.Init()
+	cc.entries = make(map[string]*list.Element)
+	cc.bytes = 0
+	return cleared
+}
+
+// Stats returns a snapshot of the cache usage, after dropping expired entries
+func (cc *ContentCache) Stats() CacheStats {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	for element := cc.order.Back(); element != nil; {
+		previous := element.Prev()
+		if cc.expired(element.Value.(*cacheEntry)) {
+			cc.remove(element)
+			cc.stats.Evictions++
+		}
+		element = previous
+	}
+
+	stats := cc.stats
+	stats.Entries = cc.order.Len()
+	stats.Bytes = cc.bytes
+	stats.MaxEntries = cc.maxEntries
+	stats.MaxBytes = cc.maxBytes
+	stats.TTLSeconds = int(cc.ttl / time.Second)
+	return stats
+}
+
+func (cc *ContentCache) expired(entry *cacheEntry) bool {
+	return cc.ttl > 0 && time.Since(entry.storedAt) > cc.ttl
+}
+
+// remove drops an entry, the caller holds mu
+func (cc *ContentCache) remove(element *list.Element) {
+	entry := cc.order.Remove(element).(*cacheEntry)
+	delete(cc.entries, entry.key)
+	cc.bytes -= entry.size
+}
+
+// contentSize approximates the memory held by extracted content
+func contentSize(content *types.DocumentContent) int64 {
+	size := int64(len(content.Text) + len(content.PlainText) + len(content.Type) +
+		len(content.ExtractionMethod) + len(content.ExtractionQuality))
+	for key, value := range content.Metadata {
+		size += int64(len(key) + len(value))
+	}
+	return size
+}
+
+// copyContent copies content so callers can't change cached metadata
+func copyContent(content *types.DocumentContent) *types.DocumentContent {
+	contentCopy := *content
+	contentCopy.Metadata = maps.Clone(content.Metadata)
+	return &contentCopy
+}
+
+// SetContentCache caches extracted content of unchanged files, nil disables caching
+func (dm *DocumentManager) SetContentCache(cache *ContentCache) {
+	dm.cache = cache
+}
+
+// ContentCache returns the cache set with SetContentCache, nil when caching is disabled
+func (dm *DocumentManager) ContentCache() *ContentCache {
+	return dm.cache
+}
@@ -0,0 +1,80 @@
+package processors
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+func TestContentCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	text := func(n int) *types.DocumentContent { return &types.DocumentContent{Text: strings.Repeat("x", n)} }
+
+	tests := []struct {
+		name       string
+		maxEntries int
+		maxBytes   int64
+		puts       []string // Keys put in order, each with 10 bytes of text
+		touch      string   // Read after the first two puts so it becomes most recently used
+		want       []string // Keys still cached
+		gone       []string
+	}{
+		{"by entries", 2, 0, []string{"a", "b", "c"}, "a", []string{"a", "c"}, []string{"b"}},
+		{"by bytes", 0, 25, []string{"a", "b", "c"}, "a", []string{"a", "c"}, []string{"b"}},
+		{"unbounded", 0, 0, []string{"a", "b", "c"}, "", []string{"a", "b", "c"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewContentCache(tt.maxEntries, tt.maxBytes, 0)
+			for i, key := range tt.puts {
+				if i == 2 && tt.touch != "" {
+					cache.Get(tt.touch)
+				}
+				cache.Put(key, text(10))
+			}
+
+			for _, key := range tt.want {
+				if _, ok := cache.Get(key); !ok {
+					t.Errorf("%s was evicted", key)
+				}
+			}
+			for _, key := range tt.gone {
+				if _, ok := cache.Get(key); ok {
+					t.Errorf("%s is still cached", key)
+				}
+			}
+			if got := cache.Stats().Evictions; got != int64(len(tt.gone)) {
+				t.Errorf("evictions = %d, want %d", got, len(tt.gone))
+			}
+		})
+	}
+}
+
+func TestContentCacheSkipsContentOverByteLimit(t *testing.T) {
+	cache := NewContentCache(0, 5, 0)
+	cache.Put("big", &types.DocumentContent{Text: "too large"})
+	if _, ok := cache.Get("big"); ok {
+		t.Error("content larger than the byte limit was cached")
+	}
+}
+
+func TestContentCacheExpiresEntries(t *testing.T) {
+	cache := NewContentCache(0, 0, time.Minute)
+	cache.Put("old", &types.DocumentContent{Text: "old"})
+	cache.Put("new", &types.DocumentContent{Text: "new"})
+
+	// Age one entry past the TTL instead of sleeping
+	cache.entries["old"].Value.(*cacheEntry).storedAt = time.Now().Add(-2 * time.Minute)
+
+	if _, ok := cache.Get("old"); ok {
+		t.Error("expired entry was returned")
+	}
+	if _, ok := cache.Get("new"); !ok {
+		t.Error("fresh entry is missing")
+	}
+	if stats := cache.Stats(); stats.Entries != 1 || stats.Evictions != 1 {
+		t.Errorf("entries = %d, evictions = %d, want 1 and 1", stats.Entries, stats.Evictions)
+	}
+}
@@ -0,0 +1,133 @@
+package processors
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Where a document date came from
+const (
+	DateSourceEmbedded = "embedded" // Stored in the document by the authoring application
+	DateSourceFile     = "file"     // The file's modification time
+)
+
+// DocumentDates are a document's creation and modification dates in RFC 3339, empty when unknown
+type DocumentDates struct {
+	Created        string
+	Modified       string
+	CreatedSource  string
+	ModifiedSource string
+}
+
+// ExtractDocumentDates reads the dates embedded in a PDF info dictionary or an Office document's
+// docProps/core.xml, falling back to the file's modification time for dates the format doesn't carry
+func ExtractDocumentDates(path, fileType string) DocumentDates {
+	var dates DocumentDates
+	switch strings.ToLower(strings.TrimPrefix(fileType, ".")) {
+	case "pdf":
+		dates.Created, dates.Modified = pdfInfoDates(path)
+	case "docx", "xlsx", "pptx":
+		dates.Created, dates.Modified = officeCoreDates(path)
+	}
+	if dates.Created != "" {
+		dates.CreatedSource = DateSourceEmbedded
+	}
+	if dates.Modified != "" {
+		dates.ModifiedSource = DateSourceEmbedded
+	}
+
+	if dates.Created == "" || dates.Modified == "" {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return dates
+		}
+		modTime := stat.ModTime().Format(time.RFC3339)
+		if dates.Created == "" {
+			dates.Created, dates.CreatedSource = modTime, DateSourceFile
+		}
+		if dates.Modified == "" {
+			dates.Modified, dates.ModifiedSource = modTime, DateSourceFile
+		}
+	}
+	return dates
+}
+
+// addTo stores the dates in document metadata
+func (d DocumentDates) addTo(metadata map[string]string) {
+	if d.Created != "" {
+		metadata["created_date"] = d.Created
+		metadata["created_date_source"] = d.CreatedSource
+	}
+	if d.Modified != "" {
+		metadata["modified_date"] = d.Modified
+		metadata["modified_date_source"] = d.ModifiedSource
+	}
+}
+
+// pdfInfoDates returns the CreationDate and ModDate of a PDF's info dictionary
+func pdfInfoDates(path string) (string, string) {
+	f, r, err := pdf.Open(path)
+	if err != nil {
+		return "", ""
+	}
+	defer f.Close()
+
+	info := r.Trailer().Key("Info")
+	if info.IsNull() {
+		return "", ""
+	}
+
+	var dates [2]string
+	for i, field := range []string{"CreationDate", "ModDate"} {
+		if t, ok := parsePDFTime(strings.TrimSpace(info.Key(field).Text())); ok {
+			dates[i] = t.Format(time.RFC3339)
+		}
+	}
+	return dates[0], dates[1]
+}
+
+// officeCoreDates returns the dcterms:created and dcterms:modified dates of an Office Open XML document
+func officeCoreDates(path string) (string, string) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return "", ""
+	}
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		if file.Name != "docProps/core.xml" {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return "", ""
+		}
+		defer rc.Close()
+
+		var core struct {
+			Created  string `xml:"created"`
+			Modified string `xml:"modified"`
+		}
+		if err := xml.NewDecoder(rc).Decode(&core); err != nil {
+			return "", ""
+		}
+		return parseW3CDate(core.Created), parseW3CDate(core.Modified)
+	}
+	return "", ""
+}
+
+// parseW3CDate normalizes a W3CDTF date from core.xml to RFC 3339, returning "" if it can't be parsed
+func parseW3CDate(value string) string {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	return ""
+}
@@ -1,993 +1,2504 @@
-package processors
-
-import (
-	"encoding/json"
-	"encoding/xml"
-	"fmt"
-	"io"
-	"log"
-	"os"
-	"path/filepath"
-	"strings"
-	"time"
-
-	"github.com/1DeliDolu/ki-ai-go/pkg/types"
-	"github.com/PuerkitoBio/goquery"
-	"github.com/ledongthuc/pdf"
-	"github.com/nguyenthenguyen/docx"
-)
-
-// DocumentProcessor interface for different document types
-type DocumentProcessor interface {
-	Read(path string) (*types.DocumentContent, error)
-	GetSupportedTypes() []string
-}
-
-// DocumentManager manages different document processors
-type DocumentManager struct {
-	processors map[string]DocumentProcessor
-	stats      ProcessingStats
-}
-
-// ProcessingStats tracks document processing statistics
-type ProcessingStats struct {
-	TotalProcessed     int
-	SuccessfullyParsed int
-	Failed             int
-	TypeCounts         map[string]int
-	LastProcessed      time.Time
-}
-
-// NewDocumentManager creates a new document manager with all processors
-func NewDocumentManager() *DocumentManager {
-	dm := &DocumentManager{
-		processors: make(map[string]DocumentProcessor),
-		stats: ProcessingStats{
-			TypeCounts: make(map[string]int),
-		},
-	}
-
-	// Register basic processors
-	dm.RegisterProcessor(&TXTProcessor{})
-	dm.RegisterProcessor(&MarkdownProcessor{})
-	dm.RegisterProcessor(&HTMLProcessor{})
-
-	// Register advanced processors
-	dm.RegisterProcessor(&PDFProcessor{})
-	dm.RegisterProcessor(&DOCXProcessor{})
-	dm.RegisterProcessor(&JSONProcessor{})
-	dm.RegisterProcessor(&XMLProcessor{})
-	dm.RegisterProcessor(&CSVProcessor{})
-	dm.RegisterProcessor(&LogProcessor{})
-	dm.RegisterProcessor(&CodeProcessor{})
-
-	log.Printf("📄 DocumentManager initialized with %d processors", len(dm.processors))
-	return dm
-}
-
-// RegisterProcessor registers a document processor for specific file types
-func (dm *DocumentManager) RegisterProcessor(processor DocumentProcessor) {
-	types := processor.GetSupportedTypes()
-	for _, t := range types {
-		dm.processors[t] = processor
-	}
-}
-
-// ProcessDocument processes a document based on its file extension with enhanced features
-func (dm *DocumentManager) ProcessDocument(path string) (*types.DocumentContent, error) {
-	log.Printf("🔄 Processing document: %s", filepath.Base(path))
-
-	ext := strings.ToLower(filepath.Ext(path))
-	if strings.HasPrefix(ext, ".") {
-		ext = ext[1:] // Remove the dot
-	}
-
-	processor, exists := dm.processors[ext]
-	if !exists {
-		dm.stats.Failed++
-		return nil, fmt.Errorf("unsupported file type: %s", ext)
-	}
-
-	// Update processing stats
-	dm.stats.TotalProcessed++
-	dm.stats.LastProcessed = time.Now()
-
-	content, err := processor.Read(path)
-	if err != nil {
-		dm.stats.Failed++
-		return nil, fmt.Errorf("failed to process %s: %w", filepath.Base(path), err)
-	}
-
-	// Update success stats
-	dm.stats.SuccessfullyParsed++
-	dm.stats.TypeCounts[ext]++
-
-	log.Printf("✅ Successfully processed %s (%s)", filepath.Base(path), ext)
-	return content, nil
-}
-
-// ProcessMultipleDocuments processes multiple documents and returns results
-func (dm *DocumentManager) ProcessMultipleDocuments(paths []string) map[string]*types.DocumentContent {
-	results := make(map[string]*types.DocumentContent)
-
-	log.Printf("📦 Processing %d documents...", len(paths))
-
-	for _, path := range paths {
-		content, err := dm.ProcessDocument(path)
-		if err != nil {
-			log.Printf("❌ Error processing %s: %v", filepath.Base(path), err)
-			continue
-		}
-		results[path] = content
-	}
-
-	log.Printf("✅ Successfully processed %d out of %d documents", len(results), len(paths))
-	return results
-}
-
-// GetProcessingStats returns current processing statistics
-func (dm *DocumentManager) GetProcessingStats() ProcessingStats {
-	return dm.stats
-}
-
-// ResetStats resets processing statistics
-func (dm *DocumentManager) ResetStats() {
-	dm.stats = ProcessingStats{
-		TypeCounts: make(map[string]int),
-	}
-	log.Println("📊 Processing stats reset")
-}
-
-// GetProcessorInfo returns information about a specific processor
-func (dm *DocumentManager) GetProcessorInfo(fileType string) map[string]interface{} {
-	processor, exists := dm.processors[fileType]
-	if !exists {
-		return map[string]interface{}{
-			"supported": false,
-			"error":     fmt.Sprintf("No processor available for type: %s", fileType),
-		}
-	}
-
-	return map[string]interface{}{
-		"supported":       true,
-		"processor_type":  fmt.Sprintf("%T", processor),
-		"supported_types": processor.GetSupportedTypes(),
-		"processed_count": dm.stats.TypeCounts[fileType],
-	}
-}
-
-// ValidateFile checks if a file can be processed
-func (dm *DocumentManager) ValidateFile(path string) error {
-	// Check if file exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return fmt.Errorf("file does not exist: %s", path)
-	}
-
-	// Check file extension
-	ext := strings.ToLower(filepath.Ext(path))
-	if strings.HasPrefix(ext, ".") {
-		ext = ext[1:]
-	}
-
-	if _, exists := dm.processors[ext]; !exists {
-		return fmt.Errorf("unsupported file type: %s", ext)
-	}
-
-	// Check file size (optional limit)
-	stat, err := os.Stat(path)
-	if err != nil {
-		return fmt.Errorf("cannot read file info: %w", err)
-	}
-
-	// Set a reasonable file size limit (100MB)
-	const maxFileSize = 100 * 1024 * 1024
-	if stat.Size() > maxFileSize {
-		return fmt.Errorf("file too large: %d bytes (max: %d bytes)", stat.Size(), maxFileSize)
-	}
-
-	return nil
-}
-
-// TruncateString helper function for content preview
-func TruncateString(s string, length int) string {
-	if len(s) <= length {
-		return s
-	}
-	return s[:length] + "..."
-}
-
-// GetSupportedExtensions returns all supported file extensions with their processors
-func (dm *DocumentManager) GetSupportedExtensions() map[string]string {
-	extensions := make(map[string]string)
-
-	for ext, processor := range dm.processors {
-		extensions[ext] = fmt.Sprintf("%T", processor)
-	}
-
-	return extensions
-}
-
-// GetSupportedTypes returns all supported file extensions
-func (dm *DocumentManager) GetSupportedTypes() []string {
-	var types []string
-	for ext := range dm.processors {
-		types = append(types, ext)
-	}
-	return types
-}
-
-// TXTProcessor handles plain text files
-type TXTProcessor struct{}
-
-func (p *TXTProcessor) Read(path string) (*types.DocumentContent, error) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read TXT file: %w", err)
-	}
-
-	text := string(content)
-	wordCount := len(strings.Fields(text))
-	lineCount := len(strings.Split(text, "\n"))
-
-	return &types.DocumentContent{
-		Text: text,
-		Type: "txt",
-		Metadata: map[string]string{
-			"word_count": fmt.Sprintf("%d", wordCount),
-			"line_count": fmt.Sprintf("%d", lineCount),
-			"char_count": fmt.Sprintf("%d", len(text)),
-		},
-		ProcessedAt: time.Now(),
-	}, nil
-}
-
-func (p *TXTProcessor) GetSupportedTypes() []string {
-	return []string{"txt", "text"}
-}
-
-// MarkdownProcessor handles markdown files (basic implementation)
-type MarkdownProcessor struct{}
-
-func (p *MarkdownProcessor) Read(path string) (*types.DocumentContent, error) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read Markdown file: %w", err)
-	}
-
-	text := string(content)
-
-	// Count headers (lines starting with #)
-	lines := strings.Split(text, "\n")
-	headerCount := 0
-	for _, line := range lines {
-		if strings.HasPrefix(strings.TrimSpace(line), "#") {
-			headerCount++
-		}
-	}
-
-	return &types.DocumentContent{
-		Text: text,
-		Type: "markdown",
-		Metadata: map[string]string{
-			"word_count":   fmt.Sprintf("%d", len(strings.Fields(text))),
-			"line_count":   fmt.Sprintf("%d", len(lines)),
-			"header_count": fmt.Sprintf("%d", headerCount),
-		},
-		ProcessedAt: time.Now(),
-	}, nil
-}
-
-func (p *MarkdownProcessor) GetSupportedTypes() []string {
-	return []string{"md", "markdown"}
-}
-
-// HTMLProcessor handles HTML files with enhanced extraction
-type HTMLProcessor struct{}
-
-func (p *HTMLProcessor) Read(path string) (*types.DocumentContent, error) {
-	log.Printf("🔄 Processing HTML with enhanced extraction: %s", filepath.Base(path))
-
-	content, err := p.extractHTMLContentAdvanced(path)
-	if err != nil {
-		log.Printf("⚠️ Advanced HTML extraction failed, using basic: %v", err)
-		return p.extractHTMLContentBasic(path)
-	}
-
-	// Get original content for metadata
-	originalContent, _ := os.ReadFile(path)
-	originalText := string(originalContent)
-
-	// Count elements
-	linkCount := strings.Count(strings.ToLower(originalText), "<a ")
-	imgCount := strings.Count(strings.ToLower(originalText), "<img ")
-	headerCount := 0
-	for i := 1; i <= 6; i++ {
-		headerCount += strings.Count(strings.ToLower(originalText), fmt.Sprintf("<h%d", i))
-	}
-
-	// Extract title using goquery
-	title := p.extractTitleAdvanced(path)
-
-	return &types.DocumentContent{
-		Text: content,
-		Type: "html",
-		Metadata: map[string]string{
-			"title":        title,
-			"word_count":   fmt.Sprintf("%d", len(strings.Fields(content))),
-			"char_count":   fmt.Sprintf("%d", len(content)),
-			"link_count":   fmt.Sprintf("%d", linkCount),
-			"image_count":  fmt.Sprintf("%d", imgCount),
-			"header_count": fmt.Sprintf("%d", headerCount),
-			"method":       "goquery",
-			"status":       "advanced_extraction",
-		},
-		ProcessedAt: time.Now(),
-	}, nil
-}
-
-func (p *HTMLProcessor) GetSupportedTypes() []string {
-	return []string{"html", "htm"}
-}
-
-func (p *HTMLProcessor) extractHTMLContentAdvanced(path string) (string, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	doc, err := goquery.NewDocumentFromReader(file)
-	if err != nil {
-		return "", err
-	}
-
-	// Remove script and style elements
-	doc.Find("script, style, noscript").Remove()
-
-	// Extract text content with better formatting
-	var content strings.Builder
-
-	// Get title if exists
-	title := doc.Find("title").First().Text()
-	if title != "" {
-		content.WriteString("TITLE: " + strings.TrimSpace(title) + "\n\n")
-	}
-
-	// Get main content areas
-	body := doc.Find("body")
-	if body.Length() == 0 {
-		// If no body, get all text
-		content.WriteString(strings.TrimSpace(doc.Text()))
-	} else {
-		// Process body content with better structure
-		body.Children().Each(func(i int, s *goquery.Selection) {
-			text := strings.TrimSpace(s.Text())
-			if text != "" {
-				tagName := goquery.NodeName(s)
-				if tagName == "h1" || tagName == "h2" || tagName == "h3" {
-					content.WriteString("\n" + strings.ToUpper(tagName) + ": " + text + "\n")
-				} else if tagName == "p" {
-					content.WriteString(text + "\n\n")
-				} else {
-					content.WriteString(text + "\n")
-				}
-			}
-		})
-	}
-
-	result := content.String()
-	if strings.TrimSpace(result) == "" {
-		return "", fmt.Errorf("no text content extracted")
-	}
-
-	return result, nil
-}
-
-func (p *HTMLProcessor) extractTitleAdvanced(path string) string {
-	file, err := os.Open(path)
-	if err != nil {
-		return ""
-	}
-	defer file.Close()
-
-	doc, err := goquery.NewDocumentFromReader(file)
-	if err != nil {
-		return ""
-	}
-
-	return strings.TrimSpace(doc.Find("title").First().Text())
-}
-
-func (p *HTMLProcessor) extractHTMLContentBasic(path string) (*types.DocumentContent, error) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open HTML file: %w", err)
-	}
-
-	text := string(content)
-	text = p.stripHTMLTags(text)
-
-	// Basic metadata
-	originalContent := string(content)
-	title := p.extractTitle(originalContent)
-
-	return &types.DocumentContent{
-		Text: text,
-		Type: "html",
-		Metadata: map[string]string{
-			"title":      title,
-			"word_count": fmt.Sprintf("%d", len(strings.Fields(text))),
-			"char_count": fmt.Sprintf("%d", len(text)),
-			"method":     "basic",
-			"status":     "fallback_extraction",
-		},
-		ProcessedAt: time.Now(),
-	}, nil
-}
-
-func (p *HTMLProcessor) stripHTMLTags(s string) string {
-	// Simple HTML tag removal
-	var result strings.Builder
-	inTag := false
-
-	for _, char := range s {
-		switch char {
-		case '<':
-			inTag = true
-		case '>':
-			inTag = false
-			result.WriteRune(' ') // Replace tag with space
-		default:
-			if !inTag {
-				result.WriteRune(char)
-			}
-		}
-	}
-
-	// Clean up multiple spaces
-	text := result.String()
-	text = strings.ReplaceAll(text, "\n", " ")
-	text = strings.ReplaceAll(text, "\t", " ")
-
-	// Remove multiple consecutive spaces
-	for strings.Contains(text, "  ") {
-		text = strings.ReplaceAll(text, "  ", " ")
-	}
-
-	return strings.TrimSpace(text)
-}
-
-func (p *HTMLProcessor) extractTitle(content string) string {
-	lower := strings.ToLower(content)
-	start := strings.Index(lower, "<title>")
-	if start == -1 {
-		return ""
-	}
-	start += 7 // len("<title>")
-
-	end := strings.Index(lower[start:], "</title>")
-	if end == -1 {
-		return ""
-	}
-
-	return strings.TrimSpace(content[start : start+end])
-}
-
-// PDFProcessor handles PDF files with real content extraction
-type PDFProcessor struct{}
-
-func (p *PDFProcessor) Read(path string) (*types.DocumentContent, error) {
-	log.Printf("🔄 Processing PDF with external library: %s", filepath.Base(path))
-
-	// Try enhanced PDF extraction first
-	content, err := p.extractPDFContentAdvanced(path)
-	if err != nil {
-		log.Printf("⚠️ Advanced PDF extraction failed, using fallback: %v", err)
-		// Fall back to basic implementation
-		return p.extractPDFContentBasic(path)
-	}
-
-	stat, _ := os.Stat(path)
-	wordCount := len(strings.Fields(content))
-	lineCount := len(strings.Split(content, "\n"))
-
-	return &types.DocumentContent{
-		Text: content,
-		Type: "pdf",
-		Metadata: map[string]string{
-			"file_size":  fmt.Sprintf("%d", stat.Size()),
-			"word_count": fmt.Sprintf("%d", wordCount),
-			"line_count": fmt.Sprintf("%d", lineCount),
-			"char_count": fmt.Sprintf("%d", len(content)),
-			"status":     "advanced_extraction",
-			"method":     "ledongthuc/pdf",
-		},
-		ProcessedAt: time.Now(),
-	}, nil
-}
-
-func (p *PDFProcessor) GetSupportedTypes() []string {
-	return []string{"pdf"}
-}
-
-func (p *PDFProcessor) extractPDFContentAdvanced(path string) (string, error) {
-	f, r, err := pdf.Open(path)
-	if err != nil {
-		return "", fmt.Errorf("failed to open PDF: %w", err)
-	}
-	defer f.Close()
-
-	var content strings.Builder
-	totalPages := r.NumPage()
-
-	log.Printf("📄 PDF has %d pages", totalPages)
-
-	for pageIndex := 1; pageIndex <= totalPages; pageIndex++ {
-		page := r.Page(pageIndex)
-		if page.V.IsNull() {
-			continue
-		}
-
-		// Fix: GetPlainText now requires fonts parameter - pass nil for auto-detection
-		text, err := page.GetPlainText(nil)
-		if err != nil {
-			log.Printf("⚠️ Error reading page %d: %v", pageIndex, err)
-			continue
-		}
-
-		if strings.TrimSpace(text) != "" {
-			content.WriteString(fmt.Sprintf("--- Page %d ---\n", pageIndex))
-			content.WriteString(text)
-			content.WriteString("\n\n")
-		}
-	}
-
-	if content.Len() == 0 {
-		return "", fmt.Errorf("no text content extracted from PDF")
-	}
-
-	return content.String(), nil
-}
-
-func (p *PDFProcessor) extractPDFContentBasic(path string) (*types.DocumentContent, error) {
-	stat, _ := os.Stat(path)
-
-	return &types.DocumentContent{
-		Text: fmt.Sprintf("PDF file detected: %s\nAdvanced PDF extraction failed. File contains %d bytes.\nConsider using a different PDF library for better text extraction.",
-			filepath.Base(path), stat.Size()),
-		Type: "pdf",
-		Metadata: map[string]string{
-			"file_size": fmt.Sprintf("%d", stat.Size()),
-			"status":    "basic_fallback",
-			"method":    "fallback",
-		},
-		ProcessedAt: time.Now(),
-	}, nil
-}
-
-// DOCXProcessor handles Word documents with real content extraction
-type DOCXProcessor struct{}
-
-func (p *DOCXProcessor) Read(path string) (*types.DocumentContent, error) {
-	log.Printf("🔄 Processing DOCX with external library: %s", filepath.Base(path))
-
-	// Try enhanced DOCX extraction first
-	content, err := p.extractDOCXContentAdvanced(path)
-	if err != nil {
-		log.Printf("⚠️ Advanced DOCX extraction failed, using fallback: %v", err)
-		// Fall back to basic implementation
-		return p.extractDOCXContentBasic(path)
-	}
-
-	stat, _ := os.Stat(path)
-	wordCount := len(strings.Fields(content))
-	lineCount := len(strings.Split(content, "\n"))
-
-	return &types.DocumentContent{
-		Text: content,
-		Type: "docx",
-		Metadata: map[string]string{
-			"file_size":  fmt.Sprintf("%d", stat.Size()),
-			"word_count": fmt.Sprintf("%d", wordCount),
-			"line_count": fmt.Sprintf("%d", lineCount),
-			"char_count": fmt.Sprintf("%d", len(content)),
-			"status":     "advanced_extraction",
-			"method":     "nguyenthenguyen/docx",
-		},
-		ProcessedAt: time.Now(),
-	}, nil
-}
-
-func (p *DOCXProcessor) GetSupportedTypes() []string {
-	return []string{"docx", "doc"}
-}
-
-func (p *DOCXProcessor) extractDOCXContentAdvanced(path string) (string, error) {
-	r, err := docx.ReadDocxFile(path)
-	if err != nil {
-		return "", fmt.Errorf("failed to open DOCX: %w", err)
-	}
-	defer r.Close()
-
-	docx1 := r.Editable()
-	content := docx1.GetContent()
-
-	if strings.TrimSpace(content) == "" {
-		return "", fmt.Errorf("no text content extracted from DOCX")
-	}
-
-	// Clean up the content
-	content = strings.ReplaceAll(content, "\r\n", "\n")
-	content = strings.ReplaceAll(content, "\r", "\n")
-
-	// Remove excessive blank lines
-	lines := strings.Split(content, "\n")
-	var cleanLines []string
-	for _, line := range lines {
-		if strings.TrimSpace(line) != "" || len(cleanLines) == 0 || strings.TrimSpace(cleanLines[len(cleanLines)-1]) != "" {
-			cleanLines = append(cleanLines, line)
-		}
-	}
-
-	return strings.Join(cleanLines, "\n"), nil
-}
-
-func (p *DOCXProcessor) extractDOCXContentBasic(path string) (*types.DocumentContent, error) {
-	stat, _ := os.Stat(path)
-
-	return &types.DocumentContent{
-		Text: fmt.Sprintf("DOCX file detected: %s\nAdvanced DOCX extraction failed. File contains %d bytes.\nConsider checking the file format or using a different library.",
-			filepath.Base(path), stat.Size()),
-		Type: "docx",
-		Metadata: map[string]string{
-			"file_size": fmt.Sprintf("%d", stat.Size()),
-			"status":    "basic_fallback",
-			"method":    "fallback",
-		},
-		ProcessedAt: time.Now(),
-	}, nil
-}
-
-// JSONProcessor handles JSON files
-type JSONProcessor struct{}
-
-func (p *JSONProcessor) Read(path string) (*types.DocumentContent, error) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read JSON file: %w", err)
-	}
-
-	text := string(content)
-
-	// Basic JSON validation
-	var jsonData interface{}
-	if err := json.Unmarshal(content, &jsonData); err != nil {
-		return &types.DocumentContent{
-			Text: text,
-			Type: "json",
-			Metadata: map[string]string{
-				"status":     "invalid_json",
-				"error":      err.Error(),
-				"char_count": fmt.Sprintf("%d", len(text)),
-			},
-			ProcessedAt: time.Now(),
-		}, nil
-	}
-
-	// Count JSON elements
-	lineCount := len(strings.Split(text, "\n"))
-
-	return &types.DocumentContent{
-		Text: text,
-		Type: "json",
-		Metadata: map[string]string{
-			"line_count": fmt.Sprintf("%d", lineCount),
-			"char_count": fmt.Sprintf("%d", len(text)),
-			"status":     "valid_json",
-		},
-		ProcessedAt: time.Now(),
-	}, nil
-}
-
-func (p *JSONProcessor) GetSupportedTypes() []string {
-	return []string{"json"}
-}
-
-// XMLProcessor handles XML files
-type XMLProcessor struct{}
-
-func (p *XMLProcessor) Read(path string) (*types.DocumentContent, error) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read XML file: %w", err)
-	}
-
-	text := string(content)
-
-	// Basic XML validation
-	decoder := xml.NewDecoder(strings.NewReader(text))
-	elementCount := 0
-	for {
-		_, err := decoder.Token()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return &types.DocumentContent{
-				Text: text,
-				Type: "xml",
-				Metadata: map[string]string{
-					"status":     "invalid_xml",
-					"error":      err.Error(),
-					"char_count": fmt.Sprintf("%d", len(text)),
-				},
-				ProcessedAt: time.Now(),
-			}, nil
-		}
-		elementCount++
-	}
-
-	return &types.DocumentContent{
-		Text: text,
-		Type: "xml",
-		Metadata: map[string]string{
-			"element_count": fmt.Sprintf("%d", elementCount),
-			"char_count":    fmt.Sprintf("%d", len(text)),
-			"status":        "valid_xml",
-		},
-		ProcessedAt: time.Now(),
-	}, nil
-}
-
-func (p *XMLProcessor) GetSupportedTypes() []string {
-	return []string{"xml"}
-}
-
-// FileTypeDetector helps detect file types (basic implementation)
-func DetectFileType(path string) (string, error) {
-	ext := strings.ToLower(filepath.Ext(path))
-	if strings.HasPrefix(ext, ".") {
-		ext = ext[1:]
-	}
-	return ext, nil
-}
-
-// CSVProcessor handles CSV files - ONLY DECLARATION
-type CSVProcessor struct{}
-
-func (p *CSVProcessor) Read(path string) (*types.DocumentContent, error) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV file: %w", err)
-	}
-
-	text := string(content)
-	lines := strings.Split(text, "\n")
-
-	// Count non-empty lines
-	actualLines := 0
-	for _, line := range lines {
-		if strings.TrimSpace(line) != "" {
-			actualLines++
-		}
-	}
-
-	// Estimate columns from first line
-	columns := 0
-	if len(lines) > 0 && strings.TrimSpace(lines[0]) != "" {
-		columns = len(strings.Split(lines[0], ","))
-	}
-
-	return &types.DocumentContent{
-		Text: text,
-		Type: "csv",
-		Metadata: map[string]string{
-			"lines":          fmt.Sprintf("%d", actualLines),
-			"columns":        fmt.Sprintf("%d", columns),
-			"estimated_rows": fmt.Sprintf("%d", actualLines-1), // minus header
-			"char_count":     fmt.Sprintf("%d", len(text)),
-		},
-		ProcessedAt: time.Now(),
-	}, nil
-}
-
-func (p *CSVProcessor) GetSupportedTypes() []string {
-	return []string{"csv"}
-}
-
-// LogProcessor handles log files - ONLY DECLARATION
-type LogProcessor struct{}
-
-func (p *LogProcessor) Read(path string) (*types.DocumentContent, error) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read log file: %w", err)
-	}
-
-	text := string(content)
-	lines := strings.Split(text, "\n")
-
-	// Count different log levels
-	errorCount := 0
-	warningCount := 0
-	infoCount := 0
-
-	for _, line := range lines {
-		lower := strings.ToLower(line)
-		if strings.Contains(lower, "error") || strings.Contains(lower, "err") {
-			errorCount++
-		} else if strings.Contains(lower, "warning") || strings.Contains(lower, "warn") {
-			warningCount++
-		} else if strings.Contains(lower, "info") {
-			infoCount++
-		}
-	}
-
-	return &types.DocumentContent{
-		Text: text,
-		Type: "log",
-		Metadata: map[string]string{
-			"total_lines":   fmt.Sprintf("%d", len(lines)),
-			"error_lines":   fmt.Sprintf("%d", errorCount),
-			"warning_lines": fmt.Sprintf("%d", warningCount),
-			"info_lines":    fmt.Sprintf("%d", infoCount),
-			"char_count":    fmt.Sprintf("%d", len(text)),
-		},
-		ProcessedAt: time.Now(),
-	}, nil
-}
-
-func (p *LogProcessor) GetSupportedTypes() []string {
-	return []string{"log", "logs"}
-}
-
-// CodeProcessor handles source code files - ONLY DECLARATION
-type CodeProcessor struct{}
-
-func (p *CodeProcessor) Read(path string) (*types.DocumentContent, error) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read code file: %w", err)
-	}
-
-	text := string(content)
-	lines := strings.Split(text, "\n")
-
-	// Count code statistics
-	codeLines := 0
-	commentLines := 0
-	emptyLines := 0
-
-	ext := strings.ToLower(filepath.Ext(path))
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" {
-			emptyLines++
-		} else if p.isCommentLine(trimmed, ext) {
-			commentLines++
-		} else {
-			codeLines++
-		}
-	}
-
-	return &types.DocumentContent{
-		Text: text,
-		Type: "code",
-		Metadata: map[string]string{
-			"total_lines":   fmt.Sprintf("%d", len(lines)),
-			"code_lines":    fmt.Sprintf("%d", codeLines),
-			"comment_lines": fmt.Sprintf("%d", commentLines),
-			"empty_lines":   fmt.Sprintf("%d", emptyLines),
-			"language":      p.detectLanguage(ext),
-			"char_count":    fmt.Sprintf("%d", len(text)),
-		},
-		ProcessedAt: time.Now(),
-	}, nil
-}
-
-func (p *CodeProcessor) isCommentLine(line, ext string) bool {
-	switch ext {
-	case ".go", ".js", ".java", ".c", ".cpp", ".cs":
-		return strings.HasPrefix(line, "//") || strings.HasPrefix(line, "/*")
-	case ".py", ".sh", ".bash":
-		return strings.HasPrefix(line, "#")
-	case ".html", ".xml":
-		return strings.HasPrefix(line, "<!--")
-	default:
-		return strings.HasPrefix(line, "//") || strings.HasPrefix(line, "#")
-	}
-}
-
-func (p *CodeProcessor) detectLanguage(ext string) string {
-	languages := map[string]string{
-		".go":   "Go",
-		".py":   "Python",
-		".js":   "JavaScript",
-		".java": "Java",
-		".c":    "C",
-		".cpp":  "C++",
-		".cs":   "C#",
-		".php":  "PHP",
-		".rb":   "Ruby",
-		".sh":   "Shell",
-		".bash": "Bash",
-		".sql":  "SQL",
-		".html": "HTML",
-		".css":  "CSS",
-		".xml":  "XML",
-	}
-
-	if lang, exists := languages[ext]; exists {
-		return lang
-	}
-	return "Unknown"
-}
-
-func (p *CodeProcessor) GetSupportedTypes() []string {
-	return []string{"go", "py", "js", "java", "c", "cpp", "cs", "php", "rb", "sh", "bash", "sql", "css"}
-}
-
-// SearchInDocument searches for text within a document
-func (dm *DocumentManager) SearchInDocument(path, query string) ([]string, error) {
-	log.Printf("🔍 Searching in document: %s for: %s", filepath.Base(path), query)
-
-	content, err := dm.ProcessDocument(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to process document: %w", err)
-	}
-
-	var matches []string
-	lines := strings.Split(content.Text, "\n")
-
-	for i, line := range lines {
-		if strings.Contains(strings.ToLower(line), strings.ToLower(query)) {
-			// Add context: line number and content
-			match := fmt.Sprintf("Line %d: %s", i+1, strings.TrimSpace(line))
-			matches = append(matches, match)
-		}
-	}
-
-	log.Printf("✅ Found %d matches in %s", len(matches), filepath.Base(path))
-	return matches, nil
-}
-
-// SearchInMultipleDocuments searches for text in multiple documents
-func (dm *DocumentManager) SearchInMultipleDocuments(paths []string, query string) (map[string][]string, error) {
-	log.Printf("🔍 Searching in %d documents for: %s", len(paths), query)
-
-	results := make(map[string][]string)
-
-	for _, path := range paths {
-		matches, err := dm.SearchInDocument(path, query)
-		if err != nil {
-			log.Printf("❌ Error searching %s: %v", filepath.Base(path), err)
-			continue
-		}
-
-		if len(matches) > 0 {
-			results[path] = matches
-		}
-	}
-
-	log.Printf("✅ Search completed. Found matches in %d out of %d documents", len(results), len(paths))
-	return results, nil
-}
-
-// GetDocumentPreview returns a preview of document content
-func (dm *DocumentManager) GetDocumentPreview(path string, maxLines int) (string, error) {
-	content, err := dm.ProcessDocument(path)
-	if err != nil {
-		return "", err
-	}
-
-	lines := strings.Split(content.Text, "\n")
-	if len(lines) <= maxLines {
-		return content.Text, nil
-	}
-
-	preview := strings.Join(lines[:maxLines], "\n")
-	preview += fmt.Sprintf("\n... (%d more lines)", len(lines)-maxLines)
-
-	return preview, nil
-}
+package processors
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/logging"
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+	"github.com/BurntSushi/toml"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/ledongthuc/pdf"
+	"github.com/nguyenthenguyen/docx"
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// DocumentProcessor interface for different document types
+type DocumentProcessor interface {
+	Read(path string) (*types.DocumentContent, error)
+	GetSupportedTypes() []string
+}
+
+// ContextProcessor is implemented by processors that stop extracting once ctx is cancelled
+type ContextProcessor interface {
+	ReadContext(ctx context.Context, path string) (*types.DocumentContent, error)
+}
+
+// VersionedProcessor is implemented by processors that report the version of their extraction logic.
+// Bump a processor's version whenever its output changes so stale documents can be reprocessed.
+type VersionedProcessor interface {
+	Version() int
+}
+
+// Processor versions
+const (
+	TXTProcessorVersion      = 3
+	MarkdownProcessorVersion = 3
+	HTMLProcessorVersion     = 3
+	PDFProcessorVersion      = 4
+	DOCXProcessorVersion     = 1
+	JSONProcessorVersion     = 1
+	XMLProcessorVersion      = 1
+	CSVProcessorVersion      = 2
+	LogProcessorVersion      = 5
+	CodeProcessorVersion     = 5
+	XLSXProcessorVersion     = 1
+	PPTXProcessorVersion     = 1
+	YAMLProcessorVersion     = 1
+	TOMLProcessorVersion     = 1
+	ArchiveProcessorVersion  = 1
+)
+
+// DocumentManager manages different document processors
+type DocumentManager struct {
+	MaxFileSize int64 // Largest file accepted by ValidateFile, in bytes; 0 disables the check
+
+	processors map[string]DocumentProcessor
+	stats      ProcessingStats
+	statsMu    sync.Mutex // Guards stats and statsLastSaved
+	saveMu     sync.Mutex // Serializes writes of the stats file
+	workers    int        // Concurrent documents in ProcessMultipleDocuments
+
+	// File types always extracted with their basic path, see SetBasicExtraction
+	basicTypes map[string]bool
+
+	// Extracted content of unchanged files, nil when caching is disabled
+	cache *ContentCache
+
+	// Optional stats persistence
+	statsPath         string
+	statsSaveInterval time.Duration
+	statsLastSaved    time.Time
+	stopStats         chan struct{}
+}
+
+// ProcessingStats tracks document processing statistics
+type ProcessingStats struct {
+	TotalProcessed     int
+	SuccessfullyParsed int
+	Failed             int
+	TypeCounts         map[string]int
+	LastProcessed      time.Time
+}
+
+// FileOutcome records why a file in a batch was not processed
+type FileOutcome struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// BatchResult separates processed, skipped and failed files of a batch
+type BatchResult struct {
+	Processed map[string]*types.DocumentContent `json:"processed"`
+	Skipped   []FileOutcome                     `json:"skipped"` // Unsupported file types
+	Failed    []FileOutcome                     `json:"failed"`  // Supported but extraction failed
+}
+
+// DefaultMaxFileSize is the file size limit of a new DocumentManager
+const DefaultMaxFileSize = 50 * 1024 * 1024
+
+// NewDocumentManager creates a new document manager with all processors
+func NewDocumentManager() *DocumentManager {
+	dm := &DocumentManager{
+		MaxFileSize: DefaultMaxFileSize,
+		processors:  make(map[string]DocumentProcessor),
+		stats: ProcessingStats{
+			TypeCounts: make(map[string]int),
+		},
+		workers: runtime.NumCPU(),
+	}
+
+	// Register basic processors
+	dm.RegisterProcessor(&TXTProcessor{})
+	dm.RegisterProcessor(&MarkdownProcessor{})
+	dm.RegisterProcessor(&HTMLProcessor{})
+
+	// Register advanced processors
+	dm.RegisterProcessor(&PDFProcessor{})
+	dm.RegisterProcessor(&DOCXProcessor{})
+	dm.RegisterProcessor(&JSONProcessor{})
+	dm.RegisterProcessor(&YAMLProcessor{})
+	dm.RegisterProcessor(&TOMLProcessor{})
+	dm.RegisterProcessor(&XMLProcessor{})
+	dm.RegisterProcessor(&CSVProcessor{})
+	dm.RegisterProcessor(&XLSXProcessor{})
+	dm.RegisterProcessor(&PPTXProcessor{})
+	dm.RegisterProcessor(&LogProcessor{})
+	dm.RegisterProcessor(&CodeProcessor{})
+	dm.RegisterProcessor(newArchiveProcessor(dm))
+
+	log.Printf("📄 DocumentManager initialized with %d processors", len(dm.processors))
+	return dm
+}
+
+// RegisterProcessor registers a document processor for specific file types
+func (dm *DocumentManager) RegisterProcessor(processor DocumentProcessor) {
+	types := processor.GetSupportedTypes()
+	for _, t := range types {
+		dm.processors[t] = processor
+	}
+}
+
+// SetMaxTextBytes caps the text kept in memory by streaming processors; larger files are truncated
+func (dm *DocumentManager) SetMaxTextBytes(limit int64) {
+	for _, processor := range dm.processors {
+		if limiter, ok := processor.(textLimiter); ok {
+			limiter.setMaxTextBytes(limit)
+		}
+	}
+}
+
+// SetMaxFileSize sets the largest file, in bytes, that ValidateFile accepts; 0 disables the check
+func (dm *DocumentManager) SetMaxFileSize(limit int64) {
+	dm.MaxFileSize = limit
+}
+
+// SetCSVDelimiter fixes the CSV delimiter; 0 restores auto-detection
+func (dm *DocumentManager) SetCSVDelimiter(delimiter rune) {
+	if processor, ok := dm.processors["csv"].(*CSVProcessor); ok {
+		processor.delimiter = delimiter
+	}
+}
+
+// SetPDFStrictMode makes failed PDF extractions return an error instead of placeholder text
+func (dm *DocumentManager) SetPDFStrictMode(strict bool) {
+	if processor, ok := dm.processors["pdf"].(*PDFProcessor); ok {
+		processor.StrictMode = strict
+	}
+}
+
+// SetPDFOCR enables OCR for PDFs without a text layer
+func (dm *DocumentManager) SetPDFOCR(enabled bool, language string) {
+	if processor, ok := dm.processors["pdf"].(*PDFProcessor); ok {
+		processor.OCREnabled = enabled
+		processor.OCRLanguage = language
+	}
+}
+
+// ProcessDocument processes a document based on its file extension with enhanced features
+func (dm *DocumentManager) ProcessDocument(path string) (*types.DocumentContent, error) {
+	return dm.ProcessDocumentContext(context.Background(), path)
+}
+
+// ProcessDocumentContext processes a document, abandoning extraction when ctx is cancelled
+func (dm *DocumentManager) ProcessDocumentContext(ctx context.Context, path string) (*types.DocumentContent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("🔄 Processing document: %s", logging.File(path))
+
+	ext, fileType, err := dm.processorFor(path)
+	if err != nil {
+		log.Printf("⚠️ Could not sniff %s, using its extension: %v", logging.File(path), err)
+	}
+	if ext != fileType.Claimed {
+		log.Printf("⚠️ %s looks like %s despite its .%s extension, processing as %s",
+			logging.File(path), fileType.Detected, fileType.Claimed, fileType.Detected)
+	}
+
+	processor, exists := dm.processors[ext]
+	if !exists {
+		dm.statsMu.Lock()
+		dm.stats.Failed++
+		dm.statsMu.Unlock()
+		return nil, fmt.Errorf("unsupported file type: %s", ext)
+	}
+
+	basic, forced := processor.(BasicReader)
+	forced = forced && dm.useBasicExtraction(ctx, processor)
+
+	var cacheKey string
+	if dm.cache != nil {
+		if info, statErr := os.Stat(path); statErr == nil {
+			cacheKey = contentCacheKey(path, info, forced)
+			if cached, ok := dm.cache.Get(cacheKey); ok {
+				log.Printf("📋 Using cached extraction of %s", logging.File(path))
+				return cached, nil
+			}
+		}
+	}
+
+	// Update processing stats
+	dm.statsMu.Lock()
+	dm.stats.TotalProcessed++
+	dm.stats.LastProcessed = time.Now()
+	dm.statsMu.Unlock()
+
+	var content *types.DocumentContent
+	if forced {
+		log.Printf("🔧 Using basic extraction for %s", logging.File(path))
+		content, err = basic.ReadBasic(path)
+	} else {
+		content, err = readDocument(ctx, processor, path)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			log.Printf("⏹️ Processing %s cancelled: %v", logging.File(path), ctx.Err())
+		}
+		dm.statsMu.Lock()
+		dm.stats.Failed++
+		dm.statsMu.Unlock()
+		dm.persistStatsIfDue()
+		return nil, fmt.Errorf("failed to process %s: %w", filepath.Base(path), err)
+	}
+
+	normalizeExtractionInfo(content)
+	if content.Metadata == nil {
+		content.Metadata = make(map[string]string)
+	}
+	content.Metadata["processor_version"] = strconv.Itoa(processorVersion(processor))
+	if forced {
+		content.Metadata["extraction_forced"] = "basic"
+	}
+	ExtractDocumentDates(path, ext).addTo(content.Metadata)
+	if ext != fileType.Claimed {
+		content.Metadata["claimed_type"] = fileType.Claimed
+		content.Metadata["detected_type"] = ext
+	}
+
+	// Update success stats
+	dm.statsMu.Lock()
+	dm.stats.SuccessfullyParsed++
+	dm.stats.TypeCounts[ext]++
+	dm.statsMu.Unlock()
+
+	if cacheKey != "" {
+		dm.cache.Put(cacheKey, content)
+	}
+
+	log.Printf("✅ Successfully processed %s (%s)", logging.File(path), ext)
+	dm.persistStatsIfDue()
+	return content, nil
+}
+
+// readDocument reads path with the processor, passing ctx along when the processor supports it
+func readDocument(ctx context.Context, processor DocumentProcessor, path string) (*types.DocumentContent, error) {
+	if cp, ok := processor.(ContextProcessor); ok {
+		return cp.ReadContext(ctx, path)
+	}
+	return processor.Read(path)
+}
+
+// sameProcessor reports whether two file types are handled by the same processor
+func (dm *DocumentManager) sameProcessor(a, b string) bool {
+	processor, exists := dm.processors[a]
+	if !exists {
+		return false
+	}
+	for _, t := range processor.GetSupportedTypes() {
+		if t == b {
+			return true
+		}
+	}
+	return false
+}
+
+// processorVersion returns the processor's version, treating unversioned processors as version 1
+func processorVersion(processor DocumentProcessor) int {
+	if versioned, ok := processor.(VersionedProcessor); ok {
+		return versioned.Version()
+	}
+	return 1
+}
+
+// ProcessorVersions returns the current processor version for each supported file type
+func (dm *DocumentManager) ProcessorVersions() map[string]int {
+	versions := make(map[string]int, len(dm.processors))
+	for ext, processor := range dm.processors {
+		versions[ext] = processorVersion(processor)
+	}
+	return versions
+}
+
+// processorFor returns the key of the processor that extracts a file: its sniffed type when that
+// has a processor other than the extension's, its extension otherwise. A sniffing error is
+// returned alongside the extension.
+func (dm *DocumentManager) processorFor(path string) (string, FileType, error) {
+	fileType, err := DetectFileType(path)
+	if _, known := dm.processors[fileType.Detected]; known && !dm.sameProcessor(fileType.Claimed, fileType.Detected) {
+		return fileType.Detected, fileType, err
+	}
+	return fileType.Claimed, fileType, err
+}
+
+// ProcessorVersion returns the current version of the processor that extracts a file, chosen
+// like ProcessDocument does, or 0 if the type is unsupported
+func (dm *DocumentManager) ProcessorVersion(path string) int {
+	ext, _, _ := dm.processorFor(path)
+
+	processor, exists := dm.processors[ext]
+	if !exists {
+		return 0
+	}
+	return processorVersion(processor)
+}
+
+// normalizeExtractionInfo fills extraction fields for processors that only set metadata
+func normalizeExtractionInfo(content *types.DocumentContent) {
+	if content.ExtractionMethod == "" {
+		content.ExtractionMethod = content.Metadata["method"]
+		if content.ExtractionMethod == "" {
+			content.ExtractionMethod = "unknown"
+		}
+	}
+
+	if content.ExtractionQuality == "" {
+		status := content.Metadata["status"]
+		if strings.Contains(status, "fallback") || strings.Contains(status, "placeholder") || strings.HasPrefix(status, "invalid") {
+			content.ExtractionQuality = types.ExtractionQualityDegraded
+		} else {
+			content.ExtractionQuality = types.ExtractionQualityHigh
+		}
+	}
+}
+
+// SetWorkers sets how many documents ProcessMultipleDocuments processes at once
+func (dm *DocumentManager) SetWorkers(workers int) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	dm.workers = workers
+}
+
+// ProcessMultipleDocuments processes multiple documents concurrently and reports which were skipped or failed
+func (dm *DocumentManager) ProcessMultipleDocuments(paths []string) *BatchResult {
+	return dm.ProcessMultipleDocumentsContext(context.Background(), paths)
+}
+
+// ProcessMultipleDocumentsContext processes a batch, reporting documents not started before ctx was
+// cancelled as failed
+func (dm *DocumentManager) ProcessMultipleDocumentsContext(ctx context.Context, paths []string) *BatchResult {
+	result := &BatchResult{
+		Processed: make(map[string]*types.DocumentContent),
+		Skipped:   []FileOutcome{},
+		Failed:    []FileOutcome{},
+	}
+
+	log.Printf("📦 Processing %d documents with %d workers...", len(paths), dm.workers)
+
+	var mu sync.Mutex // Guards result
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, dm.workers)
+
+	for _, path := range paths {
+		if dm.ProcessorVersion(path) == 0 {
+			ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+			log.Printf("⚠️ Skipping unsupported file %s", logging.File(path))
+			result.Skipped = append(result.Skipped, FileOutcome{
+				Path:   path,
+				Reason: fmt.Sprintf("unsupported file type: %s", ext),
+			})
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			mu.Lock()
+			result.Failed = append(result.Failed, FileOutcome{Path: path, Reason: err.Error()})
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			content, err := dm.ProcessDocumentContext(ctx, path)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Printf("❌ Error processing %s: %v", logging.File(path), err)
+				result.Failed = append(result.Failed, FileOutcome{Path: path, Reason: err.Error()})
+				return
+			}
+			result.Processed[path] = content
+		}(path)
+	}
+
+	wg.Wait()
+
+	log.Printf("✅ Successfully processed %d out of %d documents (%d skipped, %d failed)",
+		len(result.Processed), len(paths), len(result.Skipped), len(result.Failed))
+	return result
+}
+
+// GetProcessingStats returns a consistent snapshot of the processing statistics
+func (dm *DocumentManager) GetProcessingStats() ProcessingStats {
+	dm.statsMu.Lock()
+	defer dm.statsMu.Unlock()
+	return dm.snapshotStatsLocked()
+}
+
+// snapshotStatsLocked copies the stats, including the type counts map. Callers must hold statsMu.
+func (dm *DocumentManager) snapshotStatsLocked() ProcessingStats {
+	snapshot := dm.stats
+	snapshot.TypeCounts = make(map[string]int, len(dm.stats.TypeCounts))
+	for ext, count := range dm.stats.TypeCounts {
+		snapshot.TypeCounts[ext] = count
+	}
+	return snapshot
+}
+
+// ResetStats resets processing statistics
+func (dm *DocumentManager) ResetStats() {
+	dm.statsMu.Lock()
+	dm.stats = ProcessingStats{
+		TypeCounts: make(map[string]int),
+	}
+	dm.statsMu.Unlock()
+	log.Println("📊 Processing stats reset")
+
+	if dm.statsPath != "" {
+		if err := dm.SaveStats(); err != nil {
+			log.Printf("⚠️ Failed to persist reset stats: %v", err)
+		}
+	}
+}
+
+// EnableStatsPersistence restores stats from path and saves them back at most once per interval,
+// and on Close. Changes are also saved by a background ticker, so stats of documents processed
+// outside ProcessDocument aren't lost when no further document arrives.
+func (dm *DocumentManager) EnableStatsPersistence(path string, interval time.Duration) {
+	dm.statsPath = path
+	dm.statsSaveInterval = interval
+	if interval > 0 && dm.stopStats == nil {
+		dm.stopStats = make(chan struct{})
+		go dm.statsLoop(interval, dm.stopStats)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("⚠️ Failed to read processing stats: %v", err)
+		}
+		return
+	}
+
+	var stats ProcessingStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		log.Printf("⚠️ Ignoring corrupt processing stats file: %v", err)
+		return
+	}
+	if stats.TypeCounts == nil {
+		stats.TypeCounts = make(map[string]int)
+	}
+
+	dm.statsMu.Lock()
+	dm.stats = stats
+	dm.statsMu.Unlock()
+	log.Printf("📊 Restored processing stats (%d processed)", stats.TotalProcessed)
+}
+
+// SaveStats writes the current stats to the persistence file
+func (dm *DocumentManager) SaveStats() error {
+	if dm.statsPath == "" {
+		return fmt.Errorf("stats persistence is not enabled")
+	}
+
+	dm.saveMu.Lock()
+	defer dm.saveMu.Unlock()
+
+	dm.statsMu.Lock()
+	snapshot := dm.snapshotStatsLocked()
+	dm.statsMu.Unlock()
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal stats: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dm.statsPath), 0755); err != nil {
+		return fmt.Errorf("failed to create stats directory: %w", err)
+	}
+
+	// Write to a temp file first so a crash never leaves a half-written stats file
+	tmpPath := dm.statsPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write stats: %w", err)
+	}
+	if err := os.Rename(tmpPath, dm.statsPath); err != nil {
+		return fmt.Errorf("failed to replace stats file: %w", err)
+	}
+
+	dm.statsMu.Lock()
+	dm.statsLastSaved = time.Now()
+	dm.statsMu.Unlock()
+	return nil
+}
+
+// persistStatsIfDue saves stats when persistence is enabled and the save interval has elapsed
+func (dm *DocumentManager) persistStatsIfDue() {
+	if dm.statsPath == "" {
+		return
+	}
+
+	dm.statsMu.Lock()
+	due := time.Since(dm.statsLastSaved) >= dm.statsSaveInterval
+	dm.statsMu.Unlock()
+	if !due {
+		return
+	}
+
+	if err := dm.SaveStats(); err != nil {
+		log.Printf("⚠️ Failed to persist processing stats: %v", err)
+	}
+}
+
+// statsLoop saves stats that changed since the last save every interval until stop is closed
+func (dm *DocumentManager) statsLoop(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			dm.statsMu.Lock()
+			dirty := dm.stats.LastProcessed.After(dm.statsLastSaved)
+			dm.statsMu.Unlock()
+			if !dirty {
+				continue
+			}
+			if err := dm.SaveStats(); err != nil {
+				log.Printf("⚠️ Failed to persist processing stats: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Close stops the stats ticker and saves the stats one last time when persistence is enabled
+func (dm *DocumentManager) Close() {
+	if dm.stopStats != nil {
+		close(dm.stopStats)
+		dm.stopStats = nil
+	}
+	if dm.statsPath == "" {
+		return
+	}
+	if err := dm.SaveStats(); err != nil {
+		log.Printf("⚠️ Failed to persist processing stats: %v", err)
+	} else {
+		log.Printf("📊 Processing stats saved to %s", dm.statsPath)
+	}
+}
+
+// GetProcessorInfo returns information about a specific processor
+func (dm *DocumentManager) GetProcessorInfo(fileType string) map[string]interface{} {
+	processor, exists := dm.processors[fileType]
+	if !exists {
+		return map[string]interface{}{
+			"supported": false,
+			"error":     fmt.Sprintf("No processor available for type: %s", fileType),
+		}
+	}
+
+	dm.statsMu.Lock()
+	processedCount := dm.stats.TypeCounts[fileType]
+	dm.statsMu.Unlock()
+
+	return map[string]interface{}{
+		"supported":       true,
+		"processor_type":  fmt.Sprintf("%T", processor),
+		"supported_types": processor.GetSupportedTypes(),
+		"processed_count": processedCount,
+	}
+}
+
+// ValidateFile checks if a file can be processed
+func (dm *DocumentManager) ValidateFile(path string) error {
+	// Check if file exists
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("file does not exist: %s", path)
+	}
+
+	// Check file extension
+	ext := strings.ToLower(filepath.Ext(path))
+	if strings.HasPrefix(ext, ".") {
+		ext = ext[1:]
+	}
+
+	if _, exists := dm.processors[ext]; !exists {
+		return fmt.Errorf("unsupported file type: %s", ext)
+	}
+
+	// Check file size (optional limit)
+	stat, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("cannot read file info: %w", err)
+	}
+
+	if dm.MaxFileSize > 0 && stat.Size() > dm.MaxFileSize {
+		return fmt.Errorf("file too large: %d bytes (max: %d bytes)", stat.Size(), dm.MaxFileSize)
+	}
+
+	return nil
+}
+
+// TruncateString helper function for content preview
+func TruncateString(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+	// Cut on a rune boundary so multi-byte characters aren't split
+	for length > 0 && !utf8.RuneStart(s[length]) {
+		length--
+	}
+	return s[:length] + "..."
+}
+
+// GetSupportedExtensions returns all supported file extensions with their processors
+func (dm *DocumentManager) GetSupportedExtensions() map[string]string {
+	extensions := make(map[string]string)
+
+	for ext, processor := range dm.processors {
+		extensions[ext] = fmt.Sprintf("%T", processor)
+	}
+
+	return extensions
+}
+
+// GetSupportedTypes returns all supported file extensions
+func (dm *DocumentManager) GetSupportedTypes() []string {
+	var types []string
+	for ext := range dm.processors {
+		types = append(types, ext)
+	}
+	return types
+}
+
+// TXTProcessor handles plain text files
+type TXTProcessor struct {
+	maxTextBytes int64
+}
+
+func (p *TXTProcessor) setMaxTextBytes(limit int64) {
+	p.maxTextBytes = limit
+}
+
+func (p *TXTProcessor) Read(path string) (*types.DocumentContent, error) {
+	return p.ReadContext(context.Background(), path)
+}
+
+func (p *TXTProcessor) ReadContext(ctx context.Context, path string) (*types.DocumentContent, error) {
+	stream, err := streamTextFile(ctx, path, p.maxTextBytes, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TXT file: %w", err)
+	}
+
+	return &types.DocumentContent{
+		Text: stream.Text,
+		Type: "txt",
+		Metadata: stream.textMetadata(map[string]string{
+			"word_count": fmt.Sprintf("%d", stream.Words),
+			"line_count": fmt.Sprintf("%d", stream.Lines),
+			"char_count": fmt.Sprintf("%d", stream.Bytes),
+		}),
+		ExtractionMethod:  "plain_text",
+		ExtractionQuality: types.ExtractionQualityHigh,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+func (p *TXTProcessor) Version() int {
+	return TXTProcessorVersion
+}
+
+func (p *TXTProcessor) GetSupportedTypes() []string {
+	return []string{"txt", "text"}
+}
+
+// MarkdownProcessor handles markdown files (basic implementation)
+type MarkdownProcessor struct{}
+
+func (p *MarkdownProcessor) Read(path string) (*types.DocumentContent, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Markdown file: %w", err)
+	}
+
+	text := string(content)
+
+	lines := strings.Split(text, "\n")
+	outline := MarkdownOutline(text)
+	outlineJSON, err := json.Marshal(outline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Markdown outline: %w", err)
+	}
+
+	return &types.DocumentContent{
+		Text:      text,
+		PlainText: StripMarkdown(text),
+		Type:      "markdown",
+		Metadata: map[string]string{
+			"word_count":   fmt.Sprintf("%d", len(strings.Fields(text))),
+			"line_count":   fmt.Sprintf("%d", len(lines)),
+			"header_count": fmt.Sprintf("%d", len(outline)),
+			"outline":      string(outlineJSON),
+		},
+		ExtractionMethod:  "plain_text",
+		ExtractionQuality: types.ExtractionQualityHigh,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+func (p *MarkdownProcessor) Version() int {
+	return MarkdownProcessorVersion
+}
+
+func (p *MarkdownProcessor) GetSupportedTypes() []string {
+	return []string{"md", "markdown"}
+}
+
+// HTMLProcessor handles HTML files with enhanced extraction
+type HTMLProcessor struct{}
+
+func (p *HTMLProcessor) Read(path string) (*types.DocumentContent, error) {
+	log.Printf("🔄 Processing HTML with enhanced extraction: %s", logging.File(path))
+
+	// Read once; the parsed tree and the fallback both work from these bytes
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open HTML file: %w", err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(raw))
+	if err != nil {
+		log.Printf("⚠️ HTML parsing failed, using basic: %v", err)
+		return p.extractHTMLContentBasic(raw)
+	}
+
+	// Metadata comes from the tree before extraction strips script and style elements
+	metadata := p.extractHTMLMetadata(doc)
+
+	content, tableCount, err := p.extractHTMLContentAdvanced(doc)
+	if err != nil {
+		log.Printf("⚠️ Advanced HTML extraction failed, using basic: %v", err)
+		return p.extractHTMLContentBasic(raw)
+	}
+
+	metadata["word_count"] = fmt.Sprintf("%d", len(strings.Fields(content)))
+	metadata["char_count"] = fmt.Sprintf("%d", len(content))
+	metadata["table_count"] = fmt.Sprintf("%d", tableCount)
+	metadata["method"] = "goquery"
+	metadata["status"] = "advanced_extraction"
+
+	return &types.DocumentContent{
+		Text:              content,
+		Type:              "html",
+		Metadata:          metadata,
+		ExtractionMethod:  "goquery",
+		ExtractionQuality: types.ExtractionQualityHigh,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+// ReadBasic strips the tags without parsing the document tree
+func (p *HTMLProcessor) ReadBasic(path string) (*types.DocumentContent, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open HTML file: %w", err)
+	}
+	return p.extractHTMLContentBasic(raw)
+}
+
+// extractHTMLMetadata reads the title and element counts from a parsed document
+func (p *HTMLProcessor) extractHTMLMetadata(doc *goquery.Document) map[string]string {
+	return map[string]string{
+		"title":        strings.TrimSpace(doc.Find("title").First().Text()),
+		"link_count":   fmt.Sprintf("%d", doc.Find("a").Length()),
+		"image_count":  fmt.Sprintf("%d", doc.Find("img").Length()),
+		"header_count": fmt.Sprintf("%d", doc.Find("h1, h2, h3, h4, h5, h6").Length()),
+	}
+}
+
+func (p *HTMLProcessor) Version() int {
+	return HTMLProcessorVersion
+}
+
+func (p *HTMLProcessor) GetSupportedTypes() []string {
+	return []string{"html", "htm"}
+}
+
+func (p *HTMLProcessor) extractHTMLContentAdvanced(doc *goquery.Document) (string, int, error) {
+	// Remove script and style elements
+	doc.Find("script, style, noscript").Remove()
+
+	// Extract text content with better formatting
+	var content strings.Builder
+
+	// Get title if exists
+	title := doc.Find("title").First().Text()
+	if title != "" {
+		content.WriteString("TITLE: " + strings.TrimSpace(title) + "\n\n")
+	}
+
+	// Get main content areas
+	body := doc.Find("body")
+	if body.Length() == 0 {
+		// If no body, get all text
+		content.WriteString(strings.TrimSpace(doc.Text()))
+	} else {
+		// Process body content with better structure
+		body.Children().Each(func(i int, s *goquery.Selection) {
+			text := strings.TrimSpace(s.Text())
+			if text != "" {
+				tagName := goquery.NodeName(s)
+				if tagName == "h1" || tagName == "h2" || tagName == "h3" {
+					content.WriteString("\n" + strings.ToUpper(tagName) + ": " + text + "\n")
+				} else if tagName == "p" {
+					content.WriteString(text + "\n\n")
+				} else {
+					content.WriteString(text + "\n")
+				}
+			}
+		})
+	}
+
+	// Append tables with their row/column structure intact
+	tables := htmlTables(doc)
+	for i, table := range tables {
+		content.WriteString(fmt.Sprintf("\nTABLE %d:\n%s", i+1, table))
+	}
+
+	result := content.String()
+	if strings.TrimSpace(result) == "" {
+		return "", 0, fmt.Errorf("no text content extracted")
+	}
+
+	return result, len(tables), nil
+}
+
+// maxHTMLColspan caps how many cells a single colspan attribute can pad
+const maxHTMLColspan = 100
+
+// htmlTables renders every table of a document as a Markdown table, nested tables before their parents
+func htmlTables(doc *goquery.Document) []string {
+	var tables []string
+	doc.Find("table").FilterFunction(func(_ int, t *goquery.Selection) bool {
+		return t.ParentsFiltered("table").Length() == 0
+	}).Each(func(_ int, t *goquery.Selection) {
+		renderHTMLTable(t, &tables)
+	})
+	return tables
+}
+
+// renderHTMLTable appends the tables nested in t and then t itself to tables. It returns t's
+// number, or 0 if t has no rows. Cells holding a nested table reference it as [Table N].
+func renderHTMLTable(t *goquery.Selection, tables *[]string) int {
+	type nestedTable struct {
+		table  *goquery.Selection
+		number int
+	}
+	var nested []nestedTable
+	t.Find("table").FilterFunction(func(_ int, inner *goquery.Selection) bool {
+		return inner.Parent().Closest("table").IsSelection(t)
+	}).Each(func(_ int, inner *goquery.Selection) {
+		nested = append(nested, nestedTable{inner, renderHTMLTable(inner, tables)})
+	})
+
+	var rows [][]string
+	width := 0
+	t.Find("tr").FilterFunction(func(_ int, tr *goquery.Selection) bool {
+		return tr.Closest("table").IsSelection(t)
+	}).Each(func(_ int, tr *goquery.Selection) {
+		var row []string
+		tr.ChildrenFiltered("th, td").Each(func(_ int, cell *goquery.Selection) {
+			text := htmlCellText(cell)
+			for _, n := range nested {
+				if n.number > 0 && n.table.Parent().Closest("th, td").IsSelection(cell) {
+					text = strings.TrimSpace(fmt.Sprintf("%s [Table %d]", text, n.number))
+				}
+			}
+			row = append(row, text)
+
+			// Pad spanned columns so later cells stay under their headers
+			span, err := strconv.Atoi(cell.AttrOr("colspan", "1"))
+			for i := 1; err == nil && i < span && i < maxHTMLColspan; i++ {
+				row = append(row, "")
+			}
+		})
+		if len(row) > 0 {
+			rows = append(rows, row)
+			if len(row) > width {
+				width = len(row)
+			}
+		}
+	})
+
+	if len(rows) == 0 {
+		return 0
+	}
+
+	var table strings.Builder
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		table.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			table.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+		}
+	}
+
+	*tables = append(*tables, table.String())
+	return len(*tables)
+}
+
+// htmlCellText returns a cell's text on one line without the text of nested tables
+func htmlCellText(cell *goquery.Selection) string {
+	clone := cell.Clone()
+	clone.Find("table").Remove()
+	text := strings.Join(strings.Fields(clone.Text()), " ")
+	return strings.ReplaceAll(text, "|", "\\|")
+}
+
+func (p *HTMLProcessor) extractHTMLContentBasic(content []byte) (*types.DocumentContent, error) {
+	text := string(content)
+	text = p.stripHTMLTags(text)
+
+	// Basic metadata
+	originalContent := string(content)
+	title := p.extractTitle(originalContent)
+
+	return &types.DocumentContent{
+		Text: text,
+		Type: "html",
+		Metadata: map[string]string{
+			"title":      title,
+			"word_count": fmt.Sprintf("%d", len(strings.Fields(text))),
+			"char_count": fmt.Sprintf("%d", len(text)),
+			"method":     "basic",
+			"status":     "fallback_extraction",
+		},
+		ExtractionMethod:  "basic",
+		ExtractionQuality: types.ExtractionQualityDegraded,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+func (p *HTMLProcessor) stripHTMLTags(s string) string {
+	// Simple HTML tag removal
+	var result strings.Builder
+	inTag := false
+
+	for _, char := range s {
+		switch char {
+		case '<':
+			inTag = true
+		case '>':
+			inTag = false
+			result.WriteRune(' ') // Replace tag with space
+		default:
+			if !inTag {
+				result.WriteRune(char)
+			}
+		}
+	}
+
+	// Clean up multiple spaces
+	text := result.String()
+	text = strings.ReplaceAll(text, "\n", " ")
+	text = strings.ReplaceAll(text, "\t", " ")
+
+	// Remove multiple consecutive spaces
+	for strings.Contains(text, "  ") {
+		text = strings.ReplaceAll(text, "  ", " ")
+	}
+
+	return strings.TrimSpace(text)
+}
+
+func (p *HTMLProcessor) extractTitle(content string) string {
+	lower := strings.ToLower(content)
+	start := strings.Index(lower, "<title>")
+	if start == -1 {
+		return ""
+	}
+	start += 7 // len("<title>")
+
+	end := strings.Index(lower[start:], "</title>")
+	if end == -1 {
+		return ""
+	}
+
+	return strings.TrimSpace(content[start : start+end])
+}
+
+// ErrPDFExtractionFailed is returned by strict PDF processors instead of placeholder text
+var ErrPDFExtractionFailed = errors.New("PDF text extraction failed")
+
+// PDFProcessor handles PDF files with real content extraction
+type PDFProcessor struct {
+	StrictMode  bool     // Return an error instead of placeholder text when extraction fails
+	OCREnabled  bool     // OCR pages when the PDF has no text layer
+	OCRLanguage string   // Tesseract language code, e.g. "eng" or "deu+eng"
+	extractors  []string // Fallback chain order, DefaultPDFExtractors when empty
+}
+
+func (p *PDFProcessor) Read(path string) (*types.DocumentContent, error) {
+	return p.ReadContext(context.Background(), path)
+}
+
+func (p *PDFProcessor) ReadContext(ctx context.Context, path string) (*types.DocumentContent, error) {
+	return p.ReadPages(ctx, path, PageRange{})
+}
+
+// ReadPages extracts the pages in the range, or the whole PDF for the zero range
+func (p *PDFProcessor) ReadPages(ctx context.Context, path string, pages PageRange) (*types.DocumentContent, error) {
+	log.Printf("🔄 Processing PDF with external library: %s", logging.File(path))
+
+	content, err := p.extractorChain(ctx, pages).Run(ctx, path)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrPDFExtractionFailed, err)
+	}
+	return content, nil
+}
+
+// ReadBasic records the PDF without extracting its text
+func (p *PDFProcessor) ReadBasic(path string) (*types.DocumentContent, error) {
+	return p.extractPDFContentBasic(path)
+}
+
+// Extractors lists the PDF extractors that can be placed in the fallback chain
+func (p *PDFProcessor) Extractors() []string {
+	return []string{"ledongthuc", "ocr", "basic"}
+}
+
+func (p *PDFProcessor) setExtractorOrder(names []string) {
+	p.extractors = names
+}
+
+// extractorChain returns the configured chain, leaving out OCR when disabled and the placeholder in
+// strict mode or when extracting a page range
+func (p *PDFProcessor) extractorChain(ctx context.Context, pages PageRange) FallbackChain {
+	available := map[string]Extractor{
+		"ledongthuc": {Name: "ledongthuc", Extract: func(path string) (*types.DocumentContent, error) {
+			return p.extractPDFContentLedongthuc(ctx, path, pages)
+		}},
+	}
+	if p.OCREnabled {
+		available["ocr"] = Extractor{Name: "ocr", Extract: func(path string) (*types.DocumentContent, error) {
+			return p.extractPDFContentOCR(ctx, path, pages)
+		}}
+	}
+	if !p.StrictMode && pages.IsZero() {
+		available["basic"] = Extractor{Name: "basic", Extract: p.extractPDFContentBasic}
+	}
+
+	order := p.extractors
+	if len(order) == 0 {
+		order = DefaultPDFExtractors
+	}
+	return buildChain(order, available)
+}
+
+// extractPDFContentLedongthuc extracts the text layer and document info with ledongthuc/pdf
+func (p *PDFProcessor) extractPDFContentLedongthuc(ctx context.Context, path string, pages PageRange) (*types.DocumentContent, error) {
+	content, info, err := p.extractPDFContentAdvanced(ctx, path, pages)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, _ := os.Stat(path)
+	wordCount := len(strings.Fields(content))
+	lineCount := len(strings.Split(content, "\n"))
+
+	metadata := map[string]string{
+		"file_size":  fmt.Sprintf("%d", stat.Size()),
+		"word_count": fmt.Sprintf("%d", wordCount),
+		"line_count": fmt.Sprintf("%d", lineCount),
+		"char_count": fmt.Sprintf("%d", len(content)),
+		"status":     "advanced_extraction",
+		"method":     "ledongthuc/pdf",
+	}
+	for key, value := range info {
+		metadata[key] = value
+	}
+
+	// Text from a PDF with unreadable pages is incomplete
+	quality := types.ExtractionQualityHigh
+	if info["pages_failed"] != "0" {
+		quality = types.ExtractionQualityDegraded
+	}
+
+	return &types.DocumentContent{
+		Text:              content,
+		Type:              "pdf",
+		Metadata:          metadata,
+		ExtractionMethod:  "ledongthuc/pdf",
+		ExtractionQuality: quality,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+func (p *PDFProcessor) Version() int {
+	return PDFProcessorVersion
+}
+
+func (p *PDFProcessor) GetSupportedTypes() []string {
+	return []string{"pdf"}
+}
+
+func (p *PDFProcessor) extractPDFContentAdvanced(ctx context.Context, path string, pages PageRange) (string, map[string]string, error) {
+	f, r, err := pdf.Open(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+
+	var content strings.Builder
+	totalPages := r.NumPage()
+	info := p.extractPDFInfo(r)
+	info["page_count"] = fmt.Sprintf("%d", totalPages)
+
+	log.Printf("📄 PDF has %d pages", totalPages)
+
+	first, last := 1, totalPages
+	if !pages.IsZero() {
+		if pages.Last > totalPages {
+			return "", nil, fmt.Errorf("pages %s are outside the document's %d pages", pages, totalPages)
+		}
+		first, last = pages.First, pages.Last
+	}
+
+	failedPages := []int{}
+	for pageIndex := first; pageIndex <= last; pageIndex++ {
+		if err := ctx.Err(); err != nil {
+			return "", nil, fmt.Errorf("stopped before page %d: %w", pageIndex, err)
+		}
+
+		page := r.Page(pageIndex)
+		if page.V.IsNull() {
+			log.Printf("⚠️ Page %d is missing from the page tree", pageIndex)
+			failedPages = append(failedPages, pageIndex)
+			continue
+		}
+
+		// Fix: GetPlainText now requires fonts parameter - pass nil for auto-detection
+		text, err := page.GetPlainText(nil)
+		if err != nil {
+			log.Printf("⚠️ Error reading page %d: %v", pageIndex, err)
+			failedPages = append(failedPages, pageIndex)
+			continue
+		}
+
+		if strings.TrimSpace(text) != "" {
+			writePDFPage(&content, pageIndex, text)
+		}
+	}
+
+	if content.Len() == 0 {
+		if len(failedPages) > 0 {
+			return "", nil, fmt.Errorf("no text content extracted from PDF, %d of %d pages failed", len(failedPages), last-first+1)
+		}
+		return "", nil, fmt.Errorf("no text content extracted from PDF")
+	}
+
+	// Empty pages count as extracted, only pages that couldn't be read are failed
+	failedJSON, _ := json.Marshal(failedPages)
+	info["pages_extracted"] = strconv.Itoa(last - first + 1 - len(failedPages))
+	info["pages_failed"] = strconv.Itoa(len(failedPages))
+	info["failed_pages"] = string(failedJSON)
+	if len(failedPages) > 0 {
+		log.Printf("⚠️ %d of %d PDF pages could not be extracted", len(failedPages), last-first+1)
+	}
+
+	return content.String(), info, nil
+}
+
+// extractPDFInfo reads the document info dictionary, omitting fields that are missing or empty
+func (p *PDFProcessor) extractPDFInfo(r *pdf.Reader) map[string]string {
+	info := make(map[string]string)
+
+	dict := r.Trailer().Key("Info")
+	if dict.IsNull() {
+		return info
+	}
+
+	fields := map[string]string{
+		"Author":       "pdf_author",
+		"Title":        "pdf_title",
+		"Subject":      "pdf_subject",
+		"CreationDate": "pdf_creation_date",
+	}
+	for field, key := range fields {
+		value := strings.TrimSpace(dict.Key(field).Text())
+		if value == "" {
+			continue
+		}
+		if field == "CreationDate" {
+			value = parsePDFDate(value)
+		}
+		info[key] = value
+	}
+
+	return info
+}
+
+// parsePDFDate converts a PDF date (D:YYYYMMDDHHmmSSOHH'mm') to RFC 3339, returning the input unchanged if it can't be parsed
+func parsePDFDate(value string) string {
+	if t, ok := parsePDFTime(value); ok {
+		return t.Format(time.RFC3339)
+	}
+	return value
+}
+
+// parsePDFTime parses a PDF date (D:YYYYMMDDHHmmSSOHH'mm')
+func parsePDFTime(value string) (time.Time, bool) {
+	raw := strings.ReplaceAll(strings.TrimPrefix(value, "D:"), "'", "")
+	if i := strings.Index(raw, "Z"); i >= 0 {
+		raw = raw[:i] + "+0000" // UTC, optionally written as Z00'00'
+	}
+
+	for _, layout := range []string{"20060102150405-0700", "20060102150405", "200601021504", "20060102"} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// extractPDFContentOCR recognizes the text of scanned PDFs page by page
+func (p *PDFProcessor) extractPDFContentOCR(ctx context.Context, path string, pageRange PageRange) (*types.DocumentContent, error) {
+	language := p.OCRLanguage
+	if language == "" {
+		language = "eng"
+	}
+
+	log.Printf("🔄 Running OCR on %s (%s)", logging.File(path), language)
+	pages, err := ocrPDF(ctx, path, language, pageRange)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, _ := os.Stat(path)
+	metadata := map[string]string{
+		"file_size":    fmt.Sprintf("%d", stat.Size()),
+		"page_count":   fmt.Sprintf("%d", len(pages)),
+		"status":       "ocr_extraction",
+		"method":       "ocr",
+		"ocr_language": language,
+	}
+
+	var content strings.Builder
+	for _, page := range pages {
+		if page.Confidence >= 0 {
+			metadata[fmt.Sprintf("page_%d_confidence", page.Number)] = fmt.Sprintf("%.1f", page.Confidence)
+		}
+		if strings.TrimSpace(page.Text) == "" {
+			continue
+		}
+		writePDFPage(&content, page.Number, page.Text)
+	}
+
+	if content.Len() == 0 {
+		return nil, fmt.Errorf("OCR found no text in PDF")
+	}
+
+	text := content.String()
+	metadata["word_count"] = fmt.Sprintf("%d", len(strings.Fields(text)))
+	metadata["line_count"] = fmt.Sprintf("%d", len(strings.Split(text, "\n")))
+	metadata["char_count"] = fmt.Sprintf("%d", len(text))
+
+	log.Printf("✅ OCR extracted %d characters from %d pages", len(text), len(pages))
+	return &types.DocumentContent{
+		Text:              text,
+		Type:              "pdf",
+		Metadata:          metadata,
+		ExtractionMethod:  "ocr",
+		ExtractionQuality: types.ExtractionQualityHigh,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+func (p *PDFProcessor) extractPDFContentBasic(path string) (*types.DocumentContent, error) {
+	stat, _ := os.Stat(path)
+
+	return &types.DocumentContent{
+		Text: fmt.Sprintf("PDF file detected: %s\nAdvanced PDF extraction failed. File contains %d bytes.\nConsider using a different PDF library for better text extraction.",
+			filepath.Base(path), stat.Size()),
+		Type: "pdf",
+		Metadata: map[string]string{
+			"file_size": fmt.Sprintf("%d", stat.Size()),
+			"status":    "basic_fallback",
+			"method":    "fallback",
+		},
+		ExtractionMethod:  "fallback",
+		ExtractionQuality: types.ExtractionQualityDegraded,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+// DOCXProcessor handles Word documents with real content extraction
+type DOCXProcessor struct {
+	extractors []string // Fallback chain order, DefaultDOCXExtractors when empty
+}
+
+func (p *DOCXProcessor) Read(path string) (*types.DocumentContent, error) {
+	log.Printf("🔄 Processing DOCX with external library: %s", logging.File(path))
+
+	order := p.extractors
+	if len(order) == 0 {
+		order = DefaultDOCXExtractors
+	}
+	return buildChain(order, map[string]Extractor{
+		"docx":  {Name: "docx", Extract: p.extractDOCXContentLibrary},
+		"basic": {Name: "basic", Extract: p.extractDOCXContentBasic},
+	}).Run(context.Background(), path)
+}
+
+// ReadBasic records the DOCX without extracting its text
+func (p *DOCXProcessor) ReadBasic(path string) (*types.DocumentContent, error) {
+	return p.extractDOCXContentBasic(path)
+}
+
+// Extractors lists the DOCX extractors that can be placed in the fallback chain
+func (p *DOCXProcessor) Extractors() []string {
+	return []string{"docx", "basic"}
+}
+
+func (p *DOCXProcessor) setExtractorOrder(names []string) {
+	p.extractors = names
+}
+
+// extractDOCXContentLibrary extracts the document text with nguyenthenguyen/docx
+func (p *DOCXProcessor) extractDOCXContentLibrary(path string) (*types.DocumentContent, error) {
+	content, err := p.extractDOCXContentAdvanced(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, _ := os.Stat(path)
+	wordCount := len(strings.Fields(content))
+	lineCount := len(strings.Split(content, "\n"))
+
+	return &types.DocumentContent{
+		Text: content,
+		Type: "docx",
+		Metadata: map[string]string{
+			"file_size":  fmt.Sprintf("%d", stat.Size()),
+			"word_count": fmt.Sprintf("%d", wordCount),
+			"line_count": fmt.Sprintf("%d", lineCount),
+			"char_count": fmt.Sprintf("%d", len(content)),
+			"status":     "advanced_extraction",
+			"method":     "nguyenthenguyen/docx",
+		},
+		ExtractionMethod:  "nguyenthenguyen/docx",
+		ExtractionQuality: types.ExtractionQualityHigh,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+func (p *DOCXProcessor) Version() int {
+	return DOCXProcessorVersion
+}
+
+func (p *DOCXProcessor) GetSupportedTypes() []string {
+	return []string{"docx", "doc"}
+}
+
+func (p *DOCXProcessor) extractDOCXContentAdvanced(path string) (string, error) {
+	r, err := docx.ReadDocxFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open DOCX: %w", err)
+	}
+	defer r.Close()
+
+	docx1 := r.Editable()
+	content := docx1.GetContent()
+
+	if strings.TrimSpace(content) == "" {
+		return "", fmt.Errorf("no text content extracted from DOCX")
+	}
+
+	// Clean up the content
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+
+	// Remove excessive blank lines
+	lines := strings.Split(content, "\n")
+	var cleanLines []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" || len(cleanLines) == 0 || strings.TrimSpace(cleanLines[len(cleanLines)-1]) != "" {
+			cleanLines = append(cleanLines, line)
+		}
+	}
+
+	return strings.Join(cleanLines, "\n"), nil
+}
+
+func (p *DOCXProcessor) extractDOCXContentBasic(path string) (*types.DocumentContent, error) {
+	stat, _ := os.Stat(path)
+
+	return &types.DocumentContent{
+		Text: fmt.Sprintf("DOCX file detected: %s\nAdvanced DOCX extraction failed. File contains %d bytes.\nConsider checking the file format or using a different library.",
+			filepath.Base(path), stat.Size()),
+		Type: "docx",
+		Metadata: map[string]string{
+			"file_size": fmt.Sprintf("%d", stat.Size()),
+			"status":    "basic_fallback",
+			"method":    "fallback",
+		},
+		ExtractionMethod:  "fallback",
+		ExtractionQuality: types.ExtractionQualityDegraded,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+// JSONProcessor handles JSON files
+type JSONProcessor struct{}
+
+func (p *JSONProcessor) Read(path string) (*types.DocumentContent, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON file: %w", err)
+	}
+
+	text := string(content)
+
+	// Basic JSON validation
+	var jsonData interface{}
+	if err := json.Unmarshal(content, &jsonData); err != nil {
+		return &types.DocumentContent{
+			Text: text,
+			Type: "json",
+			Metadata: map[string]string{
+				"status":     "invalid_json",
+				"error":      err.Error(),
+				"char_count": fmt.Sprintf("%d", len(text)),
+			},
+			ExtractionMethod:  "raw_text",
+			ExtractionQuality: types.ExtractionQualityDegraded,
+			ProcessedAt:       time.Now(),
+		}, nil
+	}
+
+	// Count JSON elements
+	lineCount := len(strings.Split(text, "\n"))
+
+	return &types.DocumentContent{
+		Text: text,
+		Type: "json",
+		Metadata: map[string]string{
+			"line_count": fmt.Sprintf("%d", lineCount),
+			"char_count": fmt.Sprintf("%d", len(text)),
+			"status":     "valid_json",
+		},
+		ExtractionMethod:  "encoding/json",
+		ExtractionQuality: types.ExtractionQualityHigh,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+func (p *JSONProcessor) Version() int {
+	return JSONProcessorVersion
+}
+
+func (p *JSONProcessor) GetSupportedTypes() []string {
+	return []string{"json"}
+}
+
+// YAMLProcessor handles YAML files
+type YAMLProcessor struct{}
+
+func (p *YAMLProcessor) Read(path string) (*types.DocumentContent, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read YAML file: %w", err)
+	}
+
+	text := string(content)
+
+	// Validate every document in the stream, counting the keys of top-level mappings
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	keyCount := 0
+	documentCount := 0
+	for {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return &types.DocumentContent{
+				Text: text,
+				Type: "yaml",
+				Metadata: map[string]string{
+					"status":     "invalid",
+					"error":      err.Error(),
+					"char_count": fmt.Sprintf("%d", len(text)),
+				},
+				ExtractionMethod:  "raw_text",
+				ExtractionQuality: types.ExtractionQualityDegraded,
+				ProcessedAt:       time.Now(),
+			}, nil
+		}
+
+		documentCount++
+		if node.Kind == yaml.DocumentNode && len(node.Content) > 0 && node.Content[0].Kind == yaml.MappingNode {
+			keyCount += len(node.Content[0].Content) / 2
+		}
+	}
+
+	lineCount := len(strings.Split(text, "\n"))
+
+	return &types.DocumentContent{
+		Text: text,
+		Type: "yaml",
+		Metadata: map[string]string{
+			"line_count":     fmt.Sprintf("%d", lineCount),
+			"char_count":     fmt.Sprintf("%d", len(text)),
+			"key_count":      fmt.Sprintf("%d", keyCount),
+			"document_count": fmt.Sprintf("%d", documentCount),
+			"status":         "valid",
+		},
+		ExtractionMethod:  "yaml.v3",
+		ExtractionQuality: types.ExtractionQualityHigh,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+func (p *YAMLProcessor) Version() int {
+	return YAMLProcessorVersion
+}
+
+func (p *YAMLProcessor) GetSupportedTypes() []string {
+	return []string{"yaml", "yml"}
+}
+
+// TOMLProcessor handles TOML files
+type TOMLProcessor struct{}
+
+func (p *TOMLProcessor) Read(path string) (*types.DocumentContent, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TOML file: %w", err)
+	}
+
+	text := string(content)
+
+	// Basic TOML validation
+	var tomlData map[string]interface{}
+	if _, err := toml.Decode(text, &tomlData); err != nil {
+		return &types.DocumentContent{
+			Text: text,
+			Type: "toml",
+			Metadata: map[string]string{
+				"status":     "invalid",
+				"error":      err.Error(),
+				"char_count": fmt.Sprintf("%d", len(text)),
+			},
+			ExtractionMethod:  "raw_text",
+			ExtractionQuality: types.ExtractionQualityDegraded,
+			ProcessedAt:       time.Now(),
+		}, nil
+	}
+
+	lineCount := len(strings.Split(text, "\n"))
+
+	return &types.DocumentContent{
+		Text: text,
+		Type: "toml",
+		Metadata: map[string]string{
+			"line_count": fmt.Sprintf("%d", lineCount),
+			"char_count": fmt.Sprintf("%d", len(text)),
+			"key_count":  fmt.Sprintf("%d", len(tomlData)),
+			"status":     "valid",
+		},
+		ExtractionMethod:  "BurntSushi/toml",
+		ExtractionQuality: types.ExtractionQualityHigh,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+func (p *TOMLProcessor) Version() int {
+	return TOMLProcessorVersion
+}
+
+func (p *TOMLProcessor) GetSupportedTypes() []string {
+	return []string{"toml"}
+}
+
+// XMLProcessor handles XML files
+type XMLProcessor struct{}
+
+func (p *XMLProcessor) Read(path string) (*types.DocumentContent, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read XML file: %w", err)
+	}
+
+	text := string(content)
+
+	// Basic XML validation
+	decoder := xml.NewDecoder(strings.NewReader(text))
+	elementCount := 0
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return &types.DocumentContent{
+				Text: text,
+				Type: "xml",
+				Metadata: map[string]string{
+					"status":     "invalid_xml",
+					"error":      err.Error(),
+					"char_count": fmt.Sprintf("%d", len(text)),
+				},
+				ExtractionMethod:  "raw_text",
+				ExtractionQuality: types.ExtractionQualityDegraded,
+				ProcessedAt:       time.Now(),
+			}, nil
+		}
+		elementCount++
+	}
+
+	return &types.DocumentContent{
+		Text: text,
+		Type: "xml",
+		Metadata: map[string]string{
+			"element_count": fmt.Sprintf("%d", elementCount),
+			"char_count":    fmt.Sprintf("%d", len(text)),
+			"status":        "valid_xml",
+		},
+		ExtractionMethod:  "encoding/xml",
+		ExtractionQuality: types.ExtractionQualityHigh,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+func (p *XMLProcessor) Version() int {
+	return XMLProcessorVersion
+}
+
+func (p *XMLProcessor) GetSupportedTypes() []string {
+	return []string{"xml"}
+}
+
+// FileType is a file's type as claimed by its extension and as detected from its content
+type FileType struct {
+	Claimed  string `json:"claimed"`  // From the file extension
+	Detected string `json:"detected"` // From content sniffing, empty when inconclusive
+}
+
+// Effective returns the detected type, falling back to the extension
+func (t FileType) Effective() string {
+	if t.Detected != "" {
+		return t.Detected
+	}
+	return t.Claimed
+}
+
+// sniffBytes is how much of a file is inspected for a content signature
+const sniffBytes = 512
+
+// DetectFileType sniffs the first bytes of a file for known signatures, keeping the extension as fallback
+func DetectFileType(path string) (FileType, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if strings.HasPrefix(ext, ".") {
+		ext = ext[1:]
+	}
+	fileType := FileType{Claimed: ext}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fileType, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	header := make([]byte, sniffBytes)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return fileType, fmt.Errorf("failed to read file header: %w", err)
+	}
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, []byte("%PDF")):
+		fileType.Detected = "pdf"
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")):
+		// A file claimed as a plain archive stays one, whatever folders it contains
+		if fileType.Claimed != "zip" {
+			fileType.Detected = detectOfficeType(path)
+		}
+	default:
+		fileType.Detected = detectMarkupType(header)
+	}
+
+	return fileType, nil
+}
+
+// detectOfficeType tells DOCX, XLSX and PPTX apart by the parts inside the ZIP container. Only
+// Office packages, which carry a [Content_Types].xml part, are recognized; ordinary archives
+// with a word/, xl/ or ppt/ folder are not.
+func detectOfficeType(path string) string {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return ""
+	}
+	defer archive.Close()
+
+	officeType := ""
+	contentTypes := false
+	for _, part := range archive.File {
+		switch {
+		case part.Name == "[Content_Types].xml":
+			contentTypes = true
+		case officeType != "":
+		case strings.HasPrefix(part.Name, "word/"):
+			officeType = "docx"
+		case strings.HasPrefix(part.Name, "xl/"):
+			officeType = "xlsx"
+		case strings.HasPrefix(part.Name, "ppt/"):
+			officeType = "pptx"
+		}
+	}
+	if !contentTypes {
+		return "" // Some other ZIP-based format
+	}
+	return officeType
+}
+
+// detectMarkupType recognizes HTML and XML documents by their opening tags
+func detectMarkupType(header []byte) string {
+	start := bytes.ToLower(bytes.TrimSpace(bytes.TrimPrefix(header, []byte{0xEF, 0xBB, 0xBF})))
+
+	switch {
+	case bytes.HasPrefix(start, []byte("<!doctype html")), bytes.HasPrefix(start, []byte("<html")):
+		return "html"
+	case bytes.HasPrefix(start, []byte("<?xml")):
+		if bytes.Contains(start, []byte("<html")) {
+			return "html" // XHTML
+		}
+		return "xml"
+	}
+	return ""
+}
+
+// CSVProcessor handles CSV files with comma, semicolon or tab delimiters
+type CSVProcessor struct {
+	delimiter rune // 0 auto-detects
+}
+
+// csvDelimiters maps the supported delimiters to their metadata names, in detection preference order
+var csvDelimiters = []struct {
+	Rune rune
+	Name string
+}{
+	{',', "comma"},
+	{';', "semicolon"},
+	{'\t', "tab"},
+}
+
+func (p *CSVProcessor) Read(path string) (*types.DocumentContent, error) {
+	return p.ReadContext(context.Background(), path)
+}
+
+func (p *CSVProcessor) ReadContext(ctx context.Context, path string) (*types.DocumentContent, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV file: %w", err)
+	}
+
+	text := string(content)
+
+	delimiter := p.delimiter
+	if delimiter == 0 {
+		delimiter = detectCSVDelimiter(text)
+	}
+
+	metadata := map[string]string{
+		"delimiter":  csvDelimiterName(delimiter),
+		"char_count": fmt.Sprintf("%d", len(text)),
+		"status":     "parsed",
+	}
+
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1 // Ragged rows are reported, not rejected
+	reader.LazyQuotes = true
+
+	records := 0
+	columns := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("stopped after %d CSV records: %w", records, err)
+		}
+
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("⚠️ CSV parse error in %s: %v", logging.File(path), err)
+			metadata["status"] = "invalid_csv"
+			metadata["parse_error"] = err.Error()
+			break
+		}
+		if records == 0 {
+			columns = len(record)
+		}
+		records++
+	}
+
+	estimatedRows := records - 1 // minus header
+	if estimatedRows < 0 {
+		estimatedRows = 0
+	}
+	metadata["lines"] = fmt.Sprintf("%d", records)
+	metadata["columns"] = fmt.Sprintf("%d", columns)
+	metadata["estimated_rows"] = fmt.Sprintf("%d", estimatedRows)
+
+	return &types.DocumentContent{
+		Text:              text,
+		Type:              "csv",
+		Metadata:          metadata,
+		ExtractionMethod:  "encoding/csv",
+		ExtractionQuality: types.ExtractionQualityHigh,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+// detectCSVDelimiter picks the delimiter that splits the first records into the most,
+// consistently sized fields. It falls back to a comma.
+func detectCSVDelimiter(text string) rune {
+	sample := text
+	if len(sample) > 64*1024 {
+		sample = sample[:64*1024]
+	}
+
+	best := ','
+	bestColumns := 1
+	for _, candidate := range csvDelimiters {
+		reader := csv.NewReader(strings.NewReader(sample))
+		reader.Comma = candidate.Rune
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+
+		columns := 0
+		consistent := true
+		for i := 0; i < 10; i++ {
+			record, err := reader.Read()
+			if err != nil {
+				// A truncated sample may cut the last record; only the records read so far count
+				break
+			}
+			if i == 0 {
+				columns = len(record)
+			} else if len(record) != columns {
+				consistent = false
+				break
+			}
+		}
+
+		if consistent && columns > bestColumns {
+			best = candidate.Rune
+			bestColumns = columns
+		}
+	}
+
+	return best
+}
+
+// csvDelimiterName returns the metadata name of a delimiter
+func csvDelimiterName(delimiter rune) string {
+	for _, candidate := range csvDelimiters {
+		if candidate.Rune == delimiter {
+			return candidate.Name
+		}
+	}
+	return string(delimiter)
+}
+
+// ParseCSVDelimiter converts a configured delimiter ("auto", "comma", "semicolon", "tab" or a
+// single character) to a rune, with 0 meaning auto-detection
+func ParseCSVDelimiter(value string) rune {
+	switch strings.ToLower(value) {
+	case "", "auto":
+		return 0
+	}
+	for _, candidate := range csvDelimiters {
+		if strings.EqualFold(value, candidate.Name) {
+			return candidate.Rune
+		}
+	}
+	if runes := []rune(value); len(runes) == 1 {
+		return runes[0]
+	}
+	return 0
+}
+
+func (p *CSVProcessor) Version() int {
+	return CSVProcessorVersion
+}
+
+func (p *CSVProcessor) GetSupportedTypes() []string {
+	return []string{"csv"}
+}
+
+// XLSXProcessor handles Excel workbooks, flattening every sheet into tab-separated text
+type XLSXProcessor struct{}
+
+func (p *XLSXProcessor) Read(path string) (*types.DocumentContent, error) {
+	log.Printf("🔄 Processing XLSX with external library: %s", logging.File(path))
+
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		log.Printf("⚠️ XLSX extraction failed, using fallback: %v", err)
+		return p.extractXLSXContentBasic(path, err)
+	}
+	defer f.Close()
+
+	var content strings.Builder
+	sheets := f.GetSheetList()
+	totalRows := 0
+	metadata := map[string]string{}
+
+	for i, sheet := range sheets {
+		rows, err := f.GetRows(sheet)
+		if err != nil {
+			log.Printf("⚠️ Error reading sheet %s: %v", sheet, err)
+			continue
+		}
+
+		content.WriteString(fmt.Sprintf("--- Sheet: %s ---\n", sheet))
+		for _, row := range rows {
+			content.WriteString(strings.Join(row, "\t"))
+			content.WriteString("\n")
+		}
+		content.WriteString("\n")
+
+		totalRows += len(rows)
+		metadata[fmt.Sprintf("sheet_%d_name", i+1)] = sheet
+		metadata[fmt.Sprintf("sheet_%d_rows", i+1)] = fmt.Sprintf("%d", len(rows))
+	}
+
+	text := content.String()
+	metadata["sheet_count"] = fmt.Sprintf("%d", len(sheets))
+	metadata["sheet_names"] = strings.Join(sheets, ", ")
+	metadata["row_count"] = fmt.Sprintf("%d", totalRows)
+	metadata["word_count"] = fmt.Sprintf("%d", len(strings.Fields(text)))
+	metadata["char_count"] = fmt.Sprintf("%d", len(text))
+	metadata["status"] = "advanced_extraction"
+	metadata["method"] = "excelize"
+
+	return &types.DocumentContent{
+		Text:              text,
+		Type:              "xlsx",
+		Metadata:          metadata,
+		ExtractionMethod:  "excelize",
+		ExtractionQuality: types.ExtractionQualityHigh,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+func (p *XLSXProcessor) Version() int {
+	return XLSXProcessorVersion
+}
+
+func (p *XLSXProcessor) GetSupportedTypes() []string {
+	return []string{"xlsx", "xlsm"}
+}
+
+func (p *XLSXProcessor) extractXLSXContentBasic(path string, cause error) (*types.DocumentContent, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read XLSX file: %w", err)
+	}
+
+	reason := "Workbook could not be read."
+	status := "basic_fallback"
+	if errors.Is(cause, excelize.ErrWorkbookPassword) {
+		reason = "Workbook is password-protected."
+		status = "password_protected_fallback"
+	}
+
+	return &types.DocumentContent{
+		Text: fmt.Sprintf("Excel workbook detected: %s\n%s File contains %d bytes.",
+			filepath.Base(path), reason, stat.Size()),
+		Type: "xlsx",
+		Metadata: map[string]string{
+			"file_size": fmt.Sprintf("%d", stat.Size()),
+			"status":    status,
+			"method":    "fallback",
+		},
+		ExtractionMethod:  "fallback",
+		ExtractionQuality: types.ExtractionQualityDegraded,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+// PPTXProcessor handles PowerPoint presentations, extracting the text runs of each slide
+type PPTXProcessor struct{}
+
+func (p *PPTXProcessor) Read(path string) (*types.DocumentContent, error) {
+	log.Printf("🔄 Processing PPTX: %s", logging.File(path))
+
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PPTX file: %w", err)
+	}
+	defer archive.Close()
+
+	parts := make(map[string]*zip.File, len(archive.File))
+	for _, part := range archive.File {
+		parts[part.Name] = part
+	}
+
+	slides := pptxSlideOrder(parts)
+	if len(slides) == 0 {
+		return nil, fmt.Errorf("no slides found in PPTX file")
+	}
+
+	var content strings.Builder
+	for i, slide := range slides {
+		text, err := pptxPartText(parts[slide])
+		if err != nil {
+			log.Printf("⚠️ Error reading slide %d: %v", i+1, err)
+			continue
+		}
+		content.WriteString(fmt.Sprintf("--- Slide %d ---\n", i+1))
+		content.WriteString(text)
+		content.WriteString("\n\n")
+	}
+
+	hasNotes := false
+	for name, part := range parts {
+		if !strings.HasPrefix(name, "ppt/notesSlides/") || !strings.HasSuffix(name, ".xml") {
+			continue
+		}
+		if text, err := pptxPartText(part); err == nil && strings.TrimSpace(text) != "" {
+			hasNotes = true
+			break
+		}
+	}
+
+	text := content.String()
+	return &types.DocumentContent{
+		Text: text,
+		Type: "pptx",
+		Metadata: map[string]string{
+			"slide_count": fmt.Sprintf("%d", len(slides)),
+			"has_notes":   strconv.FormatBool(hasNotes),
+			"word_count":  fmt.Sprintf("%d", len(strings.Fields(text))),
+			"char_count":  fmt.Sprintf("%d", len(text)),
+			"status":      "parsed",
+			"method":      "xml",
+		},
+		ExtractionMethod:  "xml",
+		ExtractionQuality: types.ExtractionQualityHigh,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+func (p *PPTXProcessor) Version() int {
+	return PPTXProcessorVersion
+}
+
+func (p *PPTXProcessor) GetSupportedTypes() []string {
+	return []string{"pptx"}
+}
+
+// pptxSlideOrder returns the slide part names in presentation order, read from the slide list
+// in presentation.xml. Falls back to the slide file numbers when the list can't be resolved.
+func pptxSlideOrder(parts map[string]*zip.File) []string {
+	var presentation struct {
+		Slides []struct {
+			RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sldIdLst>sldId"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	if decodeZipXML(parts["ppt/presentation.xml"], &presentation) == nil &&
+		decodeZipXML(parts["ppt/_rels/presentation.xml.rels"], &rels) == nil {
+		targets := make(map[string]string, len(rels.Relationships))
+		for _, rel := range rels.Relationships {
+			targets[rel.ID] = "ppt/" + strings.TrimPrefix(rel.Target, "/ppt/")
+		}
+
+		var ordered []string
+		for _, slide := range presentation.Slides {
+			if name := targets[slide.RelID]; parts[name] != nil {
+				ordered = append(ordered, name)
+			}
+		}
+		if len(ordered) > 0 {
+			return ordered
+		}
+	}
+
+	type numberedSlide struct {
+		name   string
+		number int
+	}
+	var numbered []numberedSlide
+	for name := range parts {
+		base := strings.TrimPrefix(name, "ppt/slides/slide")
+		if base == name || !strings.HasSuffix(base, ".xml") {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSuffix(base, ".xml")); err == nil {
+			numbered = append(numbered, numberedSlide{name, n})
+		}
+	}
+	sort.Slice(numbered, func(i, j int) bool { return numbered[i].number < numbered[j].number })
+
+	ordered := make([]string, len(numbered))
+	for i, slide := range numbered {
+		ordered[i] = slide.name
+	}
+	return ordered
+}
+
+// decodeZipXML unmarshals an XML part of a ZIP archive
+func decodeZipXML(part *zip.File, v interface{}) error {
+	if part == nil {
+		return fmt.Errorf("part not found")
+	}
+	r, err := part.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// pptxPartText collects the <a:t> text runs of a slide or notes part, one line per <a:p> paragraph
+func pptxPartText(part *zip.File) (string, error) {
+	r, err := part.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	var lines []string
+	var line strings.Builder
+	inText := false
+
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse %s: %w", part.Name, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			inText = t.Name.Local == "t"
+			if t.Name.Local == "br" {
+				line.WriteString(" ")
+			}
+		case xml.EndElement:
+			inText = false
+			if t.Name.Local == "p" {
+				if text := strings.TrimSpace(line.String()); text != "" {
+					lines = append(lines, text)
+				}
+				line.Reset()
+			}
+		case xml.CharData:
+			if inText {
+				line.Write(t)
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// LogProcessor handles log files - ONLY DECLARATION
+type LogProcessor struct {
+	maxTextBytes int64
+}
+
+func (p *LogProcessor) setMaxTextBytes(limit int64) {
+	p.maxTextBytes = limit
+}
+
+func (p *LogProcessor) Read(path string) (*types.DocumentContent, error) {
+	return p.ReadContext(context.Background(), path)
+}
+
+func (p *LogProcessor) ReadContext(ctx context.Context, path string) (*types.DocumentContent, error) {
+	// Count different log levels, once per entry so stack trace lines aren't counted
+	errorCount := 0
+	warningCount := 0
+	infoCount := 0
+	events := &logEventParser{}
+	timeRange := &logTimeRange{}
+
+	stream, err := streamTextFile(ctx, path, p.maxTextBytes, func(line string) {
+		timeRange.add(line)
+		if events.add(line) {
+			return
+		}
+
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "error") || strings.Contains(lower, "err") {
+			errorCount++
+		} else if strings.Contains(lower, "warning") || strings.Contains(lower, "warn") {
+			warningCount++
+		} else if strings.Contains(lower, "info") {
+			infoCount++
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	events.flush()
+
+	metadata := map[string]string{
+		"total_lines":      fmt.Sprintf("%d", stream.Lines),
+		"error_lines":      fmt.Sprintf("%d", errorCount),
+		"warning_lines":    fmt.Sprintf("%d", warningCount),
+		"info_lines":       fmt.Sprintf("%d", infoCount),
+		"char_count":       fmt.Sprintf("%d", stream.Bytes),
+		"stacktrace_count": fmt.Sprintf("%d", events.traceCount),
+	}
+	if len(events.traces) > 0 {
+		traces, err := json.Marshal(events.traces)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode stack traces: %w", err)
+		}
+		metadata["stacktraces"] = string(traces)
+	}
+	timeRange.metadata(metadata)
+
+	return &types.DocumentContent{
+		Text:              stream.Text,
+		Type:              "log",
+		Metadata:          stream.textMetadata(metadata),
+		ExtractionMethod:  "plain_text",
+		ExtractionQuality: types.ExtractionQualityHigh,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+func (p *LogProcessor) Version() int {
+	return LogProcessorVersion
+}
+
+func (p *LogProcessor) GetSupportedTypes() []string {
+	return []string{"log", "logs"}
+}
+
+// CodeProcessor handles source code files - ONLY DECLARATION
+type CodeProcessor struct {
+	maxTextBytes int64
+}
+
+func (p *CodeProcessor) setMaxTextBytes(limit int64) {
+	p.maxTextBytes = limit
+}
+
+func (p *CodeProcessor) Read(path string) (*types.DocumentContent, error) {
+	return p.ReadContext(context.Background(), path)
+}
+
+func (p *CodeProcessor) ReadContext(ctx context.Context, path string) (*types.DocumentContent, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+
+	// Count code statistics
+	stats := &codeStats{ext: ext, isComment: p.isCommentLine}
+	stream, err := streamTextFile(ctx, path, p.maxTextBytes, stats.add)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read code file: %w", err)
+	}
+
+	metadata := map[string]string{
+		"total_lines":   fmt.Sprintf("%d", stream.Lines),
+		"code_lines":    fmt.Sprintf("%d", stats.codeLines),
+		"comment_lines": fmt.Sprintf("%d", stats.commentLines),
+		"empty_lines":   fmt.Sprintf("%d", stats.emptyLines),
+		"todo_count":    fmt.Sprintf("%d", stats.todos),
+		"language":      p.detectLanguage(ext),
+		"char_count":    fmt.Sprintf("%d", stream.Bytes),
+	}
+	if _, ok := functionPatterns[ext]; ok {
+		metadata["function_count"] = fmt.Sprintf("%d", stats.functions)
+	}
+
+	return &types.DocumentContent{
+		Text:              stream.Text,
+		Type:              "code",
+		Metadata:          stream.textMetadata(metadata),
+		ExtractionMethod:  "plain_text",
+		ExtractionQuality: types.ExtractionQualityHigh,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+func (p *CodeProcessor) isCommentLine(line, ext string) bool {
+	switch ext {
+	case ".go", ".js", ".ts", ".tsx", ".java", ".c", ".cpp", ".cs", ".rs", ".kt", ".swift", ".scala", ".dart":
+		return strings.HasPrefix(line, "//") || strings.HasPrefix(line, "/*")
+	case ".py", ".sh", ".bash":
+		return strings.HasPrefix(line, "#")
+	case ".sql", ".lua":
+		return strings.HasPrefix(line, "--")
+	case ".lisp", ".clj", ".el":
+		return strings.HasPrefix(line, ";")
+	case ".ini", ".cfg", ".conf":
+		return strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#")
+	case ".erl", ".tex":
+		return strings.HasPrefix(line, "%")
+	case ".html", ".xml":
+		return strings.HasPrefix(line, "<!--")
+	default:
+		return strings.HasPrefix(line, "//") || strings.HasPrefix(line, "#")
+	}
+}
+
+func (p *CodeProcessor) detectLanguage(ext string) string {
+	languages := map[string]string{
+		".go":    "Go",
+		".py":    "Python",
+		".js":    "JavaScript",
+		".ts":    "TypeScript",
+		".tsx":   "TypeScript",
+		".java":  "Java",
+		".c":     "C",
+		".cpp":   "C++",
+		".cs":    "C#",
+		".rs":    "Rust",
+		".kt":    "Kotlin",
+		".swift": "Swift",
+		".scala": "Scala",
+		".dart":  "Dart",
+		".lua":   "Lua",
+		".erl":   "Erlang",
+		".lisp":  "Lisp",
+		".clj":   "Clojure",
+		".el":    "Emacs Lisp",
+		".tex":   "LaTeX",
+		".ini":   "INI",
+		".cfg":   "INI",
+		".conf":  "Config",
+		".php":   "PHP",
+		".rb":    "Ruby",
+		".sh":    "Shell",
+		".bash":  "Bash",
+		".sql":   "SQL",
+		".html":  "HTML",
+		".css":   "CSS",
+		".xml":   "XML",
+	}
+
+	if lang, exists := languages[ext]; exists {
+		return lang
+	}
+	return "Unknown"
+}
+
+func (p *CodeProcessor) Version() int {
+	return CodeProcessorVersion
+}
+
+func (p *CodeProcessor) GetSupportedTypes() []string {
+	return []string{
+		"go", "py", "js", "ts", "tsx", "java", "c", "cpp", "cs", "rs", "kt", "swift", "scala", "dart",
+		"php", "rb", "sh", "bash", "sql", "css", "lua", "erl", "lisp", "clj", "el", "tex", "ini", "cfg", "conf",
+	}
+}
+
+// SearchInDocument searches for text within a document
+func (dm *DocumentManager) SearchInDocument(path, query string) ([]string, error) {
+	log.Printf("🔍 Searching in document: %s for: %s", logging.File(path), logging.Text(query))
+
+	content, err := dm.ProcessDocument(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process document: %w", err)
+	}
+
+	var matches []string
+	lines := strings.Split(content.Text, "\n")
+
+	for i, line := range lines {
+		if strings.Contains(strings.ToLower(line), strings.ToLower(query)) {
+			// Add context: line number and content
+			match := fmt.Sprintf("Line %d: %s", i+1, strings.TrimSpace(line))
+			matches = append(matches, match)
+		}
+	}
+
+	log.Printf("✅ Found %d matches in %s", len(matches), logging.File(path))
+	return matches, nil
+}
+
+// SearchInMultipleDocuments searches for text in multiple documents
+func (dm *DocumentManager) SearchInMultipleDocuments(paths []string, query string) (map[string][]string, error) {
+	log.Printf("🔍 Searching in %d documents for: %s", len(paths), logging.Text(query))
+
+	results := make(map[string][]string)
+
+	for _, path := range paths {
+		matches, err := dm.SearchInDocument(path, query)
+		if err != nil {
+			log.Printf("❌ Error searching %s: %v", logging.File(path), err)
+			continue
+		}
+
+		if len(matches) > 0 {
+			results[path] = matches
+		}
+	}
+
+	log.Printf("✅ Search completed. Found matches in %d out of %d documents", len(results), len(paths))
+	return results, nil
+}
+
+// GetDocumentPreview returns a preview of document content
+func (dm *DocumentManager) GetDocumentPreview(path string, maxLines int) (string, error) {
+	content, err := dm.ProcessDocument(path)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(content.Text, "\n")
+	if len(lines) <= maxLines {
+		return content.Text, nil
+	}
+
+	preview := strings.Join(lines[:maxLines], "\n")
+	preview += fmt.Sprintf("\n... (%d more lines)", len(lines)-maxLines)
+
+	return preview, nil
+}
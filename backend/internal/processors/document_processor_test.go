@@ -0,0 +1,150 @@
+package processors
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestCSVProcessorReadCountsColumns(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		delimiter string
+		columns   string
+		lines     string
+	}{
+		{"quoted comma", "\"Smith, John\",42\n", "comma", "2", "1"},
+		{"semicolon", "name;age;city\nAnna;31;Berlin\nBen;27;Hamburg\n", "semicolon", "3", "3"},
+		{"tab", "name\tage\nAnna\t31\n", "tab", "2", "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "data.csv")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("write CSV: %v", err)
+			}
+
+			content, err := (&CSVProcessor{}).Read(path)
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+
+			if got := content.Metadata["delimiter"]; got != tt.delimiter {
+				t.Errorf("delimiter = %q, want %q", got, tt.delimiter)
+			}
+			if got := content.Metadata["columns"]; got != tt.columns {
+				t.Errorf("columns = %q, want %q", got, tt.columns)
+			}
+			if got := content.Metadata["lines"]; got != tt.lines {
+				t.Errorf("lines = %q, want %q", got, tt.lines)
+			}
+		})
+	}
+}
+
+func TestDocumentManagerConcurrentProcessing(t *testing.T) {
+	dir := t.TempDir()
+	dm := NewDocumentManager()
+	// A zero interval saves after every document, so saves race with processing too
+	dm.EnableStatsPersistence(filepath.Join(dir, "stats.json"), 0)
+	defer dm.Close()
+
+	const documents = 20
+	paths := make([]string, documents)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("doc%d.txt", i))
+		if err := os.WriteFile(paths[i], []byte(fmt.Sprintf("document %d", i)), 0644); err != nil {
+			t.Fatalf("write document: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, path := range paths {
+		wg.Add(2)
+		go func(path string) {
+			defer wg.Done()
+			if _, err := dm.ProcessDocument(path); err != nil {
+				t.Errorf("ProcessDocument(%s): %v", path, err)
+			}
+		}(path)
+		go func() {
+			defer wg.Done()
+			_ = dm.GetProcessingStats()
+		}()
+	}
+	wg.Wait()
+
+	stats := dm.GetProcessingStats()
+	if stats.TotalProcessed != documents || stats.SuccessfullyParsed != documents {
+		t.Errorf("processed %d, parsed %d, want %d of each", stats.TotalProcessed, stats.SuccessfullyParsed, documents)
+	}
+	if got := stats.TypeCounts["txt"]; got != documents {
+		t.Errorf("txt count = %d, want %d", got, documents)
+	}
+}
+
+func TestDetectFileTypeZIPContainers(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		parts []string
+		want  string
+	}{
+		{"office package", "report.bin", []string{"[Content_Types].xml", "word/document.xml"}, "docx"},
+		{"archive with office folder", "backup.bin", []string{"word/notes.txt"}, ""},
+		{"claimed zip", "backup.zip", []string{"[Content_Types].xml", "xl/workbook.xml"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			f, err := os.Create(path)
+			if err != nil {
+				t.Fatalf("create archive: %v", err)
+			}
+			w := zip.NewWriter(f)
+			for _, part := range tt.parts {
+				if _, err := w.Create(part); err != nil {
+					t.Fatalf("add %s: %v", part, err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("close archive: %v", err)
+			}
+			f.Close()
+
+			fileType, err := DetectFileType(path)
+			if err != nil {
+				t.Fatalf("DetectFileType: %v", err)
+			}
+			if fileType.Detected != tt.want {
+				t.Errorf("detected %q, want %q", fileType.Detected, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessorVersionFollowsDetectedType(t *testing.T) {
+	dir := t.TempDir()
+	mislabelled := filepath.Join(dir, "scan.txt")
+	if err := os.WriteFile(mislabelled, []byte("%PDF-1.4\n%fake\n"), 0644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	plain := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(plain, []byte("just text\n"), 0644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	dm := NewDocumentManager()
+	if got := dm.ProcessorVersion(mislabelled); got != PDFProcessorVersion {
+		t.Errorf("version of PDF named .txt = %d, want the PDF processor's %d", got, PDFProcessorVersion)
+	}
+	if got := dm.ProcessorVersion(plain); got != TXTProcessorVersion {
+		t.Errorf("version of plain .txt = %d, want the TXT processor's %d", got, TXTProcessorVersion)
+	}
+}
@@ -0,0 +1,45 @@
+package processors
+
+import "os/exec"
+
+// ExternalTool is a command line tool an optional feature shells out to
+type ExternalTool struct {
+	Name      string   `json:"name"`
+	Features  []string `json:"features"`
+	Available bool     `json:"available"`
+}
+
+// Optional features that need external tools
+const (
+	FeaturePDFSplit = "pdf_split"
+	FeaturePDFMerge = "pdf_merge"
+	FeaturePDFOCR   = "pdf_ocr"
+)
+
+// CheckExternalTools reports which of the tools used to split, merge and OCR PDFs are installed
+func CheckExternalTools() []ExternalTool {
+	tools := []ExternalTool{
+		{Name: pdfSeparateTool, Features: []string{FeaturePDFSplit}},
+		{Name: pdfUniteTool, Features: []string{FeaturePDFSplit, FeaturePDFMerge}},
+		{Name: ocrRasterizer, Features: []string{FeaturePDFOCR}},
+		{Name: ocrEngine, Features: []string{FeaturePDFOCR}},
+	}
+	for i := range tools {
+		_, err := exec.LookPath(tools[i].Name)
+		tools[i].Available = err == nil
+	}
+	return tools
+}
+
+// AvailableFeatures reports for every optional feature whether all the tools it needs are installed
+func AvailableFeatures(tools []ExternalTool) map[string]bool {
+	features := make(map[string]bool)
+	for _, tool := range tools {
+		for _, feature := range tool.Features {
+			if available, seen := features[feature]; !seen || available {
+				features[feature] = tool.Available
+			}
+		}
+	}
+	return features
+}
@@ -0,0 +1,92 @@
+package processors
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/logging"
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// Extractor is one text extraction strategy in a fallback chain
+type Extractor struct {
+	Name    string
+	Extract func(path string) (*types.DocumentContent, error)
+}
+
+// FallbackChain tries its extractors in order and returns the first successful result
+type FallbackChain []Extractor
+
+// Default extractor orders, used when no order is configured
+var (
+	DefaultPDFExtractors  = []string{"ledongthuc", "ocr", "basic"}
+	DefaultDOCXExtractors = []string{"docx", "basic"}
+)
+
+// Run extracts path with each extractor in turn until one succeeds or ctx is cancelled
+func (c FallbackChain) Run(ctx context.Context, path string) (*types.DocumentContent, error) {
+	if len(c) == 0 {
+		return nil, fmt.Errorf("no extractors configured")
+	}
+
+	var failures []string
+	for _, extractor := range c {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		content, err := extractor.Extract(path)
+		if err == nil {
+			return content, nil
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%s extraction stopped: %w", extractor.Name, ctx.Err())
+		}
+		log.Printf("⚠️ %s extraction failed for %s: %v", extractor.Name, logging.File(path), err)
+		failures = append(failures, fmt.Sprintf("%s: %v", extractor.Name, err))
+	}
+
+	return nil, fmt.Errorf("all extractors failed (%s)", strings.Join(failures, "; "))
+}
+
+// chainedProcessor is implemented by processors whose extractors can be reordered through config
+type chainedProcessor interface {
+	Extractors() []string
+	setExtractorOrder(names []string)
+}
+
+// buildChain orders the available extractors by name, skipping names that aren't available
+func buildChain(order []string, available map[string]Extractor) FallbackChain {
+	chain := FallbackChain{}
+	for _, name := range order {
+		if extractor, ok := available[name]; ok {
+			chain = append(chain, extractor)
+		}
+	}
+	return chain
+}
+
+// SetExtractorChain sets the order in which a file type's extractors are tried
+func (dm *DocumentManager) SetExtractorChain(fileType string, names []string) error {
+	processor, ok := dm.processors[fileType].(chainedProcessor)
+	if !ok {
+		return fmt.Errorf("file type %s has no configurable extractors", fileType)
+	}
+
+	known := make(map[string]bool)
+	for _, name := range processor.Extractors() {
+		known[name] = true
+	}
+	for _, name := range names {
+		if !known[name] {
+			return fmt.Errorf("unknown %s extractor %q, available: %s",
+				fileType, name, strings.Join(processor.Extractors(), ", "))
+		}
+	}
+
+	processor.setExtractorOrder(names)
+	log.Printf("🔧 %s extractors: %s", strings.ToUpper(fileType), strings.Join(names, " → "))
+	return nil
+}
@@ -0,0 +1,201 @@
+package processors
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Limits on the stack traces kept in log metadata
+const (
+	maxLogStackTraces     = 20
+	maxLogStackTraceLines = 50
+)
+
+// LogStackTrace is a multi-line stack trace attached to the log line that reported it
+type LogStackTrace struct {
+	Line    int    `json:"line"`    // 1-based line of the log entry the trace belongs to
+	Message string `json:"message"` // The log entry itself
+	Trace   string `json:"trace"`   // Continuation lines, capped at maxLogStackTraceLines
+}
+
+var (
+	goroutineHeaderPattern = regexp.MustCompile(`^goroutine \d+ \[[^\]]+\]:$`)
+	javaFramePattern       = regexp.MustCompile(`^(at \S+\(.*\)|\.\.\. \d+ more|Caused by: |Suppressed: )`)
+	javaExceptionPattern   = regexp.MustCompile(`^([a-zA-Z_$][\w$]*\.)+[\w$]*(Exception|Error|Throwable)(:|$)`)
+)
+
+// logEventParser groups continuation lines (indented lines, Java frames, Python tracebacks and Go
+// goroutine dumps) with the log line before them
+type logEventParser struct {
+	lineNumber int
+	mode       string // "", "python" or "go" while inside a traceback that has unindented lines
+
+	current     LogStackTrace
+	haveCurrent bool
+	blankLines  int // Blank lines seen since the current entry's last line
+	traceLines  int
+	isTrace     bool
+
+	traces     []LogStackTrace
+	traceCount int
+}
+
+// add consumes the next line and reports whether it continues the previous log entry
+func (p *logEventParser) add(line string) bool {
+	p.lineNumber++
+	trimmed := strings.TrimSpace(line)
+
+	if p.haveCurrent && p.continues(line, trimmed) {
+		p.attach(line, trimmed)
+		return true
+	}
+	if p.haveCurrent && trimmed == "" {
+		// Go prints its goroutine dump a blank line after the panic message
+		p.blankLines++
+		return false
+	}
+
+	p.flush()
+	p.current = LogStackTrace{Line: p.lineNumber, Message: line}
+	p.haveCurrent = trimmed != ""
+	return false
+}
+
+// continues reports whether line belongs to the current entry, updating the traceback mode
+func (p *logEventParser) continues(line, trimmed string) bool {
+	switch p.mode {
+	case "python":
+		if trimmed != "" && !startsIndented(line) {
+			p.mode = "" // The unindented exception line ends a Python traceback
+		}
+		return true
+	case "go":
+		if trimmed == "" {
+			p.mode = ""
+			return false
+		}
+		return true
+	}
+
+	switch {
+	case trimmed == "":
+		return false
+	case goroutineHeaderPattern.MatchString(trimmed):
+		p.mode = "go"
+		return true
+	case p.blankLines > 0:
+		return false
+	case strings.HasPrefix(trimmed, "Traceback (most recent call last)"):
+		p.mode = "python"
+		return true
+	case javaFramePattern.MatchString(trimmed), javaExceptionPattern.MatchString(trimmed):
+		return true
+	}
+	return startsIndented(line)
+}
+
+// attach adds a continuation line to the current entry
+func (p *logEventParser) attach(line, trimmed string) {
+	if p.mode != "" || javaFramePattern.MatchString(trimmed) || strings.HasPrefix(trimmed, "File \"") {
+		p.isTrace = true
+	}
+
+	p.blankLines = 0
+	p.traceLines++
+	if p.traceLines > maxLogStackTraceLines {
+		return
+	}
+	if p.current.Trace != "" {
+		p.current.Trace += "\n"
+	}
+	p.current.Trace += line
+}
+
+// flush records the current entry if it carried a stack trace
+func (p *logEventParser) flush() {
+	if p.isTrace {
+		p.traceCount++
+		if len(p.traces) < maxLogStackTraces {
+			p.traces = append(p.traces, p.current)
+		}
+	}
+	p.current = LogStackTrace{}
+	p.blankLines = 0
+	p.traceLines = 0
+	p.isTrace = false
+	p.haveCurrent = false
+	p.mode = ""
+}
+
+// startsIndented reports whether a line begins with a space or tab
+func startsIndented(line string) bool {
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+}
+
+// Timestamp formats recognized at the start of a log line, optionally inside brackets
+var logTimestampFormats = []struct {
+	pattern *regexp.Regexp
+	layout  string
+	syslog  bool // Syslog timestamps have no year
+}{
+	{regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`), time.RFC3339Nano, false},
+	{regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?`), "2006-01-02T15:04:05.999999999", false},
+	{regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}([.,]\d+)?`), "2006-01-02 15:04:05.999999999", false},
+	{regexp.MustCompile(`^[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}`), time.Stamp, true},
+}
+
+// logTimeRange tracks the earliest and latest timestamps seen in a log
+type logTimeRange struct {
+	first, last time.Time
+	lines       int
+	year        int // Assumed year for syslog timestamps
+}
+
+// add records the timestamp at the start of line, if it has one
+func (r *logTimeRange) add(line string) {
+	ts, ok := r.parse(strings.TrimPrefix(line, "["))
+	if !ok {
+		return
+	}
+	if r.lines == 0 || ts.Before(r.first) {
+		r.first = ts
+	}
+	if r.lines == 0 || ts.After(r.last) {
+		r.last = ts
+	}
+	r.lines++
+}
+
+func (r *logTimeRange) parse(line string) (time.Time, bool) {
+	for _, format := range logTimestampFormats {
+		match := format.pattern.FindString(line)
+		if match == "" {
+			continue
+		}
+		ts, err := time.Parse(format.layout, strings.Replace(match, ",", ".", 1))
+		if err != nil {
+			return time.Time{}, false
+		}
+		if format.syslog {
+			if r.year == 0 {
+				r.year = time.Now().Year()
+			}
+			ts = ts.AddDate(r.year, 0, 0)
+		}
+		return ts, true
+	}
+	return time.Time{}, false
+}
+
+// metadata adds the time span to a log's metadata when any line had a timestamp
+func (r *logTimeRange) metadata(metadata map[string]string) {
+	if r.lines == 0 {
+		return
+	}
+	metadata["first_timestamp"] = r.first.Format(time.RFC3339)
+	metadata["last_timestamp"] = r.last.Format(time.RFC3339)
+	metadata["duration"] = r.last.Sub(r.first).String()
+	metadata["timestamped_lines"] = fmt.Sprintf("%d", r.lines)
+}
@@ -0,0 +1,112 @@
+package processors
+
+import (
+	"strings"
+)
+
+// MarkdownHeading is one entry of a Markdown document's outline
+type MarkdownHeading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	Line  int    `json:"line"` // 1-based line the heading text is on
+}
+
+// MarkdownOutline collects the ATX and Setext headings of a Markdown text in document order,
+// skipping fenced code blocks. It is the one outline parser, for extraction and summaries alike.
+func MarkdownOutline(text string) []MarkdownHeading {
+	outline := []MarkdownHeading{}
+	lines := strings.Split(text, "\n")
+	fence := ""
+	previous := "" // Last line that could be underlined as a Setext heading
+
+	for i, raw := range lines {
+		line := strings.TrimRight(raw, "\r")
+		trimmed, indented := trimMarkdownIndent(line)
+
+		if fence != "" {
+			// A closing fence uses the same character and is at least as long as the opening one
+			if indented && strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if open := markdownFence(trimmed); indented && open != "" {
+			fence = open
+			previous = ""
+			continue
+		}
+
+		if indented {
+			if level, text, ok := atxHeading(trimmed); ok {
+				outline = append(outline, MarkdownHeading{Level: level, Text: text, Line: i + 1})
+				previous = ""
+				continue
+			}
+			if level := setextUnderline(trimmed); level > 0 && previous != "" {
+				outline = append(outline, MarkdownHeading{Level: level, Text: previous, Line: i})
+				previous = ""
+				continue
+			}
+		}
+
+		if strings.TrimSpace(line) == "" {
+			previous = ""
+		} else {
+			previous = strings.TrimSpace(line)
+		}
+	}
+
+	return outline
+}
+
+// trimMarkdownIndent strips up to three leading spaces; more makes the line an indented code block
+func trimMarkdownIndent(line string) (string, bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	return trimmed, len(line)-len(trimmed) <= 3
+}
+
+// markdownFence returns the opening fence of a fenced code block, or "" when the line doesn't open one
+func markdownFence(line string) string {
+	for _, marker := range []string{"```", "~~~"} {
+		if strings.HasPrefix(line, marker) {
+			n := len(line) - len(strings.TrimLeft(line, marker[:1]))
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+// atxHeading parses a "# Heading" line, dropping an optional closing sequence of #s
+func atxHeading(line string) (int, string, bool) {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 {
+		return 0, "", false
+	}
+
+	rest := line[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, "", false // "#hashtag" isn't a heading
+	}
+
+	text := strings.TrimSpace(rest)
+	if closing := strings.TrimRight(text, "#"); closing == "" {
+		text = ""
+	} else if closing != text && strings.HasSuffix(closing, " ") {
+		text = strings.TrimSpace(closing)
+	}
+	return level, text, true
+}
+
+// setextUnderline returns 1 for an "===" underline, 2 for "---", and 0 otherwise
+func setextUnderline(line string) int {
+	line = strings.TrimSpace(line)
+	switch {
+	case line == "":
+		return 0
+	case strings.Trim(line, "=") == "":
+		return 1
+	case strings.Trim(line, "-") == "":
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package processors
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Markdown syntax removed by StripMarkdown, in the order it is applied
+var (
+	markdownHeaderPattern     = regexp.MustCompile(`^ {0,3}#{1,6}(\s+|$)`)
+	markdownClosingPattern    = regexp.MustCompile(`\s+#+\s*$`)
+	markdownRulePattern       = regexp.MustCompile(`^ {0,3}(=+|(-\s*){3,}|(\*\s*){3,}|(_\s*){3,})\s*$`)
+	markdownQuotePattern      = regexp.MustCompile(`^ {0,3}(>\s?)+`)
+	markdownImagePattern      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLinkPattern       = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	markdownRefLinkPattern    = regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`)
+	markdownRefDefPattern     = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s+\S+.*$`)
+	markdownBoldPattern       = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	markdownItalicPattern     = regexp.MustCompile(`\*(.+?)\*|\b_([^_]+)_\b`)
+	markdownStrikePattern     = regexp.MustCompile(`~~(.+?)~~`)
+	markdownInlineCodePattern = regexp.MustCompile("`+([^`]+?)`+")
+)
+
+// StripMarkdown removes Markdown syntax and keeps the visible text. Code block contents are kept
+// verbatim without their fences.
+func StripMarkdown(markdown string) string {
+	var out []string
+	fence := ""
+
+	for _, raw := range strings.Split(markdown, "\n") {
+		line := strings.TrimRight(raw, "\r")
+		trimmed, indented := trimMarkdownIndent(line)
+
+		if fence != "" {
+			if indented && strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+				continue
+			}
+			out = append(out, line)
+			continue
+		}
+		if open := markdownFence(trimmed); indented && open != "" {
+			fence = open
+			continue
+		}
+
+		if markdownRulePattern.MatchString(line) || markdownRefDefPattern.MatchString(line) {
+			continue
+		}
+
+		if markdownHeaderPattern.MatchString(line) {
+			line = markdownHeaderPattern.ReplaceAllString(line, "")
+			line = markdownClosingPattern.ReplaceAllString(line, "")
+		}
+		line = markdownQuotePattern.ReplaceAllString(line, "")
+		out = append(out, stripMarkdownInline(line))
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// stripMarkdownInline removes link, image, emphasis and code span syntax from a single line
+func stripMarkdownInline(line string) string {
+	// Code spans first so their contents aren't read as emphasis
+	var spans []string
+	line = markdownInlineCodePattern.ReplaceAllStringFunc(line, func(span string) string {
+		spans = append(spans, markdownInlineCodePattern.FindStringSubmatch(span)[1])
+		return "\x00"
+	})
+
+	line = markdownImagePattern.ReplaceAllString(line, "$1")
+	line = markdownLinkPattern.ReplaceAllString(line, "$1")
+	line = markdownRefLinkPattern.ReplaceAllString(line, "$1")
+	line = markdownBoldPattern.ReplaceAllString(line, "$1$2")
+	line = markdownItalicPattern.ReplaceAllString(line, "$1$2")
+	line = markdownStrikePattern.ReplaceAllString(line, "$1")
+
+	for _, span := range spans {
+		line = strings.Replace(line, "\x00", span, 1)
+	}
+	return line
+}
@@ -0,0 +1,149 @@
+package processors
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OCR shells out to poppler's pdftoppm and the tesseract CLI so the server binary stays CGO-free
+const (
+	ocrRasterizer = "pdftoppm"
+	ocrEngine     = "tesseract"
+	ocrDPI        = 300
+)
+
+// ocrPage is the recognized text of a single page
+type ocrPage struct {
+	Number     int
+	Text       string
+	Confidence float64 // Mean word confidence 0-100, -1 when tesseract reported none
+}
+
+// ocrAvailable reports whether the OCR tools are installed
+func ocrAvailable() error {
+	for _, tool := range []string{ocrRasterizer, ocrEngine} {
+		if _, err := exec.LookPath(tool); err != nil {
+			return fmt.Errorf("%s not found in PATH: %w", tool, err)
+		}
+	}
+	return nil
+}
+
+// ocrPDF rasterizes the pages of a PDF in the range, or every page for the zero range, and runs
+// them through tesseract
+func ocrPDF(ctx context.Context, path, language string, pages PageRange) ([]ocrPage, error) {
+	if err := ocrAvailable(); err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "pdf-ocr-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OCR work directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	args := []string{"-r", strconv.Itoa(ocrDPI), "-png"}
+	firstPage := 1
+	if !pages.IsZero() {
+		args = append(args, "-f", strconv.Itoa(pages.First), "-l", strconv.Itoa(pages.Last))
+		firstPage = pages.First
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ocrRasterizer, append(args, path, filepath.Join(dir, "page"))...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to rasterize PDF: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	images, err := filepath.Glob(filepath.Join(dir, "page-*.png"))
+	if err != nil || len(images) == 0 {
+		return nil, fmt.Errorf("rasterizing produced no pages")
+	}
+
+	// pdftoppm zero-pads page numbers to equal width, so a plain sort keeps page order
+	sort.Strings(images)
+
+	results := make([]ocrPage, 0, len(images))
+	for i, image := range images {
+		number := firstPage + i
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("stopped before OCR of page %d: %w", number, err)
+		}
+
+		page, err := ocrImage(ctx, image, language)
+		if err != nil {
+			return nil, fmt.Errorf("OCR failed on page %d: %w", number, err)
+		}
+		page.Number = number
+		results = append(results, page)
+	}
+
+	return results, nil
+}
+
+// ocrImage runs tesseract on one page image and rebuilds its lines from the TSV word boxes
+func ocrImage(ctx context.Context, image, language string) (ocrPage, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ocrEngine, image, "stdout", "-l", language, "tsv")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return ocrPage{}, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	// Columns: level page_num block_num par_num line_num word_num left top width height conf text
+	var text strings.Builder
+	var confidenceSum float64
+	words := 0
+	lastLine, lastBlock := "", ""
+
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 12 || fields[0] != "5" { // Level 5 rows are words
+			continue
+		}
+
+		word := strings.TrimSpace(fields[11])
+		if word == "" {
+			continue
+		}
+
+		block := fields[2]
+		line := strings.Join(fields[2:5], ".")
+		switch {
+		case lastLine == "":
+		case block != lastBlock:
+			text.WriteString("\n\n")
+		case line != lastLine:
+			text.WriteString("\n")
+		default:
+			text.WriteString(" ")
+		}
+		text.WriteString(word)
+		lastLine, lastBlock = line, block
+
+		if conf, err := strconv.ParseFloat(fields[10], 64); err == nil && conf >= 0 {
+			confidenceSum += conf
+			words++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ocrPage{}, fmt.Errorf("failed to read tesseract output: %w", err)
+	}
+
+	page := ocrPage{Text: text.String(), Confidence: -1}
+	if words > 0 {
+		page.Confidence = confidenceSum / float64(words)
+	}
+	return page, nil
+}
@@ -0,0 +1,107 @@
+package processors
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// PageRange is an inclusive range of 1-based PDF pages. The zero value means every page.
+type PageRange struct {
+	First int
+	Last  int
+}
+
+// ParsePageRange parses a range such as "3-5" or a single page such as "4"
+func ParsePageRange(value string) (PageRange, error) {
+	invalid := fmt.Errorf("invalid page range %q, use a page number or a range like 3-5", value)
+
+	firstText, lastText, isRange := strings.Cut(strings.TrimSpace(value), "-")
+	first, err := strconv.Atoi(strings.TrimSpace(firstText))
+	if err != nil || first < 1 {
+		return PageRange{}, invalid
+	}
+	last := first
+	if isRange {
+		if last, err = strconv.Atoi(strings.TrimSpace(lastText)); err != nil || last < first {
+			return PageRange{}, invalid
+		}
+	}
+	return PageRange{First: first, Last: last}, nil
+}
+
+// IsZero reports whether the range covers the whole document
+func (r PageRange) IsZero() bool {
+	return r.First == 0 && r.Last == 0
+}
+
+func (r PageRange) String() string {
+	if r.First == r.Last {
+		return strconv.Itoa(r.First)
+	}
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// PDFPage is the extracted text of one PDF page, including its page header
+type PDFPage struct {
+	Number int // 0 for text before the first page header
+	Text   string
+}
+
+var pdfPageHeaderPattern = regexp.MustCompile(`(?m)^--- Page (\d+) ---$`)
+
+// writePDFPage appends a page's text under the header SplitPDFPages looks for
+func writePDFPage(content *strings.Builder, number int, text string) {
+	content.WriteString(fmt.Sprintf("--- Page %d ---\n", number))
+	content.WriteString(text)
+	content.WriteString("\n\n")
+}
+
+// SplitPDFPages splits text extracted from a PDF at its page headers. Each page keeps its
+// header, so joining the pages' Text in order gives back the original text.
+func SplitPDFPages(text string) []PDFPage {
+	headers := pdfPageHeaderPattern.FindAllStringSubmatchIndex(text, -1)
+	if len(headers) == 0 {
+		return []PDFPage{{Text: text}}
+	}
+
+	var pages []PDFPage
+	if headers[0][0] > 0 {
+		pages = append(pages, PDFPage{Text: text[:headers[0][0]]})
+	}
+	for i, header := range headers {
+		end := len(text)
+		if i+1 < len(headers) {
+			end = headers[i+1][0]
+		}
+		number, _ := strconv.Atoi(text[header[2]:header[3]])
+		pages = append(pages, PDFPage{Number: number, Text: text[header[0]:end]})
+	}
+	return pages
+}
+
+// ProcessPDFPages extracts only the given pages of a PDF
+func (dm *DocumentManager) ProcessPDFPages(ctx context.Context, path string, pages PageRange) (*types.DocumentContent, error) {
+	processor, ok := dm.processors["pdf"].(*PDFProcessor)
+	if !ok {
+		return nil, fmt.Errorf("no PDF processor registered")
+	}
+
+	content, err := processor.ReadPages(ctx, path, pages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process pages %s of %s: %w", pages, filepath.Base(path), err)
+	}
+
+	normalizeExtractionInfo(content)
+	if content.Metadata == nil {
+		content.Metadata = make(map[string]string)
+	}
+	content.Metadata["processor_version"] = strconv.Itoa(processor.Version())
+	content.Metadata["pages"] = pages.String()
+	return content, nil
+}
@@ -0,0 +1,94 @@
+package processors
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Splitting shells out to poppler's pdfseparate and pdfunite, like OCR does with pdftoppm.
+// CheckExternalTools reports whether they are installed.
+const (
+	pdfSeparateTool = "pdfseparate"
+	pdfUniteTool    = "pdfunite"
+)
+
+// ErrInvalidPageRange is returned when a page range doesn't fit the document
+var ErrInvalidPageRange = errors.New("invalid page range")
+
+// ErrToolNotInstalled is returned when a tool a feature shells out to isn't in PATH
+var ErrToolNotInstalled = errors.New("required tool is not installed")
+
+// PDFPageCount returns the number of pages of a PDF
+func PDFPageCount(path string) (int, error) {
+	f, r, err := pdf.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+	return r.NumPage(), nil
+}
+
+// SplitPDF writes the pages in the range to a new PDF at outputPath
+func SplitPDF(ctx context.Context, inputPath, outputPath string, pages PageRange) error {
+	count, err := PDFPageCount(inputPath)
+	if err != nil {
+		return err
+	}
+	if pages.IsZero() || pages.Last > count {
+		return fmt.Errorf("%w: pages %s are outside the document's %d pages", ErrInvalidPageRange, pages, count)
+	}
+
+	for _, tool := range []string{pdfSeparateTool, pdfUniteTool} {
+		if _, err := exec.LookPath(tool); err != nil {
+			return fmt.Errorf("%w: %s not found in PATH", ErrToolNotInstalled, tool)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "pdf-split-*")
+	if err != nil {
+		return fmt.Errorf("failed to create split work directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = runPDFTool(ctx, pdfSeparateTool, "-f", strconv.Itoa(pages.First), "-l", strconv.Itoa(pages.Last),
+		inputPath, filepath.Join(dir, "page-%d.pdf"))
+	if err != nil {
+		return err
+	}
+
+	// pdfseparate names each file after its page number in the source document
+	args := make([]string, 0, pages.Last-pages.First+2)
+	for page := pages.First; page <= pages.Last; page++ {
+		args = append(args, filepath.Join(dir, fmt.Sprintf("page-%d.pdf", page)))
+	}
+	return runPDFTool(ctx, pdfUniteTool, append(args, outputPath)...)
+}
+
+// runPDFTool runs a poppler command, including its stderr in the error
+func runPDFTool(ctx context.Context, tool string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, tool, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", tool, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// MergePDFs concatenates the PDFs at inputPaths, in order, into a new PDF at outputPath
+func MergePDFs(ctx context.Context, inputPaths []string, outputPath string) error {
+	if _, err := exec.LookPath(pdfUniteTool); err != nil {
+		return fmt.Errorf("%w: %s not found in PATH", ErrToolNotInstalled, pdfUniteTool)
+	}
+	args := append(append([]string{}, inputPaths...), outputPath)
+	return runPDFTool(ctx, pdfUniteTool, args...)
+}
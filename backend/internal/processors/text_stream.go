@@ -0,0 +1,229 @@
+package processors
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+// DefaultMaxTextBytes caps the text kept in memory for streamed plain text files
+const DefaultMaxTextBytes = 10 * 1024 * 1024
+
+// maxLineBytes caps how much of a single line is handed to line callbacks
+const maxLineBytes = 64 * 1024
+
+// textStream holds the counts gathered while streaming a text file
+type textStream struct {
+	Text      string
+	Lines     int
+	Words     int
+	Bytes     int64
+	Truncated bool
+	Encoding  string // Detected source encoding; the text is always UTF-8
+}
+
+// textLimiter is implemented by processors whose retained text can be capped
+type textLimiter interface {
+	setMaxTextBytes(limit int64)
+}
+
+// streamTextFile reads a file line by line, keeping at most limit bytes of text while counting
+// lines, words and bytes over the whole file. Lines match strings.Split(text, "\n"), so onLine
+// also sees the final (possibly empty) segment after the last newline. Reading stops with ctx's
+// error once ctx is cancelled.
+func streamTextFile(ctx context.Context, path string, limit int64, onLine func(line string)) (*textStream, error) {
+	if limit <= 0 {
+		limit = DefaultMaxTextBytes
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	decoded, encoding, err := decodeToUTF8(f)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &textStream{Encoding: encoding}
+	reader := bufio.NewReaderSize(decoded, 64*1024)
+	var text strings.Builder
+	var line []byte
+	inWord := false
+
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+
+		result.Bytes += int64(len(chunk))
+
+		// Count words the way strings.Fields does, carrying state across chunks
+		for i := 0; i < len(chunk); {
+			r, size := utf8.DecodeRune(chunk[i:])
+			if unicode.IsSpace(r) {
+				inWord = false
+			} else if !inWord {
+				inWord = true
+				result.Words++
+			}
+			i += size
+		}
+
+		if !result.Truncated {
+			if room := limit - int64(text.Len()); int64(len(chunk)) <= room {
+				text.Write(chunk)
+			} else {
+				// Cut on a rune boundary so the kept text stays valid UTF-8
+				cut := int(room)
+				for cut > 0 && !utf8.RuneStart(chunk[cut]) {
+					cut--
+				}
+				text.Write(chunk[:cut])
+				result.Truncated = true
+			}
+		}
+
+		if onLine != nil && len(line) < maxLineBytes {
+			if room := maxLineBytes - len(line); len(chunk) > room {
+				line = append(line, chunk[:room]...)
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue // Same line continues in the next chunk
+		}
+
+		result.Lines++
+		if onLine != nil {
+			onLine(strings.TrimSuffix(string(line), "\n"))
+			line = line[:0]
+		}
+
+		if err == io.EOF {
+			break
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("stopped after %d lines: %w", result.Lines, ctxErr)
+		}
+	}
+
+	result.Text = text.String()
+	return result, nil
+}
+
+// encodingSniffBytes is how much of a file is inspected to detect its encoding
+const encodingSniffBytes = 4096
+
+// decodeToUTF8 detects the encoding of r from its byte order mark or content and returns a
+// reader producing UTF-8. Undetectable encodings are passed through as best-effort UTF-8.
+func decodeToUTF8(r io.Reader) (io.Reader, string, error) {
+	buffered := bufio.NewReaderSize(r, encodingSniffBytes)
+	sample, err := buffered.Peek(encodingSniffBytes)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		buffered.Discard(3)
+		return buffered, "utf-8", nil
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return xunicode.UTF16(xunicode.LittleEndian, xunicode.UseBOM).NewDecoder().Reader(buffered), "utf-16le", nil
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return xunicode.UTF16(xunicode.BigEndian, xunicode.UseBOM).NewDecoder().Reader(buffered), "utf-16be", nil
+	}
+
+	if endianness, ok := sniffUTF16(sample); ok {
+		name := "utf-16le"
+		if endianness == xunicode.BigEndian {
+			name = "utf-16be"
+		}
+		return xunicode.UTF16(endianness, xunicode.IgnoreBOM).NewDecoder().Reader(buffered), name, nil
+	}
+
+	if validUTF8Prefix(sample) {
+		return buffered, "utf-8", nil
+	}
+
+	// Single-byte text without control characters is most likely Latin-1/Windows-1252
+	if !containsBinaryBytes(sample) {
+		return charmap.Windows1252.NewDecoder().Reader(buffered), "windows-1252", nil
+	}
+
+	return buffered, "unknown", nil
+}
+
+// sniffUTF16 recognizes BOM-less UTF-16 by the NUL bytes of ASCII characters in alternating positions
+func sniffUTF16(sample []byte) (xunicode.Endianness, bool) {
+	if len(sample) < 4 {
+		return xunicode.LittleEndian, false
+	}
+
+	evenZeros, oddZeros := 0, 0
+	for i := 0; i+1 < len(sample); i += 2 {
+		if sample[i] == 0 {
+			evenZeros++
+		}
+		if sample[i+1] == 0 {
+			oddZeros++
+		}
+	}
+
+	pairs := len(sample) / 2
+	switch {
+	case oddZeros > pairs*4/10 && evenZeros < pairs/10:
+		return xunicode.LittleEndian, true
+	case evenZeros > pairs*4/10 && oddZeros < pairs/10:
+		return xunicode.BigEndian, true
+	}
+	return xunicode.LittleEndian, false
+}
+
+// validUTF8Prefix reports whether sample is valid UTF-8, ignoring a rune cut off at the end
+func validUTF8Prefix(sample []byte) bool {
+	for i := 1; i < utf8.UTFMax && i <= len(sample); i++ {
+		if utf8.RuneStart(sample[len(sample)-i]) {
+			if !utf8.FullRune(sample[len(sample)-i:]) {
+				sample = sample[:len(sample)-i]
+			}
+			break
+		}
+	}
+	return utf8.Valid(sample)
+}
+
+// containsBinaryBytes reports control bytes that don't occur in text files
+func containsBinaryBytes(sample []byte) bool {
+	for _, b := range sample {
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' && b != '\f' {
+			return true
+		}
+	}
+	return false
+}
+
+// textMetadata adds the encoding and, when the text was capped, the truncation flag to metadata
+func (s *textStream) textMetadata(metadata map[string]string) map[string]string {
+	metadata["encoding"] = s.Encoding
+	if s.Truncated {
+		metadata["truncated"] = "true"
+		metadata["truncated_at_bytes"] = fmt.Sprintf("%d", len(s.Text))
+	}
+	return metadata
+}
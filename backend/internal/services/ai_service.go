@@ -1,16 +1,23 @@
 package services
 
 import (
-	"bytes"
+	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"maps"
 	"net/http"
-	"os"
+	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/1DeliDolu/ki-ai-go/internal/config"
+	"github.com/1DeliDolu/ki-ai-go/internal/logging"
+	"github.com/1DeliDolu/ki-ai-go/internal/utils"
 	"github.com/1DeliDolu/ki-ai-go/pkg/types"
 )
 
@@ -26,6 +33,7 @@ type OllamaGenerateResponse struct {
 	Response string `json:"response"`
 	Done     bool   `json:"done"`
 	Model    string `json:"model,omitempty"`
+	Error    string `json:"error,omitempty"` // Set instead of Response when generation failed
 }
 
 type OllamaPullRequest struct {
@@ -39,29 +47,137 @@ type AIService struct {
 	currentModel  string
 	isModelLoaded bool
 	ollamaService *OllamaService
+	retriever     ChunkRetriever
+	defaultModel  types.DefaultModelStatus
+	generation    *GenerationLimiter
+	conversations *ConversationStore
+	retry         ollamaRetry
+}
+
+// ChunkRetriever returns the extracted content of a document and the chunks that best match a query
+type ChunkRetriever interface {
+	GetDocumentContent(documentID string) (*types.DocumentContent, error)
+	RetrieveChunkText(documentID, query string) (string, error)
+	RetrieveChunks(documentID, query string, limit int) ([]*types.DocumentChunk, error)
 }
 
 func NewAIService(cfg *config.Config) *AIService {
-	return &AIService{
+	logging.SetDocumentContent(cfg.LogDocumentContent)
+
+	s := &AIService{
 		config: cfg,
 		client: &http.Client{
-			Timeout: 120 * time.Second, // 2 minutes timeout for AI responses
+			Timeout: time.Duration(cfg.OllamaGenerateTimeout) * time.Second,
 		},
-		ollamaService: NewOllamaService(), // Initialize ollama service
+		ollamaService: NewOllamaService(cfg), // Initialize ollama service
+		generation:    NewGenerationLimiter(cfg),
+		conversations: NewConversationStore(cfg),
+		retry:         newOllamaRetry(cfg),
+	}
+
+	if cfg.DefaultModel != "" {
+		s.loadDefaultModel(cfg.DefaultModel)
+	}
+
+	return s
+}
+
+// loadDefaultModel loads the configured default model, leaving the service usable without it on failure
+func (s *AIService) loadDefaultModel(modelName string) {
+	s.defaultModel.Name = modelName
+
+	if err := s.ollamaService.Ping(); err != nil {
+		s.defaultModel.Error = err.Error()
+		log.Printf("⚠️ Skipping default model %s, Ollama is not reachable: %v", modelName, err)
+		return
+	}
+
+	if err := s.LoadModel(modelName); err != nil {
+		s.defaultModel.Error = err.Error()
+		log.Printf("❌ Failed to load default model %s: %v", modelName, err)
+		return
 	}
+
+	s.defaultModel.Loaded = true
+	log.Printf("✅ Default model %s loaded", modelName)
+}
+
+// DefaultModelStatus reports the outcome of loading the default model on startup
+func (s *AIService) DefaultModelStatus() types.DefaultModelStatus {
+	return s.defaultModel
+}
+
+// SetChunkRetriever sets where document content and chunks for the context are read from
+func (s *AIService) SetChunkRetriever(retriever ChunkRetriever) {
+	s.retriever = retriever
 }
 
-// generateWithOllama - added missing method
-func (s *AIService) generateWithOllama(prompt, modelName string) (string, error) {
+// GenerationStats reports the per-model generation queues
+func (s *AIService) GenerationStats() map[string]interface{} {
+	return s.generation.Stats()
+}
+
+// defaultGenerateOptions are the Ollama options of every generation, requests may override them
+var defaultGenerateOptions = map[string]interface{}{
+	"temperature": 0.7,
+	"top_p":       0.9,
+	"top_k":       40,
+}
+
+// generateOptions merges the request options over the defaults. The context window is sized to
+// LlamaContextSize and answers to AnswerTokens unless the request sets num_ctx or num_predict,
+// so prompts are budgeted for the window in use.
+func (s *AIService) generateOptions(options map[string]interface{}) map[string]interface{} {
+	defaults := maps.Clone(defaultGenerateOptions)
+	if s.config.LlamaContextSize > 0 {
+		defaults["num_ctx"] = s.config.LlamaContextSize
+	}
+	if s.config.AnswerTokens > 0 {
+		defaults["num_predict"] = s.config.AnswerTokens
+	}
+	return mergeOllamaOptions(defaults, options)
+}
+
+// contextWindow returns the context size in tokens a generation with options runs with
+func (s *AIService) contextWindow(options map[string]interface{}) int {
+	if n, ok := intOption(options, "num_ctx"); ok {
+		return n
+	}
+	return s.config.LlamaContextSize
+}
+
+// answerTokens returns the tokens of the context window reserved for the answer
+func (s *AIService) answerTokens(options map[string]interface{}) int {
+	if n, ok := intOption(options, "num_predict"); ok && n > 0 {
+		return n
+	}
+	return s.config.AnswerTokens
+}
+
+// intOption reads a numeric option, which is a float64 when it came from JSON
+func intOption(options map[string]interface{}, key string) (int, bool) {
+	switch n := options[key].(type) {
+	case float64:
+		return int(n), true
+	case int:
+		return n, true
+	}
+	return 0, false
+}
+
+// generateWithOllama generates a response, options override the defaults and must be validated
+// with ValidateOllamaOptions
+func (s *AIService) generateWithOllama(ctx context.Context, prompt, modelName string, options map[string]interface{}) (string, error) {
+	if err := s.generation.Acquire(ctx, modelName); err != nil {
+		return "", err
+	}
+	defer s.generation.Release(modelName)
+
 	reqBody := OllamaGenerateRequest{
-		Model:  modelName,
-		Prompt: prompt,
-		Stream: false,
-		Options: map[string]interface{}{
-			"temperature": 0.7,
-			"top_p":       0.9,
-			"top_k":       40,
-		},
+		Model:   modelName,
+		Prompt:  prompt,
+		Stream:  false,
+		Options: s.generateOptions(options),
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
@@ -69,7 +185,7 @@ func (s *AIService) generateWithOllama(prompt, modelName string) (string, error)
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	resp, err := s.client.Post(s.config.OllamaURL+"/api/generate", "application/json", bytes.NewReader(jsonBody))
+	resp, err := s.retry.post(ctx, s.client, s.config.OllamaURL+"/api/generate", jsonBody)
 	if err != nil {
 		return "", fmt.Errorf("failed to connect to Ollama: %w", err)
 	}
@@ -83,10 +199,68 @@ func (s *AIService) generateWithOllama(prompt, modelName string) (string, error)
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
 		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
+	if response.Error != "" {
+		return "", fmt.Errorf("Ollama generation failed: %s", response.Error)
+	}
 
 	return response.Response, nil
 }
 
+// generateStreamWithOllama generates a response with streaming enabled, calling onToken with each
+// piece of text as Ollama produces it. It stops when ctx is cancelled or onToken fails and returns
+// the text generated so far.
+func (s *AIService) generateStreamWithOllama(ctx context.Context, prompt, modelName string, options map[string]interface{}, onToken func(string) error) (string, error) {
+	if err := s.generation.Acquire(ctx, modelName); err != nil {
+		return "", err
+	}
+	defer s.generation.Release(modelName)
+
+	jsonBody, err := json.Marshal(OllamaGenerateRequest{
+		Model:   modelName,
+		Prompt:  prompt,
+		Stream:  true,
+		Options: s.generateOptions(options),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := s.retry.post(ctx, s.client, s.config.OllamaURL+"/api/generate", jsonBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Ollama API error: HTTP %d", resp.StatusCode)
+	}
+
+	// Ollama streams one JSON object per line until one has done set
+	var response strings.Builder
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var part OllamaGenerateResponse
+		if err := decoder.Decode(&part); err != nil {
+			if err == io.EOF {
+				return response.String(), fmt.Errorf("Ollama stream ended before the response was done")
+			}
+			return response.String(), fmt.Errorf("failed to decode response: %w", err)
+		}
+		if part.Error != "" {
+			return response.String(), fmt.Errorf("Ollama generation failed: %s", part.Error)
+		}
+		if part.Response != "" {
+			response.WriteString(part.Response)
+			if err := onToken(part.Response); err != nil {
+				return response.String(), err
+			}
+		}
+		if part.Done {
+			return response.String(), nil
+		}
+	}
+}
+
 func (s *AIService) LoadModel(modelName string) error {
 	log.Printf("Loading model: %s", modelName)
 
@@ -125,43 +299,179 @@ func (s *AIService) LoadModel(modelName string) error {
 
 func (s *AIService) testModelWithOllama(modelName string) error {
 	// Test with a simple prompt
-	_, err := s.generateWithOllama("test", modelName)
+	_, err := s.generateWithOllama(context.Background(), "test", modelName, nil)
 	return err
 }
 
-func (s *AIService) GenerateResponse(query string, documents []types.Document, wikiResults []types.WikiResult) (string, error) {
-	log.Printf("🤖 Generating AI response for query: %s", query)
+// promptTemplate holds the localized scaffolding around the RAG prompt
+type promptTemplate struct {
+	DocumentsHeader     string
+	WikiHeader          string
+	Question            string
+	Instruction         string
+	NoRelevantDocuments string // Replaces the document context when no document is relevant enough
+	HistoryHeader       string
+	UserLabel           string
+	AssistantLabel      string
+}
 
-	// Build context from documents with ACTUAL CONTENT
-	var context strings.Builder
-	context.WriteString("Context from uploaded documents:\n\n")
+var promptTemplates = map[string]promptTemplate{
+	"en": {
+		DocumentsHeader:     "Context from uploaded documents:",
+		WikiHeader:          "Additional context from Wikipedia:",
+		Question:            "Based on the following documents and context, please answer this question: %s",
+		Instruction:         "Please provide a detailed answer based on the content above. If the answer is found in the documents, reference which document contains the information. Answer in English.",
+		NoRelevantDocuments: "(No uploaded document is relevant to this question. Say so instead of guessing from unrelated documents.)",
+		HistoryHeader:       "Previous conversation:",
+		UserLabel:           "User",
+		AssistantLabel:      "Assistant",
+	},
+	"de": {
+		DocumentsHeader:     "Kontext aus den hochgeladenen Dokumenten:",
+		WikiHeader:          "Zusätzlicher Kontext aus Wikipedia:",
+		Question:            "Bitte beantworte anhand der folgenden Dokumente und des Kontexts diese Frage: %s",
+		Instruction:         "Bitte gib eine ausführliche Antwort auf Grundlage des obigen Inhalts. Wenn die Antwort in den Dokumenten steht, nenne das Dokument, das die Information enthält. Antworte auf Deutsch.",
+		NoRelevantDocuments: "(Keines der hochgeladenen Dokumente ist für diese Frage relevant. Sage das, statt aus fremden Dokumenten zu raten.)",
+		HistoryHeader:       "Bisheriger Gesprächsverlauf:",
+		UserLabel:           "Benutzer",
+		AssistantLabel:      "Assistent",
+	},
+	"tr": {
+		DocumentsHeader:     "Yüklenen dokümanlardan bağlam:",
+		WikiHeader:          "Wikipedia'dan ek bağlam:",
+		Question:            "Aşağıdaki dokümanlara ve bağlama dayanarak lütfen şu soruyu yanıtla: %s",
+		Instruction:         "Lütfen yukarıdaki içeriğe dayanarak ayrıntılı bir yanıt ver. Yanıt dokümanlarda bulunuyorsa, bilginin hangi dokümanda olduğunu belirt. Türkçe yanıt ver.",
+		NoRelevantDocuments: "(Yüklenen dokümanların hiçbiri bu soruyla ilgili değil. İlgisiz dokümanlardan tahmin yürütmek yerine bunu belirt.)",
+		HistoryHeader:       "Önceki konuşma:",
+		UserLabel:           "Kullanıcı",
+		AssistantLabel:      "Asistan",
+	},
+}
+
+// resolveLanguage picks the answer language: request, then config default, then detection
+func (s *AIService) resolveLanguage(requested, sample string) string {
+	for _, lang := range []string{requested, s.config.ResponseLanguage} {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if lang == "" || lang == "auto" {
+			continue
+		}
+		if _, ok := promptTemplates[lang]; ok {
+			return lang
+		}
+		log.Printf("⚠️ Unsupported response language %q, ignoring", lang)
+	}
 
+	if detected := utils.DetectLanguage(sample); detected != "unknown" {
+		if _, ok := promptTemplates[detected]; ok {
+			return detected
+		}
+	}
+	return "en"
+}
+
+func (s *AIService) GenerateResponse(query string, documents []types.Document, wikiResults []types.WikiResult, language string) (string, error) {
+	response, _, _, err := s.GenerateResponseWithPrompt(query, documents, wikiResults, language, nil)
+	return response, err
+}
+
+// BuildPrompt assembles the RAG prompt sent to the model
+func (s *AIService) BuildPrompt(query string, documents []types.Document, wikiResults []types.WikiResult, language string) string {
+	prompt, _, _ := s.buildPrompt(context.Background(), nil, query, documents, wikiResults, language, false, nil)
+	return prompt
+}
+
+// buildPrompt assembles the prompt. With retrieve set, every document contributes the chunks that
+// best match the query instead of its whole file. Documents below the relevance threshold are left
+// out, the rest are added most relevant first until the context budget is spent. The budget leaves
+// room in the context window of the generation options for the rest of the prompt and the answer.
+// Prior turns of the conversation come before the question. Re-ranking stops when ctx is cancelled.
+// Besides the prompt it returns the document text that made it into the prompt.
+func (s *AIService) buildPrompt(ctx context.Context, history []types.ChatMessage, query string, documents []types.Document, wikiResults []types.WikiResult, language string, retrieve bool, options map[string]interface{}) (string, types.ContextSelection, []contextExcerpt) {
+	contextTokens, answerTokens := s.contextWindow(options), s.answerTokens(options)
+
+	type candidate struct {
+		doc   types.Document
+		text  string
+		score float64
+	}
+
+	selection := types.ContextSelection{Candidates: len(documents), Included: []string{}}
+	var candidates []candidate
 	for _, doc := range documents {
-		// Get actual document content, not just metadata
-		if doc.Path != "" {
-			// Read file content directly
-			if content, err := os.ReadFile(doc.Path); err == nil {
-				context.WriteString(fmt.Sprintf("=== Document: %s ===\n", doc.Name))
-				context.WriteString(string(content))
-				context.WriteString("\n\n")
-				log.Printf("📄 Added content from %s (%d bytes)", doc.Name, len(content))
-			} else {
-				context.WriteString(fmt.Sprintf("=== Document: %s ===\n", doc.Name))
-				context.WriteString("(Content could not be read)\n\n")
-				log.Printf("❌ Could not read content from %s: %v", doc.Name, err)
+		if ctx.Err() != nil {
+			break // Nobody is waiting for the prompt
+		}
+		text, err := s.documentText(ctx, doc, query, retrieve)
+		if err != nil {
+			log.Printf("❌ Could not read content from %s: %v", logging.Document(doc.Name, doc.ID), err)
+			selection.Unreadable++
+			continue
+		}
+
+		score := utils.RelevanceScore(query, doc.Name+"\n"+text)
+		if score < s.config.MinRelevanceScore {
+			log.Printf("🔍 Skipping %s, relevance %.2f is below %.2f", logging.Document(doc.Name, doc.ID), score, s.config.MinRelevanceScore)
+			selection.BelowThreshold++
+			continue
+		}
+		candidates = append(candidates, candidate{doc: doc, text: text, score: score})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	budget, limited := s.contextBudget(promptOverhead(history, query, wikiResults), contextTokens, answerTokens)
+	if limited {
+		selection.BudgetChars = budget
+	}
+
+	// Build context from documents with ACTUAL CONTENT
+	var context strings.Builder
+	var excerpts []contextExcerpt
+	for _, c := range candidates {
+		text := c.text
+		header := fmt.Sprintf("=== Document: %s ===\n", c.doc.Name)
+		if limited {
+			remaining := budget - context.Len() - len(header) - 2
+			if remaining <= 0 {
+				log.Printf("✂️ Leaving out %s (%d bytes), the context budget of %d bytes is spent", logging.Document(c.doc.Name, c.doc.ID), len(text), budget)
+				selection.OverBudget++
+				selection.OmittedChars += len(text)
+				continue
+			}
+			if len(text) > remaining {
+				text = truncateUTF8(text, remaining)
+				log.Printf("✂️ Truncated %s from %d to %d bytes to fit the context budget", logging.Document(c.doc.Name, c.doc.ID), len(c.text), len(text))
+				selection.Truncated++
+				selection.OmittedChars += len(c.text) - len(text)
 			}
-		} else {
-			context.WriteString(fmt.Sprintf("=== Document: %s ===\n", doc.Name))
-			context.WriteString("(No file path available)\n\n")
 		}
+
+		context.WriteString(header)
+		context.WriteString(text)
+		context.WriteString("\n\n")
+		selection.Included = append(selection.Included, c.doc.ID)
+		excerpts = append(excerpts, contextExcerpt{name: c.doc.Name, text: text})
+		selection.IncludedChars += len(text)
+		log.Printf("📄 Added content from %s (%d bytes, relevance %.2f)", logging.Document(c.doc.Name, c.doc.ID), len(text), c.score)
+	}
+
+	// Query wording wins over document language when detecting
+	sample := " " + query + " "
+	if utils.DetectLanguage(sample) == "unknown" {
+		sample += context.String()
 	}
+	lang := s.resolveLanguage(language, sample)
+	tmpl := promptTemplates[lang]
+	log.Printf("🌐 Answering in language: %s", lang)
 
-	// Add wiki context - fix Summary field issue
+	if len(documents) > 0 && len(selection.Included) == 0 {
+		context.WriteString(tmpl.NoRelevantDocuments + "\n\n")
+	}
+
+	// Add wiki context
 	if len(wikiResults) > 0 {
-		context.WriteString("Additional context from Wikipedia:\n\n")
+		context.WriteString(tmpl.WikiHeader + "\n\n")
 		for _, wiki := range wikiResults {
-			// Use Description instead of Summary if Summary doesn't exist
-			summary := wiki.Description
+			summary := wikiSummary(wiki.Summary, wiki.Extract, wiki.Description)
 			if summary == "" {
 				summary = "No description available"
 			}
@@ -171,41 +481,252 @@ func (s *AIService) GenerateResponse(query string, documents []types.Document, w
 	}
 
 	// Enhanced prompt with document content
-	prompt := fmt.Sprintf(`Based on the following documents and context, please answer this question: %s
+	prompt := formatHistory(history, tmpl) + fmt.Sprintf(tmpl.Question, query) + "\n\n" +
+		tmpl.DocumentsHeader + "\n\n" + context.String() + "\n" +
+		tmpl.Instruction
+
+	return prompt, selection, excerpts
+}
 
-%s
+// contextExcerpt is the text of a document as it was included in the prompt
+type contextExcerpt struct {
+	name string
+	text string
+}
 
-Please provide a detailed answer based on the content above. If the answer is found in the documents, reference which document contains the information.`,
-		query, context.String())
+// contextBudget returns how many bytes of document context fit the prompt, and whether there is a
+// limit at all. The window of contextTokens must also hold the rest of the prompt (overhead) and
+// answerTokens for the answer. MaxContextChars caps the budget further.
+func (s *AIService) contextBudget(overhead string, contextTokens, answerTokens int) (int, bool) {
+	budget, limited := s.config.MaxContextChars, s.config.MaxContextChars > 0
+	if contextTokens > 0 {
+		free := contextTokens - answerTokens - utils.EstimateTokens(overhead)
+		fit := max(free, 0) * utils.CharsPerToken
+		if !limited || fit < budget {
+			budget, limited = fit, true
+		}
+	}
+	return budget, limited
+}
+
+// promptOverhead approximates the prompt without its document context. The English template
+// stands in for all languages, their scaffolding is of similar length.
+func promptOverhead(history []types.ChatMessage, query string, wikiResults []types.WikiResult) string {
+	tmpl := promptTemplates["en"]
+
+	var b strings.Builder
+	b.WriteString(formatHistory(history, tmpl))
+	b.WriteString(fmt.Sprintf(tmpl.Question, query) + "\n\n" + tmpl.DocumentsHeader + "\n\n")
+	b.WriteString(tmpl.NoRelevantDocuments + "\n\n" + tmpl.WikiHeader + "\n\n")
+	for _, wiki := range wikiResults {
+		b.WriteString(fmt.Sprintf("- %s: %s\n", wiki.Title, wikiSummary(wiki.Summary, wiki.Extract, wiki.Description)))
+	}
+	b.WriteString("\n" + tmpl.Instruction)
+	return b.String()
+}
+
+// formatHistory renders prior conversation turns for the prompt
+func formatHistory(history []types.ChatMessage, tmpl promptTemplate) string {
+	if len(history) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(tmpl.HistoryHeader + "\n\n")
+	for _, msg := range history {
+		label := tmpl.UserLabel
+		if msg.Role == types.ChatRoleAssistant {
+			label = tmpl.AssistantLabel
+		}
+		b.WriteString(fmt.Sprintf("%s: %s\n", label, msg.Content))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// documentText returns the text a document contributes to the prompt
+func (s *AIService) documentText(ctx context.Context, doc types.Document, query string, retrieve bool) (string, error) {
+	switch {
+	case retrieve && s.retriever != nil:
+		return s.retrieveText(ctx, doc.ID, query)
+	case doc.Path != "" && s.retriever != nil:
+		return s.extractedText(doc.ID)
+	case doc.Embeddings && s.retriever != nil:
+		// Embeddings-only documents have no source file, only stored chunks
+		return s.retrieveText(ctx, doc.ID, query)
+	}
+	return "", fmt.Errorf("no file path available")
+}
+
+// extractedText returns the markup-free text of a document, so binary formats and markup are
+// scored and quoted by their words, not their bytes
+func (s *AIService) extractedText(documentID string) (string, error) {
+	content, err := s.retriever.GetDocumentContent(documentID)
+	if err != nil {
+		return "", err
+	}
+	return content.RetrievalText(), nil
+}
+
+// retrieveText returns the chunks of a document that best match the query. With re-ranking
+// enabled, more candidates are retrieved and the model's ratings pick the ones that are kept.
+func (s *AIService) retrieveText(ctx context.Context, documentID, query string) (string, error) {
+	if !s.config.RerankEnabled {
+		return s.retriever.RetrieveChunkText(documentID, query)
+	}
+
+	candidates, err := s.retriever.RetrieveChunks(documentID, query, max(s.config.RerankCandidates, s.config.EmbeddingTopK))
+	if err != nil {
+		return "", err
+	}
+	return joinChunks(s.rerankChunks(ctx, query, candidates, s.config.EmbeddingTopK)), nil
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// GenerateResponseWithPrompt generates a response and also returns the prompt that was used and
+// which documents made it into the context. Options override the default Ollama options.
+func (s *AIService) GenerateResponseWithPrompt(query string, documents []types.Document, wikiResults []types.WikiResult, language string, options map[string]interface{}) (string, string, types.ContextSelection, error) {
+	return s.generateResponse(context.Background(), nil, query, documents, wikiResults, language, false, options)
+}
+
+// GenerateResponseFromDocuments generates a response grounded in the extracted chunks of the given
+// documents that best match the query
+func (s *AIService) GenerateResponseFromDocuments(query string, documents []types.Document, wikiResults []types.WikiResult, language string, options map[string]interface{}) (string, string, types.ContextSelection, error) {
+	return s.generateResponse(context.Background(), nil, query, documents, wikiResults, language, true, options)
+}
+
+// GenerateResponseWithHistory generates a response like GenerateResponseWithPrompt (or
+// GenerateResponseFromDocuments when retrieve is set) with the prior turns of a conversation
+// added to the prompt, so follow-up questions keep their context. Waiting for the model stops
+// when ctx is cancelled.
+func (s *AIService) GenerateResponseWithHistory(ctx context.Context, history []types.ChatMessage, query string, documents []types.Document, wikiResults []types.WikiResult, language string, retrieve bool, options map[string]interface{}) (string, string, types.ContextSelection, error) {
+	return s.generateResponse(ctx, history, query, documents, wikiResults, language, retrieve, options)
+}
+
+func (s *AIService) generateResponse(ctx context.Context, history []types.ChatMessage, query string, documents []types.Document, wikiResults []types.WikiResult, language string, retrieve bool, options map[string]interface{}) (string, string, types.ContextSelection, error) {
+	log.Printf("🤖 Generating AI response for query: %s", logging.Text(query))
+
+	prompt, selection, excerpts := s.buildPrompt(ctx, history, query, documents, wikiResults, language, retrieve, options)
 
 	// Generate response using the current model
 	if s.currentModel == "" {
-		return "Please load a model first to generate responses.", nil
+		return "Please load a model first to generate responses.", prompt, selection, nil
 	}
 
 	// Use generateWithOllama method
-	response, err := s.generateWithOllama(prompt, s.currentModel)
+	response, err := s.generateWithOllama(ctx, prompt, s.currentModel, options)
+	if errors.Is(err, ErrGenerationQueueFull) {
+		log.Printf("⚠️ Generation queue for %s is full", s.currentModel)
+		return "", prompt, selection, err // Not worth a fallback answer, the caller should retry
+	}
+	if ctx.Err() != nil {
+		return "", prompt, selection, err // Nobody is waiting for a fallback answer
+	}
 	if err != nil {
 		log.Printf("❌ Error generating response: %v", err)
 
-		// Fallback: Provide basic response with document content
-		if len(documents) > 0 {
-			fallback := fmt.Sprintf("I found %d document(s) related to your query:\n\n", len(documents))
-			for _, doc := range documents {
-				if doc.Path != "" {
-					if content, err := os.ReadFile(doc.Path); err == nil {
-						fallback += fmt.Sprintf("**%s:**\n%s\n\n", doc.Name, string(content))
-					}
-				}
+		// Fallback: quote the document text selected for the prompt, which already honours the
+		// relevance threshold and the context budget
+		if len(excerpts) > 0 {
+			var fallback strings.Builder
+			fallback.WriteString(fmt.Sprintf("I found %d document(s) related to your query:\n\n", len(excerpts)))
+			for _, excerpt := range excerpts {
+				fallback.WriteString(fmt.Sprintf("**%s:**\n%s\n\n", excerpt.name, excerpt.text))
 			}
-			return fallback, nil
+			return fallback.String(), prompt, selection, nil
 		}
 
-		return fmt.Errorf("failed to generate AI response: %w", err).Error(), nil
+		return fmt.Errorf("failed to generate AI response: %w", err).Error(), prompt, selection, nil
 	}
 
 	log.Printf("✅ Generated AI response (%d characters)", len(response))
-	return response, nil
+	return response, prompt, selection, nil
+}
+
+// GenerateResponseStream generates a response like GenerateResponseWithPrompt (or
+// GenerateResponseFromDocuments when retrieve is set), passing each token to onToken as it
+// arrives. There is no fallback answer, a failed generation returns its error.
+func (s *AIService) GenerateResponseStream(ctx context.Context, history []types.ChatMessage, query string, documents []types.Document, wikiResults []types.WikiResult, language string, retrieve bool, options map[string]interface{}, onToken func(string) error) (string, string, types.ContextSelection, error) {
+	log.Printf("🤖 Streaming AI response for query: %s", logging.Text(query))
+
+	prompt, selection, _ := s.buildPrompt(ctx, history, query, documents, wikiResults, language, retrieve, options)
+	if s.currentModel == "" {
+		return "", prompt, selection, fmt.Errorf("no model loaded, please load a model first")
+	}
+
+	response, err := s.generateStreamWithOllama(ctx, prompt, s.currentModel, options, onToken)
+	if err != nil {
+		log.Printf("❌ Error streaming response: %v", err)
+		return response, prompt, selection, err
+	}
+
+	log.Printf("✅ Streamed AI response (%d characters)", len(response))
+	return response, prompt, selection, nil
+}
+
+// StartConversation creates a conversation for owner and returns its ID, "" when history is disabled
+func (s *AIService) StartConversation(owner string) string {
+	return s.conversations.Start(owner)
+}
+
+// ConversationHistory returns the prior messages of one of owner's conversations and whether it exists
+func (s *AIService) ConversationHistory(owner, conversationID string) ([]types.ChatMessage, bool) {
+	return s.conversations.History(owner, conversationID)
+}
+
+// RecordConversationTurn adds a question and its answer to one of owner's conversations
+func (s *AIService) RecordConversationTurn(owner, conversationID, query, response string) {
+	s.conversations.AppendTurn(owner, conversationID, query, response)
+}
+
+// DeleteConversation forgets one of owner's conversations and reports whether it existed
+func (s *AIService) DeleteConversation(owner, conversationID string) bool {
+	return s.conversations.Delete(owner, conversationID)
+}
+
+// IsDebugAllowed reports whether prompt debugging may be returned to the caller. It must be
+// requested, and the caller must either be an admin with debugging enabled or send the debug token.
+func (s *AIService) IsDebugAllowed(requested bool, token string, access types.AccessContext) bool {
+	if !requested {
+		return false
+	}
+	if s.config.DebugPrompts && access.IsAdmin {
+		return true
+	}
+	return s.config.DebugToken != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(s.config.DebugToken)) == 1
+}
+
+// BuildQueryDebug packages the prompt and selected documents for a debug response
+func (s *AIService) BuildQueryDebug(prompt string, documents []types.Document) *types.QueryDebug {
+	debug := &types.QueryDebug{
+		Prompt:        prompt,
+		PromptLength:  len(prompt),
+		DocumentIDs:   []string{},
+		DocumentNames: []string{},
+	}
+
+	if limit := s.config.DebugPromptMaxChars; limit > 0 && len(prompt) > limit {
+		debug.Prompt = utils.TruncateString(prompt, limit)
+		debug.PromptTruncated = true
+	}
+
+	for _, doc := range documents {
+		debug.DocumentIDs = append(debug.DocumentIDs, doc.ID)
+		debug.DocumentNames = append(debug.DocumentNames, doc.Name)
+	}
+
+	return debug
 }
 
 func (s *AIService) GetCurrentModel() string {
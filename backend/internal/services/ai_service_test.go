@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/config"
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+func TestIsDebugAllowed(t *testing.T) {
+	admin := types.AccessContext{User: "admin", IsAdmin: true}
+
+	tests := []struct {
+		name         string
+		debugPrompts bool
+		debugToken   string
+		requested    bool
+		token        string
+		access       types.AccessContext
+		want         bool
+	}{
+		{"not requested", true, "secret", false, "secret", admin, false},
+		{"matching token", false, "secret", true, "secret", types.AccessContext{}, true},
+		{"wrong token", false, "secret", true, "secreT", types.AccessContext{}, false},
+		{"token prefix", false, "secret", true, "sec", types.AccessContext{}, false},
+		{"longer token", false, "secret", true, "secret2", types.AccessContext{}, false},
+		{"no token configured", false, "", true, "", types.AccessContext{}, false},
+		{"admin with debugging enabled", true, "", true, "", admin, true},
+		{"admin with debugging disabled", false, "", true, "", admin, false},
+		{"user with debugging enabled", true, "", true, "", types.AccessContext{User: "bob"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &AIService{config: &config.Config{DebugPrompts: tt.debugPrompts, DebugToken: tt.debugToken}}
+			if got := s.IsDebugAllowed(tt.requested, tt.token, tt.access); got != tt.want {
+				t.Errorf("IsDebugAllowed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
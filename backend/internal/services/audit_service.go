@@ -0,0 +1,136 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/config"
+	"github.com/1DeliDolu/ki-ai-go/internal/utils"
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// Audit event names
+const (
+	AuditEventView      = "view_content"
+	AuditEventDownload  = "download"
+	AuditEventConvert   = "convert"
+	AuditEventDelete    = "delete"
+	AuditEventSearchHit = "search_hit"
+	AuditEventSplit     = "split"
+	AuditEventMerge     = "merge"
+	AuditEventUpdate    = "update"
+)
+
+// maxAuditDetailsLength caps the details of an entry, e.g. a long search query
+const maxAuditDetailsLength = 1024
+
+// AuditService writes document events to an append-only JSON lines file
+type AuditService struct {
+	mu      sync.Mutex
+	enabled bool
+	path    string
+}
+
+func NewAuditService(cfg *config.Config) *AuditService {
+	if cfg.AuditLogEnabled {
+		if err := os.MkdirAll(filepath.Dir(cfg.AuditLogPath), 0755); err != nil {
+			log.Printf("Warning: Failed to create audit log directory: %v", err)
+		}
+	}
+
+	return &AuditService{
+		enabled: cfg.AuditLogEnabled,
+		path:    cfg.AuditLogPath,
+	}
+}
+
+// IsEnabled reports whether audit logging is active
+func (s *AuditService) IsEnabled() bool {
+	return s != nil && s.enabled
+}
+
+// Record appends an event for a document by user to the audit log, details are truncated
+func (s *AuditService) Record(documentID, event, requestID, clientIP, user, details string) {
+	if !s.IsEnabled() {
+		return
+	}
+
+	entry := types.AuditEntry{
+		Timestamp:  time.Now().Format(time.RFC3339),
+		DocumentID: documentID,
+		Event:      event,
+		RequestID:  requestID,
+		ClientIP:   clientIP,
+		User:       user,
+		Details:    utils.TruncateString(details, maxAuditDetailsLength),
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("⚠️ Failed to marshal audit entry: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("⚠️ Failed to open audit log: %v", err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.Printf("⚠️ Failed to write audit entry: %v", err)
+	}
+}
+
+// GetDocumentHistory returns all recorded events for a document in chronological order
+func (s *AuditService) GetDocumentHistory(documentID string) ([]types.AuditEntry, error) {
+	if !s.IsEnabled() {
+		return nil, fmt.Errorf("audit logging is disabled")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []types.AuditEntry{}, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	// Lines are read whole rather than with a Scanner, whose line limit would make a single
+	// oversized entry break the history of every document
+	history := []types.AuditEntry{}
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var entry types.AuditEntry
+			if json.Unmarshal(line, &entry) == nil && entry.DocumentID == documentID {
+				history = append(history, entry) // Corrupt lines are skipped
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit log: %w", err)
+		}
+	}
+
+	return history, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/logging"
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// rerankPrompt asks the model for a single relevance rating of a passage
+const rerankPrompt = `Rate how relevant the passage is for answering the question.
+Reply with a single number from 0 (unrelated) to 10 (answers the question directly) and nothing else.
+
+Question: %s
+
+Passage:
+%s
+
+Rating:`
+
+// rerankOptions keep ratings deterministic and short
+var rerankOptions = map[string]interface{}{
+	"temperature": 0.0,
+	"num_predict": 8,
+}
+
+var ratingPattern = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// rerankChunks asks the rerank model to rate each candidate for the query and returns at most
+// keep of them, best rated first. Candidates the model couldn't rate rank after the rated ones
+// in retrieval order, so a failing model leaves the retrieval ranking as it was. Rating stops when
+// ctx is cancelled.
+func (s *AIService) rerankChunks(ctx context.Context, query string, candidates []*types.DocumentChunk, keep int) []*types.DocumentChunk {
+	if keep <= 0 || keep > len(candidates) {
+		keep = len(candidates)
+	}
+
+	model := s.config.RerankModel
+	if model == "" {
+		model = s.currentModel
+	}
+	if model == "" || len(candidates) <= 1 {
+		return candidates[:keep]
+	}
+
+	ratings := make(map[*types.DocumentChunk]float64, len(candidates))
+	rated := 0
+	for _, chunk := range candidates {
+		if ctx.Err() != nil {
+			ratings[chunk] = -1 // Nobody is waiting for the remaining ratings
+			continue
+		}
+		rating, err := s.rateChunk(ctx, model, query, chunk.Content)
+		if err != nil {
+			log.Printf("⚠️ Could not rate chunk %d of %s: %v", chunk.ChunkIndex, chunk.DocumentID, err)
+			ratings[chunk] = -1
+			continue
+		}
+		ratings[chunk] = rating
+		rated++
+	}
+
+	ranked := make([]*types.DocumentChunk, len(candidates))
+	copy(ranked, candidates)
+	sort.SliceStable(ranked, func(i, j int) bool { return ratings[ranked[i]] > ratings[ranked[j]] })
+
+	log.Printf("🔀 Re-ranked %d chunks of document %s with %s, %d rated, keeping %d",
+		len(candidates), candidates[0].DocumentID, model, rated, keep)
+	return ranked[:keep]
+}
+
+// rateChunk returns the model's 0-10 relevance rating of a passage for the query
+func (s *AIService) rateChunk(ctx context.Context, model, query, passage string) (float64, error) {
+	reply, err := s.generateWithOllama(ctx, fmt.Sprintf(rerankPrompt, query, passage), model, rerankOptions)
+	if err != nil {
+		return 0, err
+	}
+
+	match := ratingPattern.FindString(reply)
+	if match == "" {
+		return 0, fmt.Errorf("no rating in reply %q", logging.Text(reply))
+	}
+	rating, err := strconv.ParseFloat(match, 64)
+	if err != nil {
+		return 0, err
+	}
+	return min(rating, 10), nil
+}
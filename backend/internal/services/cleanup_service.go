@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 
 	"github.com/1DeliDolu/ki-ai-go/internal/config"
+	"github.com/1DeliDolu/ki-ai-go/internal/logging"
 )
 
 type CleanupService struct {
@@ -65,11 +66,11 @@ func (s *CleanupService) cleanupUploads() error {
 
 		// Remove file or directory
 		if err := os.RemoveAll(path); err != nil {
-			log.Printf("⚠️  Failed to remove %s: %v", path, err)
+			log.Printf("⚠️  Failed to remove %s: %v", logging.File(path), err)
 			return nil // Continue with other files
 		}
 
-		log.Printf("🗑️  Removed: %s", path)
+		log.Printf("🗑️  Removed: %s", logging.File(path))
 		return nil
 	})
 
@@ -141,9 +142,9 @@ func (s *CleanupService) cleanupTempFiles() error {
 				filepath.HasPrefix(filename, "local-ai-") ||
 				filepath.HasPrefix(filename, "upload-") {
 				if err := os.Remove(path); err != nil {
-					log.Printf("⚠️  Failed to remove temp file %s: %v", path, err)
+					log.Printf("⚠️  Failed to remove temp file %s: %v", logging.File(path), err)
 				} else {
-					log.Printf("🗑️  Removed temp file: %s", path)
+					log.Printf("🗑️  Removed temp file: %s", logging.File(path))
 				}
 			}
 
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/config"
+)
+
+// ConcurrencyLimiter bounds the number of heavy operations (processing, search, generation)
+// running at the same time across the whole server
+type ConcurrencyLimiter struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+	waiting      int64
+	rejected     int64
+}
+
+func NewConcurrencyLimiter(cfg *config.Config) *ConcurrencyLimiter {
+	size := cfg.MaxConcurrentOperations
+	if size <= 0 {
+		size = 1
+	}
+
+	return &ConcurrencyLimiter{
+		slots:        make(chan struct{}, size),
+		queueTimeout: time.Duration(cfg.ConcurrencyQueueTimeout) * time.Second,
+	}
+}
+
+// Acquire reserves a slot, waiting up to the queue timeout. It returns false when
+// no slot became available; a zero timeout rejects immediately when the server is full.
+// Waiting also ends with false when ctx is cancelled, e.g. because the client went away.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if l.queueTimeout <= 0 {
+		atomic.AddInt64(&l.rejected, 1)
+		return false
+	}
+
+	atomic.AddInt64(&l.waiting, 1)
+	defer atomic.AddInt64(&l.waiting, -1)
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		atomic.AddInt64(&l.rejected, 1)
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Release frees a slot previously reserved with Acquire
+func (l *ConcurrencyLimiter) Release() {
+	<-l.slots
+}
+
+// Stats returns the current limiter state for health and metrics endpoints
+func (l *ConcurrencyLimiter) Stats() map[string]interface{} {
+	return map[string]interface{}{
+		"in_flight":      len(l.slots),
+		"max_concurrent": cap(l.slots),
+		"waiting":        atomic.LoadInt64(&l.waiting),
+		"rejected_total": atomic.LoadInt64(&l.rejected),
+	}
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/config"
+)
+
+func TestConcurrencyLimiterStopsWaitingWhenCancelled(t *testing.T) {
+	l := NewConcurrencyLimiter(&config.Config{MaxConcurrentOperations: 1, ConcurrencyQueueTimeout: 30})
+	if !l.Acquire(context.Background()) {
+		t.Fatal("first Acquire failed")
+	}
+	defer l.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if l.Acquire(ctx) {
+		t.Fatal("Acquire succeeded on a full limiter")
+	}
+	if waited := time.Since(start); waited > 5*time.Second {
+		t.Errorf("Acquire waited %v after its context was cancelled", waited)
+	}
+	if rejected := l.Stats()["rejected_total"]; rejected != int64(0) {
+		t.Errorf("rejected_total = %v, a cancelled wait isn't a rejection", rejected)
+	}
+}
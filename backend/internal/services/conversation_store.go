@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/config"
+	"github.com/1DeliDolu/ki-ai-go/internal/utils"
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// ErrInvalidConversationID is returned for conversation IDs that are too long
+var ErrInvalidConversationID = errors.New("conversation_id must be at most 128 characters")
+
+const maxConversationIDLength = 128
+
+// ConversationStore keeps the latest messages of each conversation in memory. Conversation IDs
+// are random UUIDs issued by Start rather than chosen by clients, and conversations are kept per
+// owner, so callers without a user, who all share the empty owner, can only continue conversations
+// whose ID they were given. When the store is full the conversation that was idle longest is dropped.
+type ConversationStore struct {
+	maxMessages      int
+	maxConversations int
+
+	mu            sync.Mutex
+	conversations map[conversationKey]*conversation
+}
+
+type conversationKey struct {
+	owner string
+	id    string
+}
+
+type conversation struct {
+	messages []types.ChatMessage
+	lastUsed time.Time
+}
+
+func NewConversationStore(cfg *config.Config) *ConversationStore {
+	return &ConversationStore{
+		maxMessages:      cfg.ConversationMaxMessages,
+		maxConversations: cfg.ConversationMaxCount,
+		conversations:    make(map[conversationKey]*conversation),
+	}
+}
+
+// ValidateConversationID checks an ID sent by a client
+func ValidateConversationID(id string) error {
+	if len(id) > maxConversationIDLength {
+		return ErrInvalidConversationID
+	}
+	return nil
+}
+
+// Start creates an empty conversation for owner and returns its ID, or "" when history is disabled
+func (s *ConversationStore) Start(owner string) string {
+	if s.maxMessages <= 0 {
+		return ""
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evictIdle()
+	id := utils.NewUUID()
+	s.conversations[conversationKey{owner, id}] = &conversation{lastUsed: time.Now()}
+	return id
+}
+
+// History returns a copy of the conversation's messages, oldest first, and whether owner has a
+// conversation with that ID
+func (s *ConversationStore) History(owner, id string) ([]types.ChatMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conv, ok := s.conversations[conversationKey{owner, id}]
+	if !ok {
+		return nil, false
+	}
+	conv.lastUsed = time.Now()
+	return append([]types.ChatMessage(nil), conv.messages...), true
+}
+
+// AppendTurn adds a question and its answer to a started conversation, dropping the oldest
+// messages beyond the limit. Turns of conversations that were dropped meanwhile are discarded.
+func (s *ConversationStore) AppendTurn(owner, id, query, response string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conv, ok := s.conversations[conversationKey{owner, id}]
+	if !ok {
+		return
+	}
+	conv.lastUsed = time.Now()
+	conv.messages = append(conv.messages,
+		types.ChatMessage{Role: types.ChatRoleUser, Content: query},
+		types.ChatMessage{Role: types.ChatRoleAssistant, Content: response},
+	)
+	if excess := len(conv.messages) - s.maxMessages; excess > 0 {
+		conv.messages = append([]types.ChatMessage(nil), conv.messages[excess:]...)
+	}
+}
+
+// Delete forgets a conversation and reports whether it existed
+func (s *ConversationStore) Delete(owner, id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := conversationKey{owner, id}
+	_, ok := s.conversations[key]
+	delete(s.conversations, key)
+	return ok
+}
+
+// evictIdle makes room for a new conversation. Callers must hold s.mu.
+func (s *ConversationStore) evictIdle() {
+	if s.maxConversations <= 0 || len(s.conversations) < s.maxConversations {
+		return
+	}
+
+	var oldestKey conversationKey
+	var oldest time.Time
+	for key, conv := range s.conversations {
+		if oldest.IsZero() || conv.lastUsed.Before(oldest) {
+			oldestKey, oldest = key, conv.lastUsed
+		}
+	}
+	delete(s.conversations, oldestKey)
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/utils"
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// uploadVisibilityFixtures uploads one document of alice per visibility, named after it, each
+// containing the word zephyr
+func uploadVisibilityFixtures(t *testing.T, s *DocumentService) map[string]*types.Document {
+	t.Helper()
+
+	docs := make(map[string]*types.Document)
+	for _, visibility := range []string{types.VisibilityPrivate, types.VisibilityShared, types.VisibilityPublic} {
+		docs[visibility] = uploadTestFile(t, s, visibility+".txt", "zephyr notes for "+visibility, "alice", visibility)
+	}
+	return docs
+}
+
+// visibilityCases lists who sees which of the fixtures
+var visibilityCases = []struct {
+	name    string
+	access  types.AccessContext
+	visible []string
+}{
+	{"anonymous", types.AccessContext{}, []string{types.VisibilityPublic}},
+	{"owner", types.AccessContext{User: "alice"}, []string{types.VisibilityPrivate, types.VisibilityPublic, types.VisibilityShared}},
+	{"other user", types.AccessContext{User: "bob"}, []string{types.VisibilityPublic, types.VisibilityShared}},
+	{"admin", types.AccessContext{User: "admin", IsAdmin: true}, []string{types.VisibilityPrivate, types.VisibilityPublic, types.VisibilityShared}},
+}
+
+// visibilitiesOf maps documents back to the visibility they were uploaded with, sorted
+func visibilitiesOf(fixtures map[string]*types.Document, match func(doc *types.Document) bool) []string {
+	var visibilities []string
+	for visibility, doc := range fixtures {
+		if match(doc) {
+			visibilities = append(visibilities, visibility)
+		}
+	}
+	sort.Strings(visibilities)
+	return visibilities
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDocumentVisibility(t *testing.T) {
+	s := newTestDocumentService(t)
+	fixtures := uploadVisibilityFixtures(t, s)
+
+	for _, tt := range visibilityCases {
+		t.Run(tt.name, func(t *testing.T) {
+			all, err := s.ListDocuments()
+			if err != nil {
+				t.Fatalf("ListDocuments: %v", err)
+			}
+			listed := make(map[string]bool)
+			for _, doc := range s.FilterAccessible(all, tt.access) {
+				listed[doc.ID] = true
+			}
+			if got := visibilitiesOf(fixtures, func(doc *types.Document) bool { return listed[doc.ID] }); !equalStrings(got, tt.visible) {
+				t.Errorf("listing shows %v, want %v", got, tt.visible)
+			}
+
+			got := visibilitiesOf(fixtures, func(doc *types.Document) bool {
+				_, err := s.GetDocumentsByID([]string{doc.ID}, tt.access)
+				return err == nil
+			})
+			if !equalStrings(got, tt.visible) {
+				t.Errorf("GetDocumentsByID finds %v, want %v", got, tt.visible)
+			}
+
+			results, err := s.AdvancedSearch(context.Background(), "zephyr", utils.SearchOptions{}, tt.access)
+			if err != nil {
+				t.Fatalf("AdvancedSearch: %v", err)
+			}
+			got = visibilitiesOf(fixtures, func(doc *types.Document) bool { return results[doc.Path] != nil })
+			if !equalStrings(got, tt.visible) {
+				t.Errorf("search finds %v, want %v", got, tt.visible)
+			}
+		})
+	}
+}
+
+func TestSuggestTermsVisibility(t *testing.T) {
+	s := newTestDocumentService(t)
+	fixtures := uploadVisibilityFixtures(t, s)
+	for _, doc := range fixtures {
+		if _, err := s.GetDocumentContent(doc.ID); err != nil {
+			t.Fatalf("GetDocumentContent: %v", err)
+		}
+	}
+
+	for _, tt := range visibilityCases {
+		t.Run(tt.name, func(t *testing.T) {
+			suggestions := s.SuggestTerms("zephyr", 10, tt.access)
+			if len(suggestions) != 1 {
+				t.Fatalf("suggestions = %+v, want only zephyr", suggestions)
+			}
+			if got := suggestions[0].Documents; got != len(tt.visible) {
+				t.Errorf("zephyr counted in %d documents, want %d", got, len(tt.visible))
+			}
+
+			// Each fixture's title word is only suggested to those who can see it
+			got := visibilitiesOf(fixtures, func(doc *types.Document) bool {
+				return hasSuggestion(s.SuggestTerms(doc.Visibility, 10, tt.access), doc.Visibility)
+			})
+			if !equalStrings(got, tt.visible) {
+				t.Errorf("title suggestions for %v, want %v", got, tt.visible)
+			}
+		})
+	}
+}
+
+func TestCancelJobAuthorization(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility string
+		submitter  string
+		canceller  types.AccessContext
+		want       error // nil when cancelling is allowed
+	}{
+		{"submitter", types.VisibilityShared, "bob", types.AccessContext{User: "bob"}, nil},
+		{"document owner", types.VisibilityShared, "bob", types.AccessContext{User: "alice"}, nil},
+		{"admin", types.VisibilityShared, "bob", types.AccessContext{User: "admin", IsAdmin: true}, nil},
+		{"other reader", types.VisibilityShared, "bob", types.AccessContext{User: "carol"}, ErrJobForbidden},
+		{"anonymous reader of anonymous job", types.VisibilityPublic, "", types.AccessContext{}, ErrJobForbidden},
+		{"user who can't see the document", types.VisibilityPrivate, "alice", types.AccessContext{User: "carol"}, ErrJobNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestDocumentService(t)
+			doc := uploadTestFile(t, s, "report.txt", "quarterly numbers", "alice", tt.visibility)
+
+			job, err := s.SubmitJob(JobTypeProcess, doc.ID, "", tt.submitter)
+			if err != nil {
+				t.Fatalf("SubmitJob: %v", err)
+			}
+			if job.SubmittedBy != tt.submitter {
+				t.Errorf("SubmittedBy = %q, want %q", job.SubmittedBy, tt.submitter)
+			}
+
+			_, err = s.CancelJob(job.ID, tt.canceller)
+			if tt.want == nil {
+				// The job may already be done, which is only reported after the access checks
+				if err != nil && !errors.Is(err, ErrJobFinished) {
+					t.Errorf("CancelJob error = %v, want it allowed", err)
+				}
+			} else if !errors.Is(err, tt.want) {
+				t.Errorf("CancelJob error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/processors"
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// Document job types
+const (
+	JobTypeProcess        = "process"         // Extract the document, with OCR where configured
+	JobTypeReprocessPages = "reprocess_pages" // Extract selected PDF pages again
+	JobTypeEmbed          = "embed"           // Chunk and embed the document, keeping its file
+)
+
+// ErrInvalidJob is returned for job requests that can't be run
+var ErrInvalidJob = errors.New("invalid job")
+
+// ErrJobForbidden is returned when the requester can see a job but isn't allowed to cancel it
+var ErrJobForbidden = errors.New("only the submitter of a job or the owner of its document can cancel it")
+
+// SubmitJob queues a processing job for a document on behalf of submitter. pages is required for
+// reprocess_pages jobs.
+func (s *DocumentService) SubmitJob(jobType, documentID, pages, submitter string) (Job, error) {
+	if _, err := s.store.GetDocument(documentID); err != nil {
+		return Job{}, fmt.Errorf("document not found: %w", err)
+	}
+
+	var run JobFunc
+	switch jobType {
+	case JobTypeProcess:
+		run = func(ctx context.Context) (interface{}, error) {
+			content, err := s.GetDocumentContentContext(ctx, documentID)
+			if err != nil {
+				return nil, err
+			}
+			return contentSummary(content), nil
+		}
+	case JobTypeReprocessPages:
+		pageRange, err := processors.ParsePageRange(pages)
+		if err != nil {
+			return Job{}, fmt.Errorf("%w: %v", ErrInvalidJob, err)
+		}
+		run = func(ctx context.Context) (interface{}, error) {
+			content, err := s.ReprocessPages(ctx, documentID, pageRange)
+			if err != nil {
+				return nil, err
+			}
+			summary := contentSummary(content)
+			summary["pages"] = pageRange.String()
+			return summary, nil
+		}
+	case JobTypeEmbed:
+		run = func(ctx context.Context) (interface{}, error) {
+			chunks, err := s.EmbedDocument(ctx, documentID)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"chunks": chunks, "embedding_model": s.config.EmbeddingModel}, nil
+		}
+	default:
+		return Job{}, fmt.Errorf("%w: unknown type %q, use %s, %s or %s", ErrInvalidJob, jobType,
+			JobTypeProcess, JobTypeReprocessPages, JobTypeEmbed)
+	}
+
+	return s.jobs.Submit(jobType, documentID, submitter, run)
+}
+
+// GetJob returns the state of a job. Jobs of documents the requester can't access are reported
+// as not found.
+func (s *DocumentService) GetJob(jobID string, access types.AccessContext) (Job, error) {
+	job, _, err := s.accessibleJob(jobID, access)
+	return job, err
+}
+
+// CancelJob cancels a queued or running job. Only the user who submitted it, the owner of its
+// document or an admin may cancel it, other users who can see the document get ErrJobForbidden.
+func (s *DocumentService) CancelJob(jobID string, access types.AccessContext) (Job, error) {
+	job, doc, err := s.accessibleJob(jobID, access)
+	if err != nil {
+		return Job{}, err
+	}
+	submitter := access.User != "" && job.SubmittedBy == access.User
+	if !submitter && !access.CanModify(doc) {
+		return Job{}, ErrJobForbidden
+	}
+	return s.jobs.Cancel(jobID)
+}
+
+// accessibleJob returns a job and its document, or ErrJobNotFound when the job doesn't exist or
+// the requester can't access its document
+func (s *DocumentService) accessibleJob(jobID string, access types.AccessContext) (Job, *types.Document, error) {
+	job, err := s.jobs.Get(jobID)
+	if err != nil {
+		return Job{}, nil, err
+	}
+	docs, err := s.GetDocumentsByID([]string{job.DocumentID}, access)
+	if err != nil {
+		return Job{}, nil, ErrJobNotFound
+	}
+	return job, &docs[0], nil
+}
+
+// JobStats counts the known jobs by status
+func (s *DocumentService) JobStats() map[string]int {
+	return s.jobs.Stats()
+}
+
+// contentSummary describes extracted content without its text, which can be fetched separately
+func contentSummary(content *types.DocumentContent) map[string]interface{} {
+	return map[string]interface{}{
+		"type":               content.Type,
+		"extraction_method":  content.ExtractionMethod,
+		"extraction_quality": content.ExtractionQuality,
+		"text_length":        len(content.Text),
+		"metadata":           content.Metadata,
+	}
+}
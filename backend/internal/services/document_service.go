@@ -1,34 +1,120 @@
 package services
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"maps"
 	"mime/multipart"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/1DeliDolu/ki-ai-go/internal/config"
+	"github.com/1DeliDolu/ki-ai-go/internal/logging"
 	"github.com/1DeliDolu/ki-ai-go/internal/processors"
 	"github.com/1DeliDolu/ki-ai-go/internal/storage"
 	"github.com/1DeliDolu/ki-ai-go/internal/utils"
 	"github.com/1DeliDolu/ki-ai-go/pkg/types"
 )
 
+// Size of the preview included in document summaries
+const (
+	summaryPreviewLines = 10
+	summaryPreviewChars = 500
+)
+
+// Page sizes for QueryDocuments
+const (
+	defaultQueryLimit = 50
+	maxQueryLimit     = 1000
+)
+
+// Weights and limits of search suggestion terms
+const (
+	suggestTitleWeight      = 20
+	suggestTagWeight        = 10
+	suggestMaxContentWeight = 10 // Cap so one long document can't dominate suggestions
+	suggestContentTerms     = 50 // Most frequent words indexed per document
+)
+
+// UserMetadataPrefix namespaces metadata supplied by users at upload time
+const UserMetadataPrefix = "user."
+
+const (
+	maxUserMetadataFields      = 32
+	maxUserMetadataValueLength = 1024
+)
+
+var userMetadataKeyPattern = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// externalIDPattern leaves out '/', which can't be used in a path segment such as /documents/:id
+var externalIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// allDigitsPattern matches internal document IDs, which external IDs must not look like
+var allDigitsPattern = regexp.MustCompile(`^[0-9]+$`)
+
+// ErrInvalidExternalID is returned for external IDs with unsupported characters or length, or
+// ones that could be mistaken for an internal ID
+var ErrInvalidExternalID = errors.New("external_id must be 1 to 128 letters, digits or . _ : - and not only digits")
+
+// ErrStorageLimitReached is returned when an upload would exceed the configured storage limits
+var ErrStorageLimitReached = errors.New("storage limit reached")
+
+// ErrAlreadyEmbedded is returned by EmbedDocument for documents that already have embedded chunks
+var ErrAlreadyEmbedded = errors.New("document already has embedded chunks")
+
+// Errors returned by ReprocessPages and SplitDocument
+var (
+	ErrPagesNotSupported = errors.New("page ranges are only supported for PDF documents")
+	ErrSourceRemoved     = errors.New("the source file was removed after embeddings-only ingestion, upload it again to reprocess")
+)
+
 type DocumentService struct {
-	memDB           *storage.MemoryDB
+	store           storage.DocumentStore // Document records and chunks
+	memDB           *storage.MemoryDB     // Search suggestions, always kept in the process
 	config          *config.Config
 	documentManager *processors.DocumentManager
+	uploadMu        sync.Mutex // Serializes picking a free filename and claiming it
+	reprocessQueue  chan string
+	scanner         Scanner
+	notifier        *WebhookNotifier
+	callbacksMu     sync.Mutex
+	callbacks       map[string]string // Callback URLs by document ID, each posted once
+	ollama          *OllamaService
+	jobs            *JobManager
 }
 
 func NewDocumentService(db interface{}, cfg *config.Config) *DocumentService {
-	// Convert to memory DB
-	memDB, ok := db.(*storage.MemoryDB)
-	if !ok {
+	logging.SetDocumentContent(cfg.LogDocumentContent)
+
+	var store storage.DocumentStore
+	var memDB *storage.MemoryDB
+	switch db := db.(type) {
+	case *storage.MemoryDB:
+		store, memDB = db, db
+	case *sql.DB:
+		log.Println("🐘 Storing documents in PostgreSQL")
+		store, memDB = storage.NewPostgresDB(db), storage.NewMemoryDB()
+	case storage.DocumentStore:
+		store, memDB = db, storage.NewMemoryDB()
+	default:
 		log.Println("⚠️  Warning: Using memory database fallback")
-		memDB = storage.InitMemoryDB()
+		memDB = storage.InitMemoryDB(cfg.MemoryDBSnapshotPath, time.Duration(cfg.MemoryDBSnapshotInterval)*time.Second)
+		store = memDB
 	}
 
 	// Ensure both directories exist
@@ -41,237 +127,1883 @@ func NewDocumentService(db interface{}, cfg *config.Config) *DocumentService {
 		log.Printf("Warning: Failed to create test_documents directory: %v", err)
 	}
 
-	return &DocumentService{
+	documentManager := processors.NewDocumentManager()
+	documentManager.SetMaxFileSize(cfg.MaxFileSize)
+	documentManager.SetMaxTextBytes(cfg.MaxTextBytes)
+	documentManager.SetArchiveLimits(cfg.ArchiveMaxEntries, cfg.ArchiveMaxBytes)
+	documentManager.SetCSVDelimiter(processors.ParseCSVDelimiter(cfg.CSVDelimiter))
+	documentManager.SetPDFStrictMode(cfg.PDFStrictMode)
+	documentManager.SetPDFOCR(cfg.EnableOCR, cfg.OCRLanguage)
+	for fileType, extractors := range map[string][]string{"pdf": cfg.PDFExtractors, "docx": cfg.DOCXExtractors} {
+		if len(extractors) == 0 {
+			continue
+		}
+		if err := documentManager.SetExtractorChain(fileType, extractors); err != nil {
+			log.Printf("Warning: Ignoring extractor chain for %s: %v", fileType, err)
+		}
+	}
+	if err := documentManager.SetBasicExtraction(cfg.BasicExtractionTypes); err != nil {
+		log.Printf("Warning: Ignoring basic extraction types: %v", err)
+	}
+	documentManager.SetWorkers(cfg.ProcessingWorkers)
+	if cfg.ContentCacheMaxEntries > 0 && cfg.ContentCacheMaxBytes > 0 {
+		documentManager.SetContentCache(processors.NewContentCache(cfg.ContentCacheMaxEntries,
+			cfg.ContentCacheMaxBytes, time.Duration(cfg.ContentCacheTTL)*time.Second))
+	}
+	if cfg.ProcessingStatsPath != "" {
+		documentManager.EnableStatsPersistence(cfg.ProcessingStatsPath,
+			time.Duration(cfg.ProcessingStatsSaveInterval)*time.Second)
+	}
+
+	s := &DocumentService{
+		store:           store,
 		memDB:           memDB,
 		config:          cfg,
-		documentManager: processors.NewDocumentManager(),
+		documentManager: documentManager,
+		reprocessQueue:  make(chan string, 100),
+		scanner:         NewScanner(cfg),
+		notifier:        NewWebhookNotifier(cfg),
+		callbacks:       make(map[string]string),
+		ollama:          NewOllamaService(cfg),
+		jobs:            NewJobManager(cfg),
+	}
+
+	if !utils.ValidChunkStrategy(cfg.ChunkStrategy) {
+		log.Printf("Warning: Unknown chunk strategy %q, using fixed-size chunks", cfg.ChunkStrategy)
+	}
+
+	// Misconfigured volumes should show up now rather than on the first upload or download
+	for _, check := range s.CheckStorage() {
+		if !check.Writable {
+			log.Printf("❌ Storage directory %s (%s) is unusable: %s", check.Name, check.Path, check.Error)
+		}
+	}
+
+	go s.reprocessWorker()
+
+	// Suggestions aren't persisted, restored documents get their titles and tags back right away
+	if docs, err := store.ListDocuments(); err == nil {
+		for _, doc := range docs {
+			s.indexSuggestions(doc, nil)
+		}
+	}
+
+	// Pick up documents extracted by older processor versions
+	if queued, err := s.QueueStaleDocuments(); err != nil {
+		log.Printf("Warning: Failed to check processor versions: %v", err)
+	} else if queued > 0 {
+		log.Printf("🔁 Queued %d documents for reprocessing after processor updates", queued)
 	}
+
+	return s
 }
 
-// ConvertDocument converts a document to specified format
-func (s *DocumentService) ConvertDocument(documentID, format, outputPath string) error {
-	doc, err := s.memDB.GetDocument(documentID)
+// ConvertDocument converts a document to specified format, written in outputEncoding (UTF-8 when empty)
+func (s *DocumentService) ConvertDocument(documentID, format, outputPath, outputEncoding string) error {
+	doc, err := s.store.GetDocument(documentID)
 	if err != nil {
 		return fmt.Errorf("document not found: %w", err)
 	}
 
 	converter := utils.NewDocumentConverter()
 
-	switch strings.ToLower(format) {
-	case "markdown", "md":
-		return converter.ConvertToMarkdown(doc.Path, outputPath)
-	case "html":
-		return converter.ConvertToHTML(doc.Path, outputPath)
-	case "txt", "text":
-		return converter.ConvertToPlainText(doc.Path, outputPath)
-	default:
-		return fmt.Errorf("unsupported format: %s", format)
+	switch strings.ToLower(format) {
+	case "markdown", "md":
+		return converter.ConvertToMarkdown(doc.Path, outputPath, outputEncoding)
+	case "html":
+		return converter.ConvertToHTML(doc.Path, outputPath, outputEncoding)
+	case "txt", "text":
+		return converter.ConvertToPlainText(doc.Path, outputPath, outputEncoding)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// SearchInDocumentContent searches within a specific document
+func (s *DocumentService) SearchInDocumentContent(documentID, query string) ([]string, error) {
+	doc, err := s.store.GetDocument(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("document not found: %w", err)
+	}
+
+	return s.documentManager.SearchInDocument(doc.Path, query)
+}
+
+// AdvancedSearch performs advanced search with options over the documents the requester can access.
+// The search stops when ctx is cancelled or the configured search timeout passes; the results found
+// until then are returned together with the context's error.
+func (s *DocumentService) AdvancedSearch(ctx context.Context, query string, options utils.SearchOptions, access types.AccessContext) (map[string]*utils.SearchResult, error) {
+	paths, documentMetadata, err := s.searchablePaths(access)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.config.SearchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.SearchTimeout)*time.Second)
+		defer cancel()
+	}
+
+	// Perform search
+	searcher := utils.NewDocumentSearcher()
+	if options.IncludeMetadata {
+		return searcher.SearchWithDocumentMetadataContext(ctx, paths, documentMetadata, query, options)
+	}
+	return searcher.SearchInMultipleDocumentsContext(ctx, paths, query, options)
+}
+
+// SearchStream is a running streamed search
+type SearchStream struct {
+	Results  <-chan *utils.SearchResult // Closed when the search is finished or stopped
+	Searched int                        // Documents being searched
+	err      error
+}
+
+// Err returns why the search stopped early, the context's error when it was cancelled or timed
+// out. It is only valid once Results is closed.
+func (s *SearchStream) Err() error {
+	return s.err
+}
+
+// StreamAdvancedSearch searches like AdvancedSearch, including its timeout, sending each
+// document's result as soon as it is found
+func (s *DocumentService) StreamAdvancedSearch(ctx context.Context, query string, options utils.SearchOptions, access types.AccessContext) (*SearchStream, error) {
+	paths, documentMetadata, err := s.searchablePaths(access)
+	if err != nil {
+		return nil, err
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if s.config.SearchTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.SearchTimeout)*time.Second)
+	}
+
+	searcher := utils.NewDocumentSearcher()
+	found := searcher.StreamSearch(ctx, paths, documentMetadata, query, options, s.config.ProcessingWorkers)
+
+	results := make(chan *utils.SearchResult)
+	stream := &SearchStream{Results: results, Searched: len(paths)}
+	go func() {
+		defer close(results)
+		defer cancel()
+		for result := range found {
+			select {
+			case results <- result:
+			case <-ctx.Done():
+			}
+		}
+		// Recorded before cancel, which would report every finished search as cancelled
+		stream.err = ctx.Err()
+	}()
+	return stream, nil
+}
+
+// searchablePaths returns the files of the documents the requester can access, with their stored
+// metadata keyed by path
+func (s *DocumentService) searchablePaths(access types.AccessContext) ([]string, map[string]map[string]string, error) {
+	docs, err := s.store.ListDocuments()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get documents: %w", err)
+	}
+
+	var paths []string
+	documentMetadata := make(map[string]map[string]string)
+	for _, doc := range docs {
+		if doc.Path != "" && access.CanAccess(doc) {
+			paths = append(paths, doc.Path)
+			documentMetadata[doc.Path] = doc.Metadata
+		}
+	}
+	return paths, documentMetadata, nil
+}
+
+// BasicExtractionContext returns a context forcing basic extraction for the given types in this request
+func (s *DocumentService) BasicExtractionContext(ctx context.Context, fileTypes []string) (context.Context, error) {
+	if err := s.documentManager.ValidateBasicExtraction(fileTypes); err != nil {
+		return nil, err
+	}
+	return processors.WithBasicExtraction(ctx, fileTypes), nil
+}
+
+// GetDocumentPreview returns a preview of document content
+func (s *DocumentService) GetDocumentPreview(documentID string, maxLines int) (string, error) {
+	doc, err := s.store.GetDocument(documentID)
+	if err != nil {
+		return "", fmt.Errorf("document not found: %w", err)
+	}
+	if doc.Path == "" {
+		return "", ErrSourceRemoved
+	}
+
+	return s.documentManager.GetDocumentPreview(doc.Path, maxLines)
+}
+
+// GetHighlightedPreview returns a document preview with matches of query wrapped in highlight markers.
+// Empty markers fall back to the configured defaults.
+func (s *DocumentService) GetHighlightedPreview(documentID string, maxLines int, query string, options utils.SearchOptions, pre, post string) (string, int, error) {
+	preview, err := s.GetDocumentPreview(documentID, maxLines)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if pre == "" {
+		pre = s.config.HighlightPreTag
+	}
+	if post == "" {
+		post = s.config.HighlightPostTag
+	}
+
+	searcher := utils.NewDocumentSearcher()
+	highlighted := searcher.HighlightMatchesWithMarkers(preview, query, options, pre, post)
+	matchCount := strings.Count(highlighted, pre) - strings.Count(preview, pre)
+
+	return highlighted, matchCount, nil
+}
+
+func (s *DocumentService) ListDocuments() ([]types.Document, error) {
+	log.Println("Listing documents from memory database")
+
+	docs, err := s.store.ListDocuments()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	// Convert pointers to values
+	result := make([]types.Document, len(docs))
+	for i, doc := range docs {
+		result[i] = *doc
+	}
+
+	log.Printf("Found %d documents", len(result))
+	return result, nil
+}
+
+// IsAdmin reports whether the user is configured as an administrator
+func (s *DocumentService) IsAdmin(user string) bool {
+	if user == "" {
+		return false
+	}
+	for _, admin := range s.config.AdminUsers {
+		if admin == user {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterAccessible drops documents the requester is not allowed to see
+func (s *DocumentService) FilterAccessible(docs []types.Document, access types.AccessContext) []types.Document {
+	visible := make([]types.Document, 0, len(docs))
+	for i := range docs {
+		if access.CanAccess(&docs[i]) {
+			visible = append(visible, docs[i])
+		}
+	}
+	return visible
+}
+
+// GetDocumentsByID returns the requested documents in request order. Documents the requester
+// can't access are reported as not found.
+func (s *DocumentService) GetDocumentsByID(documentIDs []string, access types.AccessContext) ([]types.Document, error) {
+	documents := make([]types.Document, 0, len(documentIDs))
+	seen := make(map[string]bool, len(documentIDs))
+	for _, id := range documentIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		doc, err := s.store.GetDocument(id)
+		if err != nil || !access.CanAccess(doc) {
+			return nil, fmt.Errorf("document %s not found", id)
+		}
+		documents = append(documents, *doc)
+	}
+	return documents, nil
+}
+
+// GetDocumentContent extracts content from a document with enhanced error handling
+func (s *DocumentService) GetDocumentContent(documentID string) (*types.DocumentContent, error) {
+	return s.GetDocumentContentContext(context.Background(), documentID)
+}
+
+// GetDocumentContentContext extracts content from a document, stopping when ctx is cancelled
+func (s *DocumentService) GetDocumentContentContext(ctx context.Context, documentID string) (*types.DocumentContent, error) {
+	doc, err := s.store.GetDocument(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("document not found: %w", err)
+	}
+
+	if doc.Path == "" {
+		if doc.Embeddings {
+			return s.contentFromChunks(doc)
+		}
+		return nil, ErrSourceRemoved
+	}
+
+	// Validate file before processing
+	if err := s.documentManager.ValidateFile(doc.Path); err != nil {
+		return nil, fmt.Errorf("file validation failed: %w", err)
+	}
+
+	content, err := s.documentManager.ProcessDocumentContext(ctx, doc.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process document: %w", err)
+	}
+
+	s.recordProcessorVersion(doc.ID, content)
+	text := content.RetrievalText()
+	s.indexSuggestions(doc, &text)
+	return content, nil
+}
+
+// indexSuggestions replaces a document's autocomplete terms with its title, title words, tags
+// and the most frequent words of its extracted text. Without text, the content terms of an
+// earlier extraction are kept.
+func (s *DocumentService) indexSuggestions(doc *types.Document, text *string) {
+	var content map[string]int
+	if text != nil {
+		content = make(map[string]int)
+		for word, count := range utils.TopTerms(*text, suggestContentTerms) {
+			content[word] = min(count, suggestMaxContentWeight)
+		}
+	}
+
+	terms := make(map[string]int)
+
+	title := strings.TrimSuffix(doc.Name, filepath.Ext(doc.Name))
+	titleWords := utils.TopTerms(title, suggestContentTerms)
+	for word := range titleWords {
+		terms[word] += suggestTitleWeight
+	}
+	if len(titleWords) > 1 {
+		terms[title] += suggestTitleWeight
+	}
+
+	for _, tag := range strings.Split(doc.Metadata[UserMetadataPrefix+"tags"], ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			terms[tag] += suggestTagWeight
+		}
+	}
+
+	if _, err := s.store.GetDocument(doc.ID); err != nil {
+		log.Printf("Warning: Failed to index suggestions for %s: %v", logging.Document(doc.Name, doc.ID), err)
+		return
+	}
+	s.memDB.IndexSuggestions(doc.ID, content, terms)
+}
+
+// SuggestTerms returns autocomplete suggestions for a search prefix, drawn from the documents
+// the requester can access
+func (s *DocumentService) SuggestTerms(prefix string, limit int, access types.AccessContext) []types.Suggestion {
+	docs, err := s.store.ListDocuments()
+	if err != nil {
+		log.Printf("Warning: Failed to list documents for suggestions: %v", err)
+		return []types.Suggestion{}
+	}
+
+	visible := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		if access.CanAccess(doc) {
+			visible[doc.ID] = true
+		}
+	}
+	return s.memDB.Suggest(strings.TrimSpace(prefix), func(id string) bool { return visible[id] }, limit)
+}
+
+// IngestEmbeddingsOnly chunks and embeds an uploaded document, then deletes the source file so
+// only the chunks are retained. On failure the document is removed entirely.
+func (s *DocumentService) IngestEmbeddingsOnly(documentID string) error {
+	content, err := s.GetDocumentContent(documentID)
+	if err != nil {
+		s.discardDocument(documentID)
+		return fmt.Errorf("failed to extract content: %w", err)
+	}
+
+	doc, err := s.store.GetDocument(documentID)
+	if err != nil {
+		return fmt.Errorf("document not found: %w", err)
+	}
+	strategy := s.chunkStrategyFor(doc)
+
+	chunks := s.chunkContent(documentID, content, strategy)
+	if len(chunks) == 0 {
+		s.discardDocument(documentID)
+		return fmt.Errorf("document has no text to embed")
+	}
+
+	if err := s.embedChunks(context.Background(), chunks); err != nil {
+		s.discardDocument(documentID)
+		return err
+	}
+
+	for _, chunk := range chunks {
+		if err := s.store.CreateChunk(chunk); err != nil {
+			s.discardDocument(documentID)
+			return fmt.Errorf("failed to store chunk: %w", err)
+		}
+	}
+
+	if doc, err = s.store.GetDocument(documentID); err != nil {
+		return fmt.Errorf("document not found: %w", err)
+	}
+	sourcePath := doc.Path
+
+	doc.Chunks = len(chunks)
+	doc.Embeddings = true
+	doc.Path = ""
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]string)
+	}
+	doc.Metadata["ingestion_mode"] = "embeddings_only"
+	doc.Metadata["chunk_strategy"] = strategy
+	doc.Metadata["chunk_overlap"] = strconv.Itoa(utils.ChunkOverlapFor(strategy, s.config.ChunkOverlap))
+	doc.Metadata["embedding_model"] = s.config.EmbeddingModel
+	if err := s.store.UpdateDocument(doc); err != nil {
+		s.discardDocument(documentID)
+		return fmt.Errorf("failed to update document: %w", err)
+	}
+
+	if err := os.Remove(sourcePath); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: failed to delete source file %s: %v", logging.File(sourcePath), err)
+	}
+	if err := os.Remove(sidecarPath(sourcePath)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: failed to delete sidecar for %s: %v", logging.File(sourcePath), err)
+	}
+
+	log.Printf("✅ Stored %d embedded chunks for %s and removed the source file", len(chunks), logging.Document(doc.Name, doc.ID))
+	return nil
+}
+
+// EmbedDocument chunks and embeds a document while keeping its source file, and returns the number
+// of chunks stored. Nothing is stored when ctx is cancelled before all chunks are embedded.
+func (s *DocumentService) EmbedDocument(ctx context.Context, documentID string) (int, error) {
+	doc, err := s.store.GetDocument(documentID)
+	if err != nil {
+		return 0, fmt.Errorf("document not found: %w", err)
+	}
+	if doc.Embeddings {
+		return 0, ErrAlreadyEmbedded
+	}
+
+	content, err := s.GetDocumentContentContext(ctx, documentID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to extract content: %w", err)
+	}
+
+	strategy := s.chunkStrategyFor(doc)
+	chunks := s.chunkContent(documentID, content, strategy)
+	if len(chunks) == 0 {
+		return 0, fmt.Errorf("document has no text to embed")
+	}
+	if err := s.embedChunks(ctx, chunks); err != nil {
+		return 0, err
+	}
+
+	for _, chunk := range chunks {
+		if err := s.store.CreateChunk(chunk); err != nil {
+			return 0, fmt.Errorf("failed to store chunk: %w", err)
+		}
+	}
+
+	err = s.store.UpdateDocumentMetadata(documentID, map[string]string{
+		"chunk_strategy":  strategy,
+		"chunk_overlap":   strconv.Itoa(utils.ChunkOverlapFor(strategy, s.config.ChunkOverlap)),
+		"embedding_model": s.config.EmbeddingModel,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to update document: %w", err)
+	}
+	if doc, err = s.store.GetDocument(documentID); err != nil {
+		return 0, fmt.Errorf("document not found: %w", err)
+	}
+	doc.Chunks = len(chunks)
+	doc.Embeddings = true
+	if err := s.store.UpdateDocument(doc); err != nil {
+		return 0, fmt.Errorf("failed to update document: %w", err)
+	}
+
+	log.Printf("✅ Stored %d embedded chunks for %s", len(chunks), logging.Document(doc.Name, doc.ID))
+	return len(chunks), nil
+}
+
+// embedChunks sets the embedding of every chunk, stopping when ctx is cancelled
+func (s *DocumentService) embedChunks(ctx context.Context, chunks []*types.DocumentChunk) error {
+	for i, chunk := range chunks {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("embedding cancelled: %w", err)
+		}
+		embedding, err := s.ollama.Embed(s.config.EmbeddingModel, chunk.Content)
+		if err != nil {
+			return fmt.Errorf("failed to embed chunk %d: %w", i, err)
+		}
+		chunk.Embedding = embedding
+	}
+	return nil
+}
+
+// ErrInvalidChunkStrategy is returned for unknown chunking strategies
+var ErrInvalidChunkStrategy = errors.New("chunk strategy must be fixed, sentence or paragraph")
+
+// SetChunkStrategy sets how a document is chunked for embedding and retrieval, overriding the
+// configured strategy
+func (s *DocumentService) SetChunkStrategy(documentID, strategy string) error {
+	if !utils.ValidChunkStrategy(strategy) {
+		return ErrInvalidChunkStrategy
+	}
+	return s.store.UpdateDocumentMetadata(documentID, map[string]string{"chunk_strategy": strategy})
+}
+
+// chunkStrategyFor returns the strategy set for a document, or the configured one
+func (s *DocumentService) chunkStrategyFor(doc *types.Document) string {
+	if strategy := doc.Metadata["chunk_strategy"]; utils.ValidChunkStrategy(strategy) {
+		return strategy
+	}
+	if utils.ValidChunkStrategy(s.config.ChunkStrategy) {
+		return s.config.ChunkStrategy
+	}
+	return utils.ChunkStrategyFixed
+}
+
+// chunkContent splits extracted content into chunks with the given strategy. PDFs are chunked page
+// by page so every chunk records the page it came from.
+func (s *DocumentService) chunkContent(documentID string, content *types.DocumentContent, strategy string) []*types.DocumentChunk {
+	pages := []processors.PDFPage{{Text: content.RetrievalText()}}
+	if content.Type == "pdf" {
+		pages = processors.SplitPDFPages(content.RetrievalText())
+	}
+
+	var chunks []*types.DocumentChunk
+	for _, page := range pages {
+		for _, text := range utils.ChunkTextWithStrategy(page.Text, strategy, s.config.ChunkSize, s.config.ChunkOverlap) {
+			chunks = append(chunks, &types.DocumentChunk{
+				ID:         fmt.Sprintf("%s_chunk_%d", documentID, len(chunks)),
+				DocumentID: documentID,
+				Content:    text,
+				ChunkIndex: len(chunks),
+				Page:       page.Number,
+				CreatedAt:  time.Now().Format(time.RFC3339),
+			})
+		}
+	}
+	return chunks
+}
+
+// discardDocument removes a document whose embeddings-only ingestion failed
+func (s *DocumentService) discardDocument(documentID string) {
+	if err := s.DeleteDocument(documentID); err != nil {
+		log.Printf("Warning: failed to discard document %s: %v", documentID, err)
+	}
+}
+
+// sortedChunks returns a document's chunks in their original order
+func (s *DocumentService) sortedChunks(documentID string) ([]*types.DocumentChunk, error) {
+	chunks, err := s.store.GetChunks(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chunks: %w", err)
+	}
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("document has no stored chunks")
+	}
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].ChunkIndex < chunks[j].ChunkIndex })
+	return chunks, nil
+}
+
+// contentFromChunks rebuilds the text of an embeddings-only document from its chunks
+func (s *DocumentService) contentFromChunks(doc *types.Document) (*types.DocumentContent, error) {
+	chunks, err := s.sortedChunks(doc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Chunks only overlap within a page, so join each page's chunks separately
+	overlap, _ := strconv.Atoi(doc.Metadata["chunk_overlap"])
+	var text strings.Builder
+	for start := 0; start < len(chunks); {
+		end := start + 1
+		for end < len(chunks) && chunks[end].Page == chunks[start].Page {
+			end++
+		}
+		texts := make([]string, 0, end-start)
+		for _, chunk := range chunks[start:end] {
+			texts = append(texts, chunk.Content)
+		}
+		text.WriteString(utils.JoinChunks(texts, overlap))
+		start = end
+	}
+
+	metadata := map[string]string{"chunks": strconv.Itoa(len(chunks))}
+	for k, v := range doc.Metadata {
+		metadata[k] = v
+	}
+
+	return &types.DocumentContent{
+		Text:              text.String(),
+		Type:              doc.Type,
+		Metadata:          metadata,
+		ExtractionMethod:  "chunks",
+		ExtractionQuality: types.ExtractionQualityHigh,
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+// RetrieveChunkText returns the chunks of a document most similar to the query, joined in document order.
+// Falls back to the leading chunks when the query can't be embedded.
+func (s *DocumentService) RetrieveChunkText(documentID, query string) (string, error) {
+	chunks, err := s.RetrieveChunks(documentID, query, s.config.EmbeddingTopK)
+	if err != nil {
+		return "", err
+	}
+	return joinChunks(chunks), nil
+}
+
+// RetrieveChunks returns up to limit chunks of a document, most similar to the query first. A limit
+// of 0 returns every chunk. Embedded chunks are ranked by vector similarity, or kept in document
+// order when the query can't be embedded; other documents are chunked and ranked lexically.
+func (s *DocumentService) RetrieveChunks(documentID, query string, limit int) ([]*types.DocumentChunk, error) {
+	doc, err := s.store.GetDocument(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("document not found: %w", err)
+	}
+	if !doc.Embeddings {
+		return s.retrieveExtractedChunks(doc, query, limit)
+	}
+
+	chunks, err := s.sortedChunks(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 || limit > len(chunks) {
+		limit = len(chunks)
+	}
+
+	queryEmbedding, err := s.ollama.Embed(s.config.EmbeddingModel, query)
+	if err != nil {
+		log.Printf("⚠️ Could not embed query, using leading chunks of %s: %v", documentID, err)
+		return chunks[:limit], nil
+	}
+
+	scored, err := s.store.SearchChunksByEmbedding(queryEmbedding, []string{documentID}, limit)
+	if err != nil {
+		log.Printf("⚠️ Could not rank chunks of %s, using leading chunks: %v", documentID, err)
+		return chunks[:limit], nil
+	}
+	ranked := make([]*types.DocumentChunk, len(scored))
+	for i, result := range scored {
+		ranked[i] = result.DocumentChunk
+	}
+	return ranked, nil
+}
+
+// joinChunks joins chunks in document order, marking the gaps between them
+func joinChunks(chunks []*types.DocumentChunk) string {
+	ordered := make([]*types.DocumentChunk, len(chunks))
+	copy(ordered, chunks)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].ChunkIndex < ordered[j].ChunkIndex })
+
+	parts := make([]string, len(ordered))
+	for i, chunk := range ordered {
+		parts[i] = chunk.Content
+	}
+	return strings.Join(parts, "\n...\n")
+}
+
+// Chunk search modes
+const (
+	ChunkSearchAuto   = "auto"   // Vector search when chunks have embeddings, text search otherwise
+	ChunkSearchVector = "vector" // Rank by cosine similarity to the embedded query
+	ChunkSearchText   = "text"   // Rank by matching lines
+)
+
+// SearchChunks searches the stored chunks of the documents the requester can access and returns
+// the best matches first
+func (s *DocumentService) SearchChunks(query, mode string, options utils.SearchOptions, limit int, access types.AccessContext) ([]types.ChunkSearchResult, string, error) {
+	if mode == "" {
+		mode = ChunkSearchAuto
+	}
+	if mode != ChunkSearchAuto && mode != ChunkSearchVector && mode != ChunkSearchText {
+		return nil, "", fmt.Errorf("invalid search mode %q, use auto, vector or text", mode)
+	}
+
+	documents := make(map[string]*types.Document)
+	var chunks []*types.DocumentChunk
+	embedded := false
+	stored, err := s.store.ListAllChunks()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list chunks: %w", err)
+	}
+	for _, chunk := range stored {
+		doc, ok := documents[chunk.DocumentID]
+		if !ok {
+			if doc, _ = s.store.GetDocument(chunk.DocumentID); doc != nil && !access.CanAccess(doc) {
+				doc = nil
+			}
+			documents[chunk.DocumentID] = doc
+		}
+		if doc == nil {
+			continue
+		}
+		chunks = append(chunks, chunk)
+		embedded = embedded || len(chunk.Embedding) > 0
+	}
+
+	var results []types.ChunkSearchResult
+	if mode == ChunkSearchVector || (mode == ChunkSearchAuto && embedded) {
+		queryEmbedding, err := s.ollama.Embed(s.config.EmbeddingModel, query)
+		switch {
+		case err == nil:
+			accessible := make([]string, 0, len(documents))
+			for id, doc := range documents {
+				if doc != nil {
+					accessible = append(accessible, id)
+				}
+			}
+			scored, err := s.store.SearchChunksByEmbedding(queryEmbedding, accessible, limit)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to search chunks: %w", err)
+			}
+			for _, result := range scored {
+				results = append(results, chunkSearchResult(result.DocumentChunk, documents[result.DocumentID],
+					result.Score, ChunkSearchVector))
+			}
+			mode = ChunkSearchVector
+		case mode == ChunkSearchVector:
+			return nil, "", fmt.Errorf("failed to embed query: %w", err)
+		default:
+			log.Printf("⚠️ Could not embed query, falling back to text chunk search: %v", err)
+		}
+	}
+
+	if mode != ChunkSearchVector {
+		mode = ChunkSearchText
+		searcher := utils.NewDocumentSearcher()
+		for _, chunk := range chunks {
+			if matches := searcher.SearchText(chunk.Content, query, options); len(matches) > 0 {
+				results = append(results, chunkSearchResult(chunk, documents[chunk.DocumentID],
+					float64(len(matches)), ChunkSearchText))
+			}
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].DocumentID != results[j].DocumentID {
+			return results[i].DocumentID < results[j].DocumentID
+		}
+		return results[i].ChunkIndex < results[j].ChunkIndex
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, mode, nil
+}
+
+// chunkSearchResult describes a matching chunk
+func chunkSearchResult(chunk *types.DocumentChunk, doc *types.Document, score float64, method string) types.ChunkSearchResult {
+	return types.ChunkSearchResult{
+		DocumentID:   chunk.DocumentID,
+		DocumentName: doc.Name,
+		ChunkIndex:   chunk.ChunkIndex,
+		Page:         chunk.Page,
+		Score:        score,
+		Method:       method,
+		Content:      chunk.Content,
+	}
+}
+
+// retrieveExtractedChunks extracts a document, chunks it and ranks the chunks by how many query
+// terms they share. Ranking is lexical so a query costs no embedding calls per chunk.
+func (s *DocumentService) retrieveExtractedChunks(doc *types.Document, query string, limit int) ([]*types.DocumentChunk, error) {
+	content, err := s.GetDocumentContent(doc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	texts := utils.ChunkTextWithStrategy(content.RetrievalText(), s.chunkStrategyFor(doc), s.config.ChunkSize, s.config.ChunkOverlap)
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("document has no text")
+	}
+
+	terms := strings.Fields(strings.ToLower(query))
+	chunks := make([]*types.DocumentChunk, len(texts))
+	scores := make(map[*types.DocumentChunk]int, len(texts))
+	for i, text := range texts {
+		chunks[i] = &types.DocumentChunk{DocumentID: doc.ID, ChunkIndex: i, Content: text}
+		lower := strings.ToLower(text)
+		for _, term := range terms {
+			scores[chunks[i]] += strings.Count(lower, term)
+		}
+	}
+
+	sort.SliceStable(chunks, func(a, b int) bool { return scores[chunks[a]] > scores[chunks[b]] })
+	if limit > 0 && limit < len(chunks) {
+		chunks = chunks[:limit]
+	}
+	return chunks, nil
+}
+
+// recordProcessorVersion stores which processor version last extracted the document
+func (s *DocumentService) recordProcessorVersion(documentID string, content *types.DocumentContent) {
+	err := s.store.UpdateDocumentMetadata(documentID, map[string]string{
+		"processor_version": content.Metadata["processor_version"],
+		"extracted_at":      content.ProcessedAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Printf("Warning: Failed to record processor version for %s: %v", documentID, err)
+	}
+
+	// Documents uploaded before dates were extracted pick them up on their next extraction
+	doc, err := s.store.GetDocument(documentID)
+	if err != nil || doc.CreatedDate != "" || content.Metadata["created_date"] == "" {
+		return
+	}
+	doc.CreatedDate = content.Metadata["created_date"]
+	doc.ModifiedDate = content.Metadata["modified_date"]
+	if err := s.store.UpdateDocument(doc); err != nil {
+		log.Printf("Warning: Failed to record dates for %s: %v", documentID, err)
+	}
+}
+
+// FindStaleDocuments returns documents extracted with an older processor version than the current one
+func (s *DocumentService) FindStaleDocuments() ([]types.Document, error) {
+	docs, err := s.store.ListDocuments()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	stale := []types.Document{}
+	for _, doc := range docs {
+		recorded, err := strconv.Atoi(doc.Metadata["processor_version"])
+		if err != nil || doc.Path == "" {
+			continue // Never extracted, nothing to refresh
+		}
+		if current := s.documentManager.ProcessorVersion(doc.Path); current > recorded {
+			stale = append(stale, *doc)
+		}
+	}
+
+	return stale, nil
+}
+
+// QueueStaleDocuments queues every stale document for background reprocessing
+func (s *DocumentService) QueueStaleDocuments() (int, error) {
+	stale, err := s.FindStaleDocuments()
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, doc := range stale {
+		select {
+		case s.reprocessQueue <- doc.ID:
+			queued++
+		default:
+			log.Printf("Warning: Reprocess queue full, skipping %s", logging.Document(doc.Name, doc.ID))
+		}
+	}
+	return queued, nil
+}
+
+// ReprocessPages re-extracts a page range of a PDF document, so extraction fixes for specific pages
+// can be checked without processing the whole file. Stored chunks of those pages are replaced by
+// chunks of the new text, embedded again when the document has embeddings.
+func (s *DocumentService) ReprocessPages(ctx context.Context, documentID string, pages processors.PageRange) (*types.DocumentContent, error) {
+	doc, err := s.store.GetDocument(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("document not found: %w", err)
+	}
+
+	if !strings.EqualFold(strings.TrimPrefix(doc.Type, "."), "pdf") {
+		return nil, ErrPagesNotSupported
+	}
+	if doc.Path == "" {
+		return nil, ErrSourceRemoved
+	}
+
+	content, err := s.documentManager.ProcessPDFPages(ctx, doc.Path, pages)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.rechunkPages(ctx, doc, content, pages); err != nil {
+		return nil, err
+	}
+
+	err = s.store.UpdateDocumentMetadata(doc.ID, map[string]string{
+		"pages_reprocessed":    pages.String(),
+		"pages_reprocessed_at": content.ProcessedAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Printf("Warning: Failed to record page reprocessing for %s: %v", doc.ID, err)
+	}
+	return content, nil
+}
+
+// rechunkPages replaces the stored chunks of the given pages with chunks of their re-extracted
+// content. Documents without chunks are left alone.
+func (s *DocumentService) rechunkPages(ctx context.Context, doc *types.Document, content *types.DocumentContent, pages processors.PageRange) error {
+	existing, err := s.store.GetChunks(doc.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load chunks: %w", err)
+	}
+	if len(existing) == 0 {
+		return nil
+	}
+
+	fresh := s.chunkContent(doc.ID, content, s.chunkStrategyFor(doc))
+	for _, chunk := range fresh {
+		if chunk.Page == 0 {
+			chunk.Page = pages.First // Single page extractions may come without a page header
+		}
+	}
+	if doc.Embeddings {
+		if err := s.embedChunks(ctx, fresh); err != nil {
+			return err
+		}
+	}
+
+	chunks := make([]*types.DocumentChunk, 0, len(existing)+len(fresh))
+	for _, chunk := range existing {
+		if chunk.Page < pages.First || chunk.Page > pages.Last {
+			chunks = append(chunks, chunk)
+		}
+	}
+	chunks = append(chunks, fresh...)
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].Page < chunks[j].Page })
+	for i, chunk := range chunks {
+		chunk.ID = fmt.Sprintf("%s_chunk_%d", doc.ID, i)
+		chunk.ChunkIndex = i
+	}
+
+	if err := s.store.ReplaceChunks(doc.ID, chunks); err != nil {
+		return fmt.Errorf("failed to store chunks: %w", err)
+	}
+	if doc, err = s.store.GetDocument(doc.ID); err != nil {
+		return fmt.Errorf("document not found: %w", err)
+	}
+	doc.Chunks = len(chunks)
+	if err := s.store.UpdateDocument(doc); err != nil {
+		return fmt.Errorf("failed to update document: %w", err)
+	}
+
+	log.Printf("🔁 Replaced the chunks of pages %s of %s, %d chunks now", pages, logging.Document(doc.Name, doc.ID), len(chunks))
+	return nil
+}
+
+// ProcessWithCallback queues a document for background processing and posts the outcome to
+// callbackURL. The URL is kept only until that outcome is posted, later reprocessing doesn't
+// call it again.
+func (s *DocumentService) ProcessWithCallback(documentID, callbackURL string) error {
+	if err := ValidateCallbackURL(callbackURL); err != nil {
+		return err
+	}
+
+	s.callbacksMu.Lock()
+	s.callbacks[documentID] = callbackURL
+	s.callbacksMu.Unlock()
+
+	select {
+	case s.reprocessQueue <- documentID:
+		return nil
+	default:
+		s.callbacksMu.Lock()
+		delete(s.callbacks, documentID)
+		s.callbacksMu.Unlock()
+		return fmt.Errorf("processing queue is full")
+	}
+}
+
+// reprocessWorker re-extracts queued documents one at a time
+func (s *DocumentService) reprocessWorker() {
+	for documentID := range s.reprocessQueue {
+		_, err := s.GetDocumentContent(documentID)
+		if err != nil {
+			log.Printf("❌ Reprocessing %s failed: %v", documentID, err)
+		} else {
+			log.Printf("🔁 Reprocessed document %s", documentID)
+		}
+		s.notifyCompletion(documentID, err)
+	}
+}
+
+// notifyCompletion posts the processing outcome to the document's pending callback URL, if it has
+// one, and forgets the URL
+func (s *DocumentService) notifyCompletion(documentID string, processErr error) {
+	s.callbacksMu.Lock()
+	callbackURL, pending := s.callbacks[documentID]
+	delete(s.callbacks, documentID)
+	s.callbacksMu.Unlock()
+	if !pending {
+		return
+	}
+
+	callback := types.ProcessingCallback{
+		DocumentID:  documentID,
+		Status:      "processed",
+		CompletedAt: time.Now().Format(time.RFC3339),
+	}
+	if doc, err := s.store.GetDocument(documentID); err == nil {
+		callback.ExternalID = doc.ExternalID
+	}
+	if processErr != nil {
+		callback.Status = "failed"
+		callback.Error = processErr.Error()
+	}
+
+	s.notifier.Notify(callbackURL, callback)
+}
+
+// Close saves state that is only flushed periodically, such as the processing stats. Call it on
+// shutdown, next to closing the database.
+func (s *DocumentService) Close() {
+	s.documentManager.Close()
+}
+
+// GetDocumentProcessingStats returns processing statistics
+func (s *DocumentService) GetDocumentProcessingStats() interface{} {
+	return s.documentManager.GetProcessingStats()
+}
+
+// ResetDocumentProcessingStats starts a fresh statistics window
+func (s *DocumentService) ResetDocumentProcessingStats() {
+	s.documentManager.ResetStats()
+}
+
+// GetContentCacheStats reports the extracted content cache, nil when caching is disabled
+func (s *DocumentService) GetContentCacheStats() *processors.CacheStats {
+	cache := s.documentManager.ContentCache()
+	if cache == nil {
+		return nil
+	}
+	stats := cache.Stats()
+	return &stats
+}
+
+// ClearContentCache drops all cached extractions and returns how many there were
+func (s *DocumentService) ClearContentCache() int {
+	cache := s.documentManager.ContentCache()
+	if cache == nil {
+		return 0
+	}
+	return cache.Clear()
+}
+
+// ValidateUploadedFile validates a file before upload
+func (s *DocumentService) ValidateUploadedFile(fileHeader *multipart.FileHeader) error {
+	// Check file extension
+	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
+	if strings.HasPrefix(ext, ".") {
+		ext = ext[1:]
+	}
+
+	supportedTypes := s.documentManager.GetSupportedTypes()
+	isSupported := false
+	for _, supportedType := range supportedTypes {
+		if ext == supportedType {
+			isSupported = true
+			break
+		}
+	}
+
+	if !isSupported {
+		return fmt.Errorf("unsupported file type: %s. Supported types: %v", ext, supportedTypes)
+	}
+
+	// Check file size against the same limit processing uses
+	if maxSize := s.documentManager.MaxFileSize; maxSize > 0 && fileHeader.Size > maxSize {
+		return fmt.Errorf("file too large: %d bytes (max: %d bytes)", fileHeader.Size, maxSize)
+	}
+
+	return nil
+}
+
+// HashUpload returns the hex SHA-256 of an uploaded file's content
+func HashUpload(fileHeader *multipart.FileHeader) (string, error) {
+	src, err := fileHeader.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer src.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, src); err != nil {
+		return "", fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// UploadDocument with frontend document support
+// An optional externalID must be unique, see ValidateExternalID.
+func (s *DocumentService) UploadDocument(fileHeader *multipart.FileHeader, owner, visibility, externalID string, userMetadata map[string]string) (*types.Document, error) {
+	// Validate file before upload
+	if err := s.ValidateUploadedFile(fileHeader); err != nil {
+		return nil, err
+	}
+	if externalID != "" {
+		if err := ValidateExternalID(externalID); err != nil {
+			return nil, err
+		}
+		// Checked again when the record is stored, this only avoids saving a file for nothing
+		if _, err := s.store.GetDocumentByExternalID(externalID); err == nil {
+			return nil, fmt.Errorf("%w: %s", storage.ErrDuplicateExternalID, externalID)
+		}
+	}
+
+	if visibility == "" {
+		visibility = s.config.DefaultVisibility
+	}
+	if !types.IsValidVisibility(visibility) {
+		return nil, fmt.Errorf("invalid visibility: %s", visibility)
+	}
+	if owner == "" && visibility != types.VisibilityPublic {
+		// Anonymous uploads have no owner who could see a restricted document
+		log.Printf("⚠️ Anonymous upload of %s stored as public", logging.File(fileHeader.Filename))
+		visibility = types.VisibilityPublic
+	}
+
+	// Determine save path - frontend uploads go to test_documents
+	var savePath string
+	isFromFrontend := true // Frontend'den geldiğini varsayıyoruz
+
+	if isFromFrontend {
+		// Frontend dokümanları test_documents'e kaydet
+		if err := os.MkdirAll(s.config.TestDocumentsPath, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create test_documents directory: %w", err)
+		}
+		savePath = s.config.TestDocumentsPath
+		log.Printf("📁 Saving frontend document to test_documents: %s", logging.File(fileHeader.Filename))
+	} else {
+		// API dokümanları uploads'e kaydet
+		if err := os.MkdirAll(s.config.UploadsPath, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create uploads directory: %w", err)
+		}
+		savePath = s.config.UploadsPath
+	}
+
+	// Open the uploaded file
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Write to a temp file first - the content hash strategy needs the whole file before naming it
+	tmp, err := os.CreateTemp(savePath, ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	hasher := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(tmp, hasher), file); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to save file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to save file: %w", err)
+	}
+
+	// Scan before the file gets a real name or a document record
+	clean, err := s.scanner.Scan(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan file: %w", err)
+	}
+	if !clean {
+		os.Remove(tmpPath)
+		log.Printf("🛑 Rejected upload %s: malware detected", logging.File(fileHeader.Filename))
+		return nil, ErrMalwareDetected
+	}
+
+	// Create unique filename using the configured strategy
+	contentHash := hex.EncodeToString(hasher.Sum(nil))
+
+	// Create document with enhanced metadata
+	dates := processors.ExtractDocumentDates(tmpPath, filepath.Ext(fileHeader.Filename))
+	doc := &types.Document{
+		Name:         fileHeader.Filename,
+		Type:         filepath.Ext(fileHeader.Filename),
+		Size:         fileHeader.Size,
+		UploadDate:   time.Now().Format("2006-01-02 15:04:05"),
+		Status:       "ready",
+		Owner:        owner,
+		Visibility:   visibility,
+		ExternalID:   externalID,
+		CreatedDate:  dates.Created,
+		ModifiedDate: dates.Modified,
+	}
+
+	// Add metadata about storage location
+	doc.Metadata = map[string]string{
+		"storage_location": func() string {
+			if isFromFrontend {
+				return "test_documents"
+			}
+			return "uploads"
+		}(),
+		"original_filename": fileHeader.Filename,
+		"upload_source":     "frontend",
+		"content_sha256":    contentHash,
+	}
+	for key, value := range userMetadata {
+		doc.Metadata[key] = value
+	}
+
+	if err := s.storeDocument(doc, tmpPath, savePath, contentHash); err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ Document uploaded successfully: %s -> %s", logging.Document(doc.Name, doc.ID), logging.File(doc.Path))
+	return doc, nil
+}
+
+// storeDocument moves the checked file at tmpPath into savePath and creates the document's record.
+// uploadMu is held from the capacity check until the record exists, so concurrent uploads can't
+// all fit into the same free space.
+func (s *DocumentService) storeDocument(doc *types.Document, tmpPath, savePath, contentHash string) error {
+	s.uploadMu.Lock()
+	if err := s.ensureCapacity(doc.Size, doc.Owner); err != nil {
+		s.uploadMu.Unlock()
+		return err
+	}
+	filename := s.uniqueFilename(savePath, s.storedFilename(doc.Name, contentHash))
+	doc.Path = filepath.Join(savePath, filename)
+	doc.Metadata["saved_filename"] = filename
+	if err := os.Rename(tmpPath, doc.Path); err != nil {
+		s.uploadMu.Unlock()
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+
+	// Save to memory database
+	err := s.store.CreateDocument(doc)
+	s.uploadMu.Unlock()
+	if err != nil {
+		os.Remove(doc.Path)
+		return fmt.Errorf("failed to save to database: %w", err)
+	}
+
+	// Titles and tags are suggested right away, content terms once the background extraction is done
+	s.indexSuggestions(doc, nil)
+	select {
+	case s.reprocessQueue <- doc.ID:
+	default:
+		log.Printf("Warning: Processing queue full, %s content won't be suggested until it is extracted", logging.Document(doc.Name, doc.ID))
+	}
+
+	// Sidecar makes the stored file traceable to its document without the database
+	if err := writeSidecar(doc, filename); err != nil {
+		log.Printf("Warning: Failed to write sidecar for %s: %v", logging.File(filename), err)
+	}
+	return nil
+}
+
+// SplitDocument copies a page range of a PDF document into a new PDF document, e.g. to index
+// the chapters of a large report separately. The new document belongs to owner and keeps the
+// source's visibility and user metadata.
+func (s *DocumentService) SplitDocument(ctx context.Context, documentID string, pages processors.PageRange, owner string) (*types.Document, error) {
+	source, err := s.store.GetDocument(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("document not found: %w", err)
+	}
+	if !strings.EqualFold(strings.TrimPrefix(source.Type, "."), "pdf") {
+		return nil, ErrPagesNotSupported
+	}
+	if source.Path == "" {
+		return nil, ErrSourceRemoved
+	}
+
+	savePath := filepath.Dir(source.Path)
+	tmp, err := os.CreateTemp(savePath, ".split-*.pdf")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if err := processors.SplitPDF(ctx, source.Path, tmpPath, pages); err != nil {
+		return nil, err
+	}
+
+	visibility := source.Visibility
+	if owner == "" {
+		visibility = types.VisibilityPublic // Only public documents are visible to anonymous requests
+	}
+
+	name := fmt.Sprintf("%s_pages_%s.pdf", strings.TrimSuffix(source.Name, filepath.Ext(source.Name)), pages)
+	metadata := map[string]string{
+		"storage_location": source.Metadata["storage_location"],
+		"upload_source":    "split",
+		"split_from":       source.ID,
+		"split_pages":      pages.String(),
+	}
+	copyUserMetadata(metadata, source.Metadata)
+
+	doc, err := s.storeDerivedDocument(tmpPath, name, owner, visibility, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("✂️ Split pages %s of %s into %s", pages, logging.Document(source.Name, source.ID), logging.Document(doc.Name, doc.ID))
+	return doc, nil
+}
+
+// Separators placed between the parts of merged text documents, by file type
+var mergeSeparators = map[string]string{
+	"txt": "\n\n",
+	"log": "\n",
+	"md":  "\n\n---\n\n",
+}
+
+// ErrMergeNotSupported is returned when documents can't be merged into one
+var ErrMergeNotSupported = errors.New("documents can't be merged")
+
+// MergeDocuments concatenates documents of the same type, in the given order, into a new document
+// named name (derived from the first document if empty). PDFs are merged page-wise; text, log and
+// Markdown files are joined with a separator. The new document belongs to owner, and is only as
+// visible as the most restricted source.
+func (s *DocumentService) MergeDocuments(ctx context.Context, documentIDs []string, name, owner string) (*types.Document, error) {
+	if len(documentIDs) < 2 {
+		return nil, fmt.Errorf("%w: at least two documents are required", ErrMergeNotSupported)
+	}
+
+	sources := make([]*types.Document, 0, len(documentIDs))
+	seen := make(map[string]bool)
+	for _, id := range documentIDs {
+		if seen[id] {
+			return nil, fmt.Errorf("%w: document %s is listed more than once", ErrMergeNotSupported, id)
+		}
+		seen[id] = true
+
+		doc, err := s.store.GetDocument(id)
+		if err != nil {
+			return nil, fmt.Errorf("document not found: %w", err)
+		}
+		if doc.Path == "" {
+			return nil, fmt.Errorf("%s: %w", doc.Name, ErrSourceRemoved)
+		}
+		sources = append(sources, doc)
+	}
+
+	fileType := strings.ToLower(strings.TrimPrefix(sources[0].Type, "."))
+	separator, isText := mergeSeparators[fileType]
+	if !isText && fileType != "pdf" {
+		return nil, fmt.Errorf("%w: %s documents are not supported", ErrMergeNotSupported, fileType)
+	}
+	for _, doc := range sources[1:] {
+		if other := strings.ToLower(strings.TrimPrefix(doc.Type, ".")); other != fileType {
+			return nil, fmt.Errorf("%w: %s is a %s document, expected %s", ErrMergeNotSupported, doc.Name, other, fileType)
+		}
+	}
+
+	savePath := filepath.Dir(sources[0].Path)
+	tmp, err := os.CreateTemp(savePath, ".merge-*."+fileType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if isText {
+		err = writeMergedText(tmp, sources, separator)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+	} else {
+		tmp.Close()
+		paths := make([]string, len(sources))
+		for i, doc := range sources {
+			paths[i] = doc.Path
+		}
+		err = processors.MergePDFs(ctx, paths, tmpPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if name = strings.TrimSpace(filepath.Base(name)); name == "" || name == "." {
+		name = strings.TrimSuffix(sources[0].Name, filepath.Ext(sources[0].Name)) + "_merged"
+	}
+	if !strings.EqualFold(filepath.Ext(name), "."+fileType) {
+		name += "." + fileType
+	}
+
+	visibility := types.VisibilityPublic
+	ids := make([]string, len(sources))
+	metadata := map[string]string{
+		"storage_location": sources[0].Metadata["storage_location"],
+		"upload_source":    "merge",
+	}
+	for i, doc := range sources {
+		ids[i] = doc.ID
+		visibility = mostRestrictedVisibility(visibility, doc.Visibility)
+		copyUserMetadata(metadata, doc.Metadata) // Earlier documents win on conflicting keys
+	}
+	if owner == "" {
+		visibility = types.VisibilityPublic // Only public documents are visible to anonymous requests
+	}
+	metadata["merged_from"] = utils.FormatMetadataList(ids)
+
+	doc, err := s.storeDerivedDocument(tmpPath, name, owner, visibility, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🧩 Merged %d documents into %s", len(sources), logging.Document(doc.Name, doc.ID))
+	return doc, nil
+}
+
+// writeMergedText copies the text documents to w in order, with separator between them
+func writeMergedText(w io.Writer, sources []*types.Document, separator string) error {
+	for i, doc := range sources {
+		data, err := os.ReadFile(doc.Path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", doc.Name, err)
+		}
+		if i > 0 {
+			data = append([]byte(separator), bytes.TrimPrefix(data, []byte("\ufeff"))...) // Only the first part keeps a BOM
+		}
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("failed to write merged file: %w", err)
+		}
+	}
+	return nil
+}
+
+// mostRestrictedVisibility returns whichever of a and b is visible to fewer users
+func mostRestrictedVisibility(a, b string) string {
+	rank := map[string]int{types.VisibilityPrivate: 0, types.VisibilityShared: 1, types.VisibilityPublic: 2}
+	if rank[b] < rank[a] {
+		return b
+	}
+	return a
+}
+
+// copyUserMetadata copies the user-supplied fields of src that dst doesn't have yet
+func copyUserMetadata(dst, src map[string]string) {
+	for key, value := range src {
+		if _, exists := dst[key]; !exists && strings.HasPrefix(key, UserMetadataPrefix) {
+			dst[key] = value
+		}
+	}
+}
+
+// storeDerivedDocument moves a file produced from other documents, such as a split or merge, into
+// storage next to it and creates its record. metadata is extended with the usual upload fields.
+func (s *DocumentService) storeDerivedDocument(tmpPath, name, owner, visibility string, metadata map[string]string) (*types.Document, error) {
+	data, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	sum := sha256.Sum256(data)
+	contentHash := hex.EncodeToString(sum[:])
+
+	fileType := strings.ToLower(filepath.Ext(name))
+	dates := processors.ExtractDocumentDates(tmpPath, fileType)
+	doc := &types.Document{
+		Name:         name,
+		Type:         fileType,
+		Size:         int64(len(data)),
+		UploadDate:   time.Now().Format("2006-01-02 15:04:05"),
+		Status:       "ready",
+		Owner:        owner,
+		Visibility:   visibility,
+		CreatedDate:  dates.Created,
+		ModifiedDate: dates.Modified,
+		Metadata:     metadata,
+	}
+	metadata["original_filename"] = name
+	metadata["content_sha256"] = contentHash
+
+	if err := s.storeDocument(doc, tmpPath, filepath.Dir(tmpPath), contentHash); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// storageUsage returns the number of stored documents and their total size
+func (s *DocumentService) storageUsage() (int, int64, []*types.Document, error) {
+	docs, err := s.store.ListDocuments()
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	var totalBytes int64
+	for _, doc := range docs {
+		totalBytes += doc.Size
+	}
+	return len(docs), totalBytes, docs, nil
+}
+
+// exceedsLimits reports whether adding incoming bytes as a new document would break a configured limit
+func (s *DocumentService) exceedsLimits(count int, totalBytes, incoming int64) bool {
+	if s.config.MaxDocuments > 0 && count+1 > s.config.MaxDocuments {
+		return true
+	}
+	return s.config.MaxStorageBytes > 0 && totalBytes+incoming > s.config.MaxStorageBytes
+}
+
+// ensureCapacity makes room for an upload of the given size, evicting the owner's oldest
+// documents when eviction is enabled. Documents of other users and anonymous uploads are never
+// evicted. Callers must hold uploadMu.
+func (s *DocumentService) ensureCapacity(size int64, owner string) error {
+	if s.config.MaxDocuments <= 0 && s.config.MaxStorageBytes <= 0 {
+		return nil
+	}
+
+	if s.config.MaxStorageBytes > 0 && size > s.config.MaxStorageBytes {
+		return fmt.Errorf("%w: file is larger than the storage limit", ErrStorageLimitReached)
+	}
+
+	count, totalBytes, docs, err := s.storageUsage()
+	if err != nil {
+		return err
+	}
+	if !s.exceedsLimits(count, totalBytes, size) {
+		return nil
+	}
+
+	if s.config.StorageEviction != "oldest" {
+		return ErrStorageLimitReached
+	}
+
+	// UploadDate uses a sortable layout, so string order is chronological
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].UploadDate < docs[j].UploadDate
+	})
+
+	for _, doc := range docs {
+		if !s.exceedsLimits(count, totalBytes, size) {
+			break
+		}
+		if owner == "" || doc.Owner != owner {
+			continue
+		}
+		if err := s.DeleteDocument(doc.ID); err != nil {
+			return fmt.Errorf("failed to evict document %s: %w", doc.ID, err)
+		}
+		log.Printf("🗑️ Evicted %s to stay within storage limits", logging.Document(doc.Name, doc.ID))
+		count--
+		totalBytes -= doc.Size
+	}
+
+	if s.exceedsLimits(count, totalBytes, size) {
+		return ErrStorageLimitReached
 	}
+	return nil
 }
 
-// SearchInDocumentContent searches within a specific document
-func (s *DocumentService) SearchInDocumentContent(documentID, query string) ([]string, error) {
-	doc, err := s.memDB.GetDocument(documentID)
+// GetStorageUsage reports current storage usage against the configured limits
+func (s *DocumentService) GetStorageUsage() (map[string]interface{}, error) {
+	count, totalBytes, _, err := s.storageUsage()
 	if err != nil {
-		return nil, fmt.Errorf("document not found: %w", err)
+		return nil, err
 	}
 
-	return s.documentManager.SearchInDocument(doc.Path, query)
+	return map[string]interface{}{
+		"document_count":    count,
+		"max_documents":     s.config.MaxDocuments,
+		"total_bytes":       totalBytes,
+		"total_size":        utils.FormatFileSize(totalBytes),
+		"max_storage_bytes": s.config.MaxStorageBytes,
+		"eviction":          s.config.StorageEviction,
+	}, nil
 }
 
-// AdvancedSearch performs advanced search with options
-func (s *DocumentService) AdvancedSearch(query string, options utils.SearchOptions) (map[string]*utils.SearchResult, error) {
-	// Get all documents
-	docs, err := s.memDB.ListDocuments()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get documents: %w", err)
+// ValidateExternalID checks an external ID sent by a client
+func ValidateExternalID(externalID string) error {
+	if !externalIDPattern.MatchString(externalID) || allDigitsPattern.MatchString(externalID) {
+		return ErrInvalidExternalID
+	}
+	return nil
+}
+
+// SanitizeUserMetadata validates user-supplied metadata and namespaces the keys so they can't
+// overwrite system metadata. Keys are lowercased; values are trimmed and stripped of control characters.
+func SanitizeUserMetadata(raw map[string]string) (map[string]string, error) {
+	if len(raw) > maxUserMetadataFields {
+		return nil, fmt.Errorf("too many metadata fields (max %d)", maxUserMetadataFields)
 	}
 
-	// Collect paths
-	var paths []string
-	for _, doc := range docs {
-		if doc.Path != "" {
-			paths = append(paths, doc.Path)
+	sanitized := make(map[string]string, len(raw))
+	for key, value := range raw {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !userMetadataKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid metadata key %q: use letters, digits, '_' or '-' (max 64)", key)
+		}
+
+		value = strings.TrimSpace(strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, value))
+		if len(value) > maxUserMetadataValueLength {
+			return nil, fmt.Errorf("metadata value for %q is too long (max %d characters)", key, maxUserMetadataValueLength)
 		}
+
+		sanitized[UserMetadataPrefix+key] = value
 	}
 
-	// Perform search
-	searcher := utils.NewDocumentSearcher()
-	return searcher.SearchInMultipleDocuments(paths, query, options)
+	return sanitized, nil
 }
 
-// GetDocumentPreview returns a preview of document content
-func (s *DocumentService) GetDocumentPreview(documentID string, maxLines int) (string, error) {
-	doc, err := s.memDB.GetDocument(documentID)
-	if err != nil {
-		return "", fmt.Errorf("document not found: %w", err)
+// FilterByUserMetadata keeps documents whose user metadata matches every filter (case-insensitive)
+func (s *DocumentService) FilterByUserMetadata(docs []types.Document, filters map[string]string) []types.Document {
+	if len(filters) == 0 {
+		return docs
 	}
 
-	return s.documentManager.GetDocumentPreview(doc.Path, maxLines)
+	filtered := []types.Document{}
+	for _, doc := range docs {
+		matches := true
+		for key, value := range filters {
+			if !strings.EqualFold(doc.Metadata[UserMetadataPrefix+strings.ToLower(key)], value) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			filtered = append(filtered, doc)
+		}
+	}
+	return filtered
 }
 
-func (s *DocumentService) ListDocuments() ([]types.Document, error) {
-	log.Println("Listing documents from memory database")
+// Document listing layouts
+const (
+	ListingFlat   = "flat" // Every document
+	ListingByName = "name" // The latest document per name with a count of its versions
+)
 
-	docs, err := s.memDB.ListDocuments()
-	if err != nil {
-		return nil, fmt.Errorf("failed to list documents: %w", err)
-	}
+// ErrInvalidListing is returned for an unknown listing layout
+var ErrInvalidListing = errors.New("listing must be flat or name")
 
-	// Convert pointers to values
-	result := make([]types.Document, len(docs))
-	for i, doc := range docs {
-		result[i] = *doc
+// ListingDefaults returns the configured listing layout and whether identical content is folded
+func (s *DocumentService) ListingDefaults() (string, bool) {
+	layout := s.config.DocumentListing
+	if layout == "" {
+		layout = ListingFlat
 	}
-
-	log.Printf("Found %d documents", len(result))
-	return result, nil
+	return layout, s.config.DocumentListingDedupe
 }
 
-// GetDocumentContent extracts content from a document with enhanced error handling
-func (s *DocumentService) GetDocumentContent(documentID string) (*types.DocumentContent, error) {
-	doc, err := s.memDB.GetDocument(documentID)
-	if err != nil {
-		return nil, fmt.Errorf("document not found: %w", err)
+// ArrangeListing prepares documents for display. With dedupe, documents with the same content hash
+// are folded into their latest upload; with the name layout, the latest document of each name is
+// shown with its versions. Entries keep the order their shown documents were listed in.
+func (s *DocumentService) ArrangeListing(docs []types.Document, layout string, dedupe bool) ([]types.DocumentGroup, error) {
+	if layout != ListingFlat && layout != ListingByName {
+		return nil, ErrInvalidListing
 	}
 
-	if doc.Path == "" {
-		return nil, fmt.Errorf("document path not available")
+	// UploadDate uses a sortable layout, so string order is chronological
+	newest := make([]types.Document, len(docs))
+	copy(newest, docs)
+	sort.SliceStable(newest, func(i, j int) bool { return newest[i].UploadDate > newest[j].UploadDate })
+
+	// Fold identical content into the latest upload
+	var entries []*types.DocumentGroup
+	byHash := make(map[string]*types.DocumentGroup)
+	for _, doc := range newest {
+		hash := doc.Metadata["content_sha256"]
+		if entry, ok := byHash[hash]; ok && dedupe && hash != "" {
+			entry.Duplicates++
+			entry.DuplicateIDs = append(entry.DuplicateIDs, doc.ID)
+			continue
+		}
+		entry := &types.DocumentGroup{Document: doc, Versions: 1, VersionIDs: []string{doc.ID}}
+		byHash[hash] = entry
+		entries = append(entries, entry)
 	}
 
-	// Validate file before processing
-	if err := s.documentManager.ValidateFile(doc.Path); err != nil {
-		return nil, fmt.Errorf("file validation failed: %w", err)
+	if layout == ListingByName {
+		var groups []*types.DocumentGroup
+		byName := make(map[string]*types.DocumentGroup)
+		for _, entry := range entries {
+			group, ok := byName[entry.Name]
+			if !ok {
+				byName[entry.Name] = entry
+				groups = append(groups, entry)
+				continue
+			}
+			group.Versions++
+			group.VersionIDs = append(group.VersionIDs, entry.ID)
+			group.Duplicates += entry.Duplicates
+			group.DuplicateIDs = append(group.DuplicateIDs, entry.DuplicateIDs...)
+		}
+		entries = groups
 	}
 
-	content, err := s.documentManager.ProcessDocument(doc.Path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to process document: %w", err)
+	position := make(map[string]int, len(docs))
+	for i, doc := range docs {
+		position[doc.ID] = i
 	}
-
-	return content, nil
+	listing := make([]types.DocumentGroup, len(entries))
+	for i, entry := range entries {
+		listing[i] = *entry
+	}
+	sort.SliceStable(listing, func(i, j int) bool { return position[listing[i].ID] < position[listing[j].ID] })
+	return listing, nil
 }
 
-// GetDocumentProcessingStats returns processing statistics
-func (s *DocumentService) GetDocumentProcessingStats() interface{} {
-	return s.documentManager.GetProcessingStats()
+// SetScanner replaces the upload scanner, e.g. with a custom implementation
+func (s *DocumentService) SetScanner(scanner Scanner) {
+	s.scanner = scanner
 }
 
-// ValidateUploadedFile validates a file before upload
-func (s *DocumentService) ValidateUploadedFile(fileHeader *multipart.FileHeader) error {
-	// Check file extension
-	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-	if strings.HasPrefix(ext, ".") {
-		ext = ext[1:]
+// UploadDocuments uploads several files with bounded concurrency.
+// Results are returned in the same order as the input files.
+func (s *DocumentService) UploadDocuments(fileHeaders []*multipart.FileHeader, owner, visibility string, userMetadata map[string]string) []types.BatchUploadResult {
+	workers := s.config.BatchUploadConcurrency
+	if workers <= 0 {
+		workers = 1
 	}
 
-	supportedTypes := s.documentManager.GetSupportedTypes()
-	isSupported := false
-	for _, supportedType := range supportedTypes {
-		if ext == supportedType {
-			isSupported = true
-			break
-		}
-	}
+	results := make([]types.BatchUploadResult, len(fileHeaders))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
 
-	if !isSupported {
-		return fmt.Errorf("unsupported file type: %s. Supported types: %v", ext, supportedTypes)
-	}
+	for i, fileHeader := range fileHeaders {
+		wg.Add(1)
+		sem <- struct{}{}
 
-	// Check file size (50MB limit for uploads)
-	const maxUploadSize = 50 * 1024 * 1024
-	if fileHeader.Size > maxUploadSize {
-		return fmt.Errorf("file too large: %d bytes (max: %d bytes)", fileHeader.Size, maxUploadSize)
+		go func(i int, fileHeader *multipart.FileHeader) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = s.uploadWithRetry(fileHeader, owner, visibility, userMetadata)
+		}(i, fileHeader)
 	}
 
-	return nil
+	wg.Wait()
+	return results
 }
 
-// UploadDocument with frontend document support
-func (s *DocumentService) UploadDocument(fileHeader *multipart.FileHeader) (*types.Document, error) {
-	// Validate file before upload
+// uploadWithRetry uploads one file of a batch. Files that can never be stored are rejected
+// right away, storage failures are retried with exponential backoff.
+func (s *DocumentService) uploadWithRetry(fileHeader *multipart.FileHeader, owner, visibility string, userMetadata map[string]string) (result types.BatchUploadResult) {
+	result = types.BatchUploadResult{Filename: fileHeader.Filename}
+	defer func() {
+		// A malformed file must not take the rest of the batch down with it
+		if r := recover(); r != nil {
+			log.Printf("❌ Batch upload of %s panicked: %v", logging.File(fileHeader.Filename), r)
+			result.Success = false
+			result.Document = nil
+			result.Outcome = types.UploadOutcomeStorageError
+			result.Error = fmt.Sprintf("internal error: %v", r)
+		}
+	}()
+
 	if err := s.ValidateUploadedFile(fileHeader); err != nil {
-		return nil, err
+		result.Outcome = types.UploadOutcomeValidationError
+		result.Error = err.Error()
+		return result
 	}
 
-	// Determine save path - frontend uploads go to test_documents
-	var savePath string
-	isFromFrontend := true // Frontend'den geldiğini varsayıyoruz
+	delay := time.Duration(s.config.BatchUploadRetryDelay) * time.Millisecond
+	for {
+		result.Attempts++
+		doc, err := s.UploadDocument(fileHeader, owner, visibility, "", userMetadata)
+		if err == nil {
+			result.Success = true
+			result.Document = doc
+			result.Error = ""
+			result.Outcome = types.UploadOutcomeSuccess
+			if result.Attempts > 1 {
+				result.Outcome = types.UploadOutcomeRetried
+			}
+			return result
+		}
 
-	if isFromFrontend {
-		// Frontend dokümanları test_documents'e kaydet
-		if err := os.MkdirAll(s.config.TestDocumentsPath, 0755); err != nil {
-			return nil, fmt.Errorf("failed to create test_documents directory: %w", err)
+		result.Error = err.Error()
+		if isPermanentUploadError(err) {
+			log.Printf("❌ Batch upload rejected %s: %v", logging.File(fileHeader.Filename), err)
+			result.Outcome = types.UploadOutcomeValidationError
+			switch {
+			case errors.Is(err, ErrStorageLimitReached):
+				result.Outcome = types.UploadOutcomeStorageLimit
+			case errors.Is(err, ErrMalwareDetected):
+				result.Outcome = types.UploadOutcomeMalwareDetected
+			}
+			return result
 		}
-		savePath = s.config.TestDocumentsPath
-		log.Printf("📁 Saving frontend document to test_documents: %s", fileHeader.Filename)
-	} else {
-		// API dokümanları uploads'e kaydet
-		if err := os.MkdirAll(s.config.UploadsPath, 0755); err != nil {
-			return nil, fmt.Errorf("failed to create uploads directory: %w", err)
+		result.Outcome = types.UploadOutcomeStorageError
+		if result.Attempts > s.config.BatchUploadRetries {
+			log.Printf("❌ Batch upload failed for %s after %d attempts: %v", logging.File(fileHeader.Filename), result.Attempts, err)
+			return result
 		}
-		savePath = s.config.UploadsPath
-	}
 
-	// Open the uploaded file
-	file, err := fileHeader.Open()
-	if err != nil {
-		return nil, err
+		log.Printf("⚠️ Batch upload of %s failed, retrying in %v: %v", logging.File(fileHeader.Filename), delay, err)
+		time.Sleep(delay)
+		delay *= 2
 	}
-	defer file.Close()
+}
 
-	// Create unique filename with timestamp
-	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("%s_%s", timestamp, fileHeader.Filename)
-	filePath := filepath.Join(savePath, filename)
+// isPermanentUploadError reports whether an upload failed because of the file itself or a limit,
+// so retrying it can't help
+func isPermanentUploadError(err error) bool {
+	return errors.Is(err, ErrMalwareDetected) || errors.Is(err, ErrStorageLimitReached) ||
+		errors.Is(err, storage.ErrDuplicateExternalID)
+}
 
-	// Create the destination file
-	dst, err := os.Create(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create file: %w", err)
-	}
-	defer dst.Close()
+// storedFilename builds the on-disk name for an upload according to the configured strategy
+func (s *DocumentService) storedFilename(original, contentHash string) string {
+	ext := strings.ToLower(filepath.Ext(original))
 
-	// Copy file content
-	if _, err = io.Copy(dst, file); err != nil {
-		return nil, fmt.Errorf("failed to save file: %w", err)
+	switch s.config.FilenameStrategy {
+	case "hash":
+		return contentHash + ext
+	case "uuid":
+		return utils.NewUUID() + ext
+	default:
+		timestamp := time.Now().Format("20060102_150405")
+		return fmt.Sprintf("%s_%s", timestamp, filepath.Base(original))
 	}
+}
 
-	// Create document with enhanced metadata
-	doc := &types.Document{
-		Name:       fileHeader.Filename,
-		Type:       filepath.Ext(fileHeader.Filename),
-		Size:       fileHeader.Size,
-		UploadDate: time.Now().Format("2006-01-02 15:04:05"),
-		Status:     "ready",
-		Path:       filePath,
-	}
+// uniqueFilename appends a counter when the name is already taken in dir
+func (s *DocumentService) uniqueFilename(dir, filename string) string {
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
 
-	// Add metadata about storage location
-	doc.Metadata = map[string]string{
-		"storage_location": func() string {
-			if isFromFrontend {
-				return "test_documents"
-			}
-			return "uploads"
-		}(),
-		"original_filename": fileHeader.Filename,
-		"saved_filename":    filename,
-		"upload_source":     "frontend",
+	candidate := filename
+	for i := 1; ; i++ {
+		if _, err := os.Stat(filepath.Join(dir, candidate)); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s_%d%s", base, i, ext)
 	}
+}
 
-	// Save to memory database
-	if err := s.memDB.CreateDocument(doc); err != nil {
-		return nil, fmt.Errorf("failed to save to database: %w", err)
+// sidecarPath returns the path of the .meta.json file stored next to a document
+func sidecarPath(filePath string) string {
+	return filePath + ".meta.json"
+}
+
+// writeSidecar stores the document ID and original name next to the stored file
+func writeSidecar(doc *types.Document, savedFilename string) error {
+	sidecar := map[string]string{
+		"document_id":    doc.ID,
+		"original_name":  doc.Name,
+		"saved_filename": savedFilename,
+		"upload_date":    doc.UploadDate,
+		"owner":          doc.Owner,
+		"visibility":     doc.Visibility,
+		"size":           fmt.Sprintf("%d", doc.Size),
 	}
 
-	log.Printf("✅ Document uploaded successfully: %s -> %s", doc.Name, filePath)
-	return doc, nil
+	data, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(sidecarPath(doc.Path), data, 0644)
 }
 
 // GetTestDocuments returns documents from test_documents folder
 func (s *DocumentService) GetTestDocuments() ([]types.Document, error) {
-	docs, err := s.memDB.ListDocuments()
+	docs, err := s.store.ListDocuments()
 	if err != nil {
 		return nil, err
 	}
@@ -300,7 +2032,7 @@ func (s *DocumentService) CleanupTestDocuments() error {
 	// Delete each document
 	for _, doc := range testDocs {
 		if err := s.DeleteDocument(doc.ID); err != nil {
-			log.Printf("Warning: Failed to delete test document %s: %v", doc.Name, err)
+			log.Printf("Warning: Failed to delete test document %s: %v", logging.Document(doc.Name, doc.ID), err)
 		}
 	}
 
@@ -319,10 +2051,10 @@ func (s *DocumentService) CleanupTestDocuments() error {
 }
 
 func (s *DocumentService) SearchDocuments(query string) ([]types.Document, error) {
-	log.Printf("🔍 Searching documents for query: '%s'", query)
+	log.Printf("🔍 Searching documents for query: '%s'", logging.Text(query))
 
 	// Get all documents from memory database
-	docs, err := s.memDB.ListDocuments()
+	docs, err := s.store.ListDocuments()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list documents: %w", err)
 	}
@@ -347,7 +2079,7 @@ func (s *DocumentService) SearchDocuments(query string) ([]types.Document, error
 			if content, err := os.ReadFile(doc.Path); err == nil {
 				if containsIgnoreCase(string(content), query) {
 					matched = true
-					log.Printf("📄 Content match found in %s", doc.Name)
+					log.Printf("📄 Content match found in %s", logging.Document(doc.Name, doc.ID))
 				}
 			}
 		}
@@ -368,10 +2100,66 @@ func (s *DocumentService) SearchDocuments(query string) ([]types.Document, error
 		result[i] = *doc
 	}
 
-	log.Printf("✅ Found %d documents matching query '%s'", len(result), query)
+	log.Printf("✅ Found %d documents matching query '%s'", len(result), logging.Text(query))
+	return result, nil
+}
+
+// QueryDocuments returns one page of the accessible documents matching all of the query's filters
+func (s *DocumentService) QueryDocuments(query types.DocumentQuery, access types.AccessContext) (*types.DocumentQueryResult, error) {
+	docs, err := s.store.QueryDocuments(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+
+	matched := []types.Document{}
+	for _, doc := range docs {
+		if !access.CanAccess(doc) {
+			continue
+		}
+		if query.Text != "" && !s.documentContainsText(doc, query.Text) {
+			continue
+		}
+		matched = append(matched, *doc)
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultQueryLimit
+	} else if limit > maxQueryLimit {
+		limit = maxQueryLimit
+	}
+
+	result := &types.DocumentQueryResult{
+		Documents: []types.Document{},
+		Total:     len(matched),
+		Offset:    query.Offset,
+		Limit:     limit,
+	}
+	if query.Offset < len(matched) {
+		end := query.Offset + limit
+		if end > len(matched) {
+			end = len(matched)
+		}
+		result.Documents = matched[query.Offset:end]
+	}
+
+	log.Printf("🔍 Document query matched %d documents, returning %d", result.Total, len(result.Documents))
 	return result, nil
 }
 
+// documentContainsText reports whether a document's name or extracted content contains text
+func (s *DocumentService) documentContainsText(doc *types.Document, text string) bool {
+	if containsIgnoreCase(doc.Name, text) {
+		return true
+	}
+
+	content, err := s.GetDocumentContent(doc.ID)
+	if err != nil {
+		return false
+	}
+	return containsIgnoreCase(content.Text, text)
+}
+
 // Helper function for case-insensitive string matching
 func containsIgnoreCase(s, substr string) bool {
 	return len(s) >= len(substr) &&
@@ -381,32 +2169,112 @@ func containsIgnoreCase(s, substr string) bool {
 			strings.Contains(strings.ToLower(s), strings.ToLower(substr)))
 }
 
+// ErrInvalidUpdate is returned by UpdateDocument for field values that can't be stored
+var ErrInvalidUpdate = errors.New("invalid document update")
+
+// DocumentUpdate lists the fields UpdateDocument changes, nil fields are left as they are
+type DocumentUpdate struct {
+	Status     *string `json:"status"`
+	Chunks     *int    `json:"chunks"`
+	Embeddings *bool   `json:"embeddings"`
+	Visibility *string `json:"visibility"`
+	// User metadata to set, an empty value removes the key
+	Metadata map[string]string `json:"metadata"`
+}
+
+// UpdateDocument applies an update to a stored document and returns the result
+func (s *DocumentService) UpdateDocument(documentID string, update DocumentUpdate) (*types.Document, error) {
+	doc, err := s.store.GetDocument(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("document not found: %w", err)
+	}
+
+	if update.Status != nil {
+		status := strings.TrimSpace(*update.Status)
+		if status == "" {
+			return nil, fmt.Errorf("%w: status must not be empty", ErrInvalidUpdate)
+		}
+		doc.Status = status
+	}
+	if update.Chunks != nil {
+		if *update.Chunks < 0 {
+			return nil, fmt.Errorf("%w: chunks must not be negative", ErrInvalidUpdate)
+		}
+		doc.Chunks = *update.Chunks
+	}
+	if update.Embeddings != nil {
+		doc.Embeddings = *update.Embeddings
+	}
+	if update.Visibility != nil {
+		if !types.IsValidVisibility(*update.Visibility) {
+			return nil, fmt.Errorf("%w: invalid visibility %s", ErrInvalidUpdate, *update.Visibility)
+		}
+		if doc.Owner == "" && *update.Visibility != types.VisibilityPublic {
+			// Anonymous uploads have no owner who could see a restricted document
+			return nil, fmt.Errorf("%w: documents without an owner must stay public", ErrInvalidUpdate)
+		}
+		doc.Visibility = *update.Visibility
+	}
+	if len(update.Metadata) > 0 {
+		metadata, err := SanitizeUserMetadata(update.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidUpdate, err)
+		}
+		// Work on a copy: the store may share the map with other readers
+		doc.Metadata = maps.Clone(doc.Metadata)
+		if doc.Metadata == nil {
+			doc.Metadata = make(map[string]string)
+		}
+		for key, value := range metadata {
+			if value == "" {
+				delete(doc.Metadata, key)
+			} else {
+				doc.Metadata[key] = value
+			}
+		}
+	}
+
+	if err := s.store.UpdateDocument(doc); err != nil {
+		return nil, fmt.Errorf("failed to update document: %w", err)
+	}
+	if len(update.Metadata) > 0 {
+		s.indexSuggestions(doc, nil) // Tags may have changed, the content terms stay
+	}
+
+	log.Printf("✏️ Updated document %s (%s)", logging.Document(doc.Name, doc.ID), doc.ID)
+	return doc, nil
+}
+
 func (s *DocumentService) DeleteDocument(idStr string) error {
 	log.Printf("Deleting document with ID: %s", idStr)
 
 	// Get document info first
-	doc, err := s.memDB.GetDocument(idStr)
+	doc, err := s.store.GetDocument(idStr)
 	if err != nil {
 		return fmt.Errorf("document with id %s not found: %w", idStr, err)
 	}
 
-	// Delete from memory database
-	if err := s.memDB.DeleteDocument(idStr); err != nil {
+	if err := s.store.DeleteDocument(idStr); err != nil {
 		return fmt.Errorf("failed to delete document from database: %w", err)
 	}
+	s.memDB.DeleteSuggestions(idStr)
 
 	// Delete file from filesystem if path exists
 	if doc.Path != "" {
 		if err := os.Remove(doc.Path); err != nil {
 			// Log the error but don't fail the operation
 			// since the database record is already deleted
-			log.Printf("Warning: failed to delete file %s: %v", doc.Path, err)
+			log.Printf("Warning: failed to delete file %s: %v", logging.File(doc.Path), err)
 		} else {
-			log.Printf("Successfully deleted file: %s", doc.Path)
+			log.Printf("Successfully deleted file: %s", logging.File(doc.Path))
+		}
+
+		if err := os.Remove(sidecarPath(doc.Path)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Warning: failed to delete sidecar for %s: %v", logging.File(doc.Path), err)
 		}
 	}
 
-	log.Printf("Successfully deleted document: %s", doc.Name)
+	log.Printf("Successfully deleted document: %s", logging.Document(doc.Name, doc.ID))
 	return nil
 }
 
@@ -415,20 +2283,43 @@ func (s *DocumentService) GetSupportedDocumentTypes() []string {
 	return s.documentManager.GetSupportedTypes()
 }
 
+// GetProcessorVersions returns the registered processors and their versions by file type
+func (s *DocumentService) GetProcessorVersions() map[string]int {
+	return s.documentManager.ProcessorVersions()
+}
+
 // GetDocument returns a document by ID
 func (s *DocumentService) GetDocument(documentID string) (*types.Document, error) {
-	return s.memDB.GetDocument(documentID)
+	return s.store.GetDocument(documentID)
+}
+
+// GetDocumentByExternalID returns the document an integrating system knows by externalID
+func (s *DocumentService) GetDocumentByExternalID(externalID string) (*types.Document, error) {
+	return s.store.GetDocumentByExternalID(externalID)
+}
+
+// ResolveDocumentID returns the internal ID of the document id refers to, which may be an internal
+// or an external ID. External IDs are never all digits like internal IDs, so the two can't be
+// confused. Unknown IDs are returned unchanged.
+func (s *DocumentService) ResolveDocumentID(id string) string {
+	if _, err := s.store.GetDocument(id); err == nil {
+		return id
+	}
+	if doc, err := s.store.GetDocumentByExternalID(id); err == nil {
+		return doc.ID
+	}
+	return id
 }
 
 // GetDocumentFileInfo returns comprehensive file information
 func (s *DocumentService) GetDocumentFileInfo(documentID string) (*utils.FileInfo, error) {
-	doc, err := s.memDB.GetDocument(documentID)
+	doc, err := s.store.GetDocument(documentID)
 	if err != nil {
 		return nil, fmt.Errorf("document not found: %w", err)
 	}
 
 	if doc.Path == "" {
-		return nil, fmt.Errorf("document path not available")
+		return nil, ErrSourceRemoved
 	}
 
 	// Get document content
@@ -441,6 +2332,29 @@ func (s *DocumentService) GetDocumentFileInfo(documentID string) (*utils.FileInf
 	return utils.GetFileInfo(doc.Path, content)
 }
 
+// ProcessDocuments processes a batch of documents. Unknown IDs are reported as failed.
+func (s *DocumentService) ProcessDocuments(documentIDs []string) *processors.BatchResult {
+	return s.ProcessDocumentsContext(context.Background(), documentIDs)
+}
+
+// ProcessDocumentsContext processes a batch of documents, stopping when ctx is cancelled
+func (s *DocumentService) ProcessDocumentsContext(ctx context.Context, documentIDs []string) *processors.BatchResult {
+	var paths []string
+	var missing []processors.FileOutcome
+	for _, id := range documentIDs {
+		doc, err := s.store.GetDocument(id)
+		if err != nil || doc.Path == "" {
+			missing = append(missing, processors.FileOutcome{Path: id, Reason: "document not found"})
+			continue
+		}
+		paths = append(paths, doc.Path)
+	}
+
+	result := s.documentManager.ProcessMultipleDocumentsContext(ctx, paths)
+	result.Failed = append(result.Failed, missing...)
+	return result
+}
+
 // GetDocumentAnalysis provides content analysis
 func (s *DocumentService) GetDocumentAnalysis(documentID string) (map[string]interface{}, error) {
 	content, err := s.GetDocumentContent(documentID)
@@ -455,3 +2369,96 @@ func (s *DocumentService) GetDocumentAnalysis(documentID string) (map[string]int
 
 	return analysis, nil
 }
+
+// GetDocumentSummary combines document fields, file info, analysis, language, outline and a
+// short preview so a detail view needs a single request. The document is processed once.
+func (s *DocumentService) GetDocumentSummary(documentID string) (map[string]interface{}, error) {
+	doc, err := s.store.GetDocument(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("document not found: %w", err)
+	}
+
+	content, err := s.GetDocumentContent(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(content.Text, "\n")
+	if len(lines) > summaryPreviewLines {
+		lines = lines[:summaryPreviewLines]
+	}
+
+	summary := map[string]interface{}{
+		"document":           doc,
+		"analysis":           utils.AnalyzeContent(content.Text),
+		"language":           utils.DetectLanguage(content.Text),
+		"preview":            utils.TruncateString(strings.Join(lines, "\n"), summaryPreviewChars),
+		"extraction_method":  content.ExtractionMethod,
+		"extraction_quality": content.ExtractionQuality,
+	}
+
+	// Embeddings-only documents have no file left to describe
+	if doc.Path != "" {
+		fileInfo, err := utils.GetFileInfo(doc.Path, content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get file info: %w", err)
+		}
+		summary["file_info"] = fileInfo
+	}
+
+	if content.Type == "markdown" {
+		summary["outline"] = processors.MarkdownOutline(content.Text)
+	}
+
+	return summary, nil
+}
+
+// documentStatistics returns the comparable metrics of a document
+func (s *DocumentService) documentStatistics(documentID string) (map[string]interface{}, error) {
+	doc, err := s.store.GetDocument(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("document not found: %w", err)
+	}
+
+	content, err := s.GetDocumentContent(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := utils.AnalyzeContent(content.Text)
+	return map[string]interface{}{
+		"document_id":       documentID,
+		"name":              doc.Name,
+		"word_count":        analysis["total_words"],
+		"total_lines":       analysis["total_lines"],
+		"total_chars":       analysis["total_chars"],
+		"complexity_score":  utils.CalculateComplexityScore(content.Text),
+		"readability_score": utils.CalculateReadabilityScore(content.Text),
+		"language":          utils.DetectLanguage(content.Text),
+	}, nil
+}
+
+// CompareDocumentStatistics analyzes two documents and reports the metric deltas (b minus a)
+func (s *DocumentService) CompareDocumentStatistics(documentA, documentB string) (map[string]interface{}, error) {
+	statsA, err := s.documentStatistics(documentA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze document %s: %w", documentA, err)
+	}
+
+	statsB, err := s.documentStatistics(documentB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze document %s: %w", documentB, err)
+	}
+
+	deltas := map[string]int{}
+	for _, key := range []string{"word_count", "total_lines", "total_chars", "complexity_score", "readability_score"} {
+		deltas[key] = statsB[key].(int) - statsA[key].(int)
+	}
+
+	return map[string]interface{}{
+		"a":             statsA,
+		"b":             statsB,
+		"deltas":        deltas,
+		"same_language": statsA["language"] == statsB["language"],
+	}, nil
+}
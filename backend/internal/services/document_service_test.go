@@ -0,0 +1,117 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"path/filepath"
+	"testing"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/config"
+	"github.com/1DeliDolu/ki-ai-go/internal/storage"
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// newTestDocumentService returns a DocumentService over an in-memory store with its directories
+// in a temporary directory
+func newTestDocumentService(t *testing.T) *DocumentService {
+	t.Helper()
+
+	dir := t.TempDir()
+	cfg := config.Load()
+	cfg.UploadsPath = filepath.Join(dir, "uploads")
+	cfg.TestDocumentsPath = filepath.Join(dir, "test_documents")
+	cfg.ModelsPath = filepath.Join(dir, "models")
+	cfg.ProcessingStatsPath = ""
+	cfg.MemoryDBSnapshotPath = ""
+	cfg.UploadScanEnabled = false
+	cfg.AdminUsers = []string{"admin"}
+
+	return NewDocumentService(storage.NewMemoryDB(), cfg)
+}
+
+// uploadTestFile uploads content as a file named name through a real multipart form
+func uploadTestFile(t *testing.T, s *DocumentService, name, content, owner, visibility string) *types.Document {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	part.Write([]byte(content))
+	writer.Close()
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("ReadForm: %v", err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+
+	doc, err := s.UploadDocument(form.File["file"][0], owner, visibility, "", nil)
+	if err != nil {
+		t.Fatalf("UploadDocument(%s): %v", name, err)
+	}
+	return doc
+}
+
+func hasSuggestion(suggestions []types.Suggestion, term string) bool {
+	for _, suggestion := range suggestions {
+		if suggestion.Term == term {
+			return true
+		}
+	}
+	return false
+}
+
+func TestUpdateDocumentMetadataKeepsContentSuggestions(t *testing.T) {
+	s := newTestDocumentService(t)
+	doc := uploadTestFile(t, s, "notes.txt", "photosynthesis converts light. photosynthesis needs water.", "", "")
+
+	if _, err := s.GetDocumentContent(doc.ID); err != nil {
+		t.Fatalf("GetDocumentContent: %v", err)
+	}
+	if got := s.SuggestTerms("photo", 10, types.AccessContext{}); !hasSuggestion(got, "photosynthesis") {
+		t.Fatalf("content term missing after extraction: %+v", got)
+	}
+
+	update := DocumentUpdate{Metadata: map[string]string{"tags": "biology"}}
+	if _, err := s.UpdateDocument(doc.ID, update); err != nil {
+		t.Fatalf("UpdateDocument: %v", err)
+	}
+
+	if got := s.SuggestTerms("photo", 10, types.AccessContext{}); !hasSuggestion(got, "photosynthesis") {
+		t.Errorf("content term lost after changing tags: %+v", got)
+	}
+	if got := s.SuggestTerms("bio", 10, types.AccessContext{}); !hasSuggestion(got, "biology") {
+		t.Errorf("new tag not suggested: %+v", got)
+	}
+}
+
+func TestEmbeddingsOnlyDocumentWithoutSourceFile(t *testing.T) {
+	s := newTestDocumentService(t)
+	doc := &types.Document{Name: "vectors.txt", Type: ".txt", Embeddings: true, Visibility: types.VisibilityPublic}
+	if err := s.store.CreateDocument(doc); err != nil {
+		t.Fatalf("CreateDocument: %v", err)
+	}
+	chunk := &types.DocumentChunk{DocumentID: doc.ID, Content: "only the chunks are kept"}
+	if err := s.store.CreateChunk(chunk); err != nil {
+		t.Fatalf("CreateChunk: %v", err)
+	}
+
+	summary, err := s.GetDocumentSummary(doc.ID)
+	if err != nil {
+		t.Fatalf("GetDocumentSummary: %v", err)
+	}
+	if _, ok := summary["file_info"]; ok {
+		t.Errorf("summary has file_info for a document without a file")
+	}
+
+	if _, err := s.GetDocumentFileInfo(doc.ID); !errors.Is(err, ErrSourceRemoved) {
+		t.Errorf("GetDocumentFileInfo error = %v, want ErrSourceRemoved", err)
+	}
+	if _, err := s.GetDocumentPreview(doc.ID, 10); !errors.Is(err, ErrSourceRemoved) {
+		t.Errorf("GetDocumentPreview error = %v, want ErrSourceRemoved", err)
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/config"
+)
+
+// ErrGenerationQueueFull is returned when a model already has too many generations queued,
+// or a queued generation timed out waiting for its model
+var ErrGenerationQueueFull = errors.New("generation queue is full, please retry later")
+
+// GenerationLimiter bounds concurrent generations per model. Ollama serializes generation per
+// model, so extra requests are queued here instead of piling up (and timing out) in Ollama.
+type GenerationLimiter struct {
+	concurrency  int
+	queueDepth   int
+	queueTimeout time.Duration
+
+	mu     sync.Mutex
+	models map[string]*modelQueue
+}
+
+// modelQueue holds the generation slots of one model
+type modelQueue struct {
+	slots    chan struct{}
+	waiting  int
+	rejected int64
+}
+
+func NewGenerationLimiter(cfg *config.Config) *GenerationLimiter {
+	concurrency := cfg.GenerationConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	return &GenerationLimiter{
+		concurrency:  concurrency,
+		queueDepth:   cfg.GenerationQueueDepth,
+		queueTimeout: time.Duration(cfg.GenerationQueueTimeout) * time.Second,
+		models:       make(map[string]*modelQueue),
+	}
+}
+
+// queue returns the model's queue, creating it on first use
+func (l *GenerationLimiter) queue(modelName string) *modelQueue {
+	q, ok := l.models[modelName]
+	if !ok {
+		q = &modelQueue{slots: make(chan struct{}, l.concurrency)}
+		l.models[modelName] = q
+	}
+	return q
+}
+
+// Acquire reserves a generation slot for the model, queueing behind running generations up to
+// the queue depth and timeout. It returns ErrGenerationQueueFull when no slot became available,
+// or ctx's error when the caller gave up while queued.
+func (l *GenerationLimiter) Acquire(ctx context.Context, modelName string) error {
+	l.mu.Lock()
+	q := l.queue(modelName)
+	select {
+	case q.slots <- struct{}{}:
+		l.mu.Unlock()
+		return nil
+	default:
+	}
+
+	if q.waiting >= l.queueDepth || l.queueTimeout <= 0 {
+		q.rejected++
+		l.mu.Unlock()
+		return ErrGenerationQueueFull
+	}
+	q.waiting++
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case q.slots <- struct{}{}:
+	case <-timer.C:
+		err = ErrGenerationQueueFull
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.mu.Lock()
+	q.waiting--
+	if err == ErrGenerationQueueFull {
+		q.rejected++
+	}
+	l.mu.Unlock()
+	return err
+}
+
+// Release frees a slot previously reserved with Acquire
+func (l *GenerationLimiter) Release(modelName string) {
+	l.mu.Lock()
+	q := l.queue(modelName)
+	l.mu.Unlock()
+	<-q.slots
+}
+
+// Stats returns the running and queued generations per model for health and metrics endpoints
+func (l *GenerationLimiter) Stats() map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	models := make(map[string]interface{}, len(l.models))
+	for name, q := range l.models {
+		models[name] = map[string]interface{}{
+			"in_flight":      len(q.slots),
+			"queue_depth":    q.waiting,
+			"rejected_total": q.rejected,
+		}
+	}
+	return map[string]interface{}{
+		"max_concurrent_per_model": l.concurrency,
+		"max_queue_depth":          l.queueDepth,
+		"models":                   models,
+	}
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/config"
+)
+
+var (
+	// ErrIdempotencyInProgress is returned when a request with the same key is still running
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still in progress")
+	// ErrIdempotencyMismatch is returned when a key is reused for a different request
+	ErrIdempotencyMismatch = errors.New("idempotency key was already used for a different request")
+)
+
+// IdempotentResult is the stored response of a completed request
+type IdempotentResult struct {
+	Status int
+	Body   interface{}
+}
+
+type idempotencyEntry struct {
+	fingerprint string
+	result      *IdempotentResult // nil while the request is running
+	expires     time.Time
+}
+
+// IdempotencyStore remembers the results of requests sent with an Idempotency-Key so client
+// retries are answered with the original result instead of repeating the operation. Only
+// successful results are kept; a failed request releases its key so it can be retried.
+type IdempotencyStore struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*idempotencyEntry
+	order      []string // Keys in insertion order, oldest first
+}
+
+func NewIdempotencyStore(cfg *config.Config) *IdempotencyStore {
+	maxEntries := cfg.IdempotencyMaxKeys
+	if maxEntries <= 0 {
+		maxEntries = 1
+	}
+
+	return &IdempotencyStore{
+		ttl:        time.Duration(cfg.IdempotencyTTL) * time.Second,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*idempotencyEntry),
+	}
+}
+
+// Begin claims key for a request identified by fingerprint. It returns the stored result when
+// the same request already completed within the TTL, or nil when the caller should run it.
+func (s *IdempotencyStore) Begin(key, fingerprint string) (*IdempotentResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.pruneLocked(now)
+
+	if entry, exists := s.entries[key]; exists {
+		if entry.fingerprint != fingerprint {
+			return nil, ErrIdempotencyMismatch
+		}
+		if entry.result == nil {
+			return nil, ErrIdempotencyInProgress
+		}
+		return entry.result, nil
+	}
+
+	// Evict the oldest keys to stay within the bound, never dropping running requests
+	for len(s.entries) >= s.maxEntries {
+		if !s.evictOldestLocked() {
+			break
+		}
+	}
+
+	s.entries[key] = &idempotencyEntry{fingerprint: fingerprint, expires: now.Add(s.ttl)}
+	s.order = append(s.order, key)
+	return nil, nil
+}
+
+// Complete stores the result of a request claimed with Begin
+func (s *IdempotencyStore) Complete(key string, status int, body interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, exists := s.entries[key]; exists {
+		entry.result = &IdempotentResult{Status: status, Body: body}
+		entry.expires = time.Now().Add(s.ttl)
+	}
+}
+
+// Release forgets a claimed key whose request did not complete, so a retry runs again
+func (s *IdempotencyStore) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, exists := s.entries[key]; exists && entry.result == nil {
+		s.removeLocked(key)
+	}
+}
+
+// pruneLocked drops expired results. Callers must hold mu.
+func (s *IdempotencyStore) pruneLocked(now time.Time) {
+	for key, entry := range s.entries {
+		if entry.result != nil && now.After(entry.expires) {
+			s.removeLocked(key)
+		}
+	}
+}
+
+// evictOldestLocked removes the oldest completed entry and reports whether one was removed.
+// Callers must hold mu.
+func (s *IdempotencyStore) evictOldestLocked() bool {
+	for _, key := range s.order {
+		if s.entries[key].result != nil {
+			s.removeLocked(key)
+			return true
+		}
+	}
+	return false
+}
+
+// removeLocked deletes key from the map and the insertion order. Callers must hold mu.
+func (s *IdempotencyStore) removeLocked(key string) {
+	delete(s.entries, key)
+	for i, k := range s.order {
+		if k == key {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/config"
+)
+
+// Job states
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// Errors returned by JobManager
+var (
+	ErrJobQueueFull = errors.New("job queue is full, please retry later")
+	ErrJobNotFound  = errors.New("job not found")
+	ErrJobFinished  = errors.New("job has already finished")
+)
+
+// JobFunc does the work of a job and returns its result. It should stop when ctx is cancelled.
+type JobFunc func(ctx context.Context) (interface{}, error)
+
+// Job is the state of a submitted job as reported to clients
+type Job struct {
+	ID          string      `json:"id"`
+	Type        string      `json:"type"`
+	DocumentID  string      `json:"document_id"`
+	SubmittedBy string      `json:"submitted_by,omitempty"` // User who submitted the job, empty for anonymous callers
+	Status      string      `json:"status"`
+	Result      interface{} `json:"result,omitempty"`
+	Error       string      `json:"error,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	StartedAt   *time.Time  `json:"started_at,omitempty"`
+	FinishedAt  *time.Time  `json:"finished_at,omitempty"`
+}
+
+// jobEntry is a job with what the manager needs to run and cancel it
+type jobEntry struct {
+	job    Job
+	run    JobFunc
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// JobManager runs submitted jobs on a fixed pool of workers. Jobs wait in a bounded queue and
+// finished jobs are kept for the retention period so their outcome can be polled.
+type JobManager struct {
+	queue     chan *jobEntry
+	retention time.Duration
+
+	mu     sync.Mutex
+	jobs   map[string]*jobEntry
+	nextID int64
+}
+
+func NewJobManager(cfg *config.Config) *JobManager {
+	workers := cfg.JobWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	m := &JobManager{
+		queue:     make(chan *jobEntry, max(cfg.JobQueueDepth, 1)),
+		retention: time.Duration(cfg.JobRetention) * time.Second,
+		jobs:      make(map[string]*jobEntry),
+	}
+	for i := 0; i < workers; i++ {
+		go m.worker()
+	}
+	return m
+}
+
+// Submit queues a job on behalf of submitter and returns its initial state
+func (m *JobManager) Submit(jobType, documentID, submitter string, run JobFunc) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pruneLocked()
+
+	m.nextID++
+	ctx, cancel := context.WithCancel(context.Background())
+	entry := &jobEntry{
+		job: Job{
+			ID:          fmt.Sprintf("job_%d_%d", time.Now().Unix(), m.nextID),
+			Type:        jobType,
+			DocumentID:  documentID,
+			SubmittedBy: submitter,
+			Status:      JobQueued,
+			CreatedAt:   time.Now(),
+		},
+		run:    run,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	select {
+	case m.queue <- entry:
+	default:
+		cancel()
+		return Job{}, ErrJobQueueFull
+	}
+
+	m.jobs[entry.job.ID] = entry
+	log.Printf("📥 Queued %s job %s for document %s", jobType, entry.job.ID, documentID)
+	return entry.job, nil
+}
+
+// Get returns the current state of a job
+func (m *JobManager) Get(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return entry.job, nil
+}
+
+// Cancel stops a job. A queued job is cancelled right away, a running one once its work notices
+// the cancelled context.
+func (m *JobManager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+
+	switch entry.job.Status {
+	case JobQueued:
+		m.finishLocked(entry, JobCancelled, nil, context.Canceled)
+	case JobRunning:
+		log.Printf("⏹️ Cancelling running job %s", id)
+	default:
+		return entry.job, ErrJobFinished
+	}
+	entry.cancel()
+	return entry.job, nil
+}
+
+// Stats counts the known jobs by status
+func (m *JobManager) Stats() map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := map[string]int{JobQueued: 0, JobRunning: 0, JobSucceeded: 0, JobFailed: 0, JobCancelled: 0}
+	for _, entry := range m.jobs {
+		stats[entry.job.Status]++
+	}
+	return stats
+}
+
+func (m *JobManager) worker() {
+	for entry := range m.queue {
+		m.mu.Lock()
+		if entry.job.Status != JobQueued { // Cancelled while waiting
+			m.mu.Unlock()
+			continue
+		}
+		started := time.Now()
+		entry.job.Status = JobRunning
+		entry.job.StartedAt = &started
+		m.mu.Unlock()
+
+		result, err := entry.run(entry.ctx)
+
+		m.mu.Lock()
+		switch {
+		case err == nil:
+			m.finishLocked(entry, JobSucceeded, result, nil)
+		case entry.ctx.Err() != nil:
+			m.finishLocked(entry, JobCancelled, nil, entry.ctx.Err())
+		default:
+			m.finishLocked(entry, JobFailed, nil, err)
+		}
+		m.mu.Unlock()
+		entry.cancel() // Release the context
+	}
+}
+
+// finishLocked records the outcome of a job, the caller holds mu
+func (m *JobManager) finishLocked(entry *jobEntry, status string, result interface{}, err error) {
+	finished := time.Now()
+	entry.job.Status = status
+	entry.job.Result = result
+	entry.job.FinishedAt = &finished
+	if err != nil {
+		entry.job.Error = err.Error()
+		log.Printf("❌ Job %s %s: %v", entry.job.ID, status, err)
+	} else {
+		log.Printf("✅ Job %s %s", entry.job.ID, status)
+	}
+}
+
+// pruneLocked forgets finished jobs older than the retention period, the caller holds mu
+func (m *JobManager) pruneLocked() {
+	for id, entry := range m.jobs {
+		if entry.job.FinishedAt != nil && time.Since(*entry.job.FinishedAt) > m.retention {
+			delete(m.jobs, id)
+		}
+	}
+}
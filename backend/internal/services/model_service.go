@@ -2,19 +2,19 @@
 package services
 
 import (
-	"bytes"
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/1DeliDolu/ki-ai-go/internal/config"
+	"github.com/1DeliDolu/ki-ai-go/internal/utils"
 	"github.com/1DeliDolu/ki-ai-go/pkg/types"
 )
 
@@ -26,12 +26,15 @@ type ModelService struct {
 }
 
 func NewModelService(cfg *config.Config, db *sql.DB) *ModelService {
-	return &ModelService{
+	s := &ModelService{
 		config:        cfg,
 		db:            db,
-		ollamaService: NewOllamaService(),
+		ollamaService: NewOllamaService(cfg),
 		currentModel:  "",
 	}
+
+	s.ValidateModelDefinitions()
+	return s
 }
 
 func (s *ModelService) ListModels() ([]*types.Model, error) {
@@ -44,6 +47,71 @@ func (s *ModelService) ListModels() ([]*types.Model, error) {
 	return models, nil
 }
 
+// ModelListOptions filters and sorts a model list. Empty fields are ignored.
+type ModelListOptions struct {
+	SortBy       string // name, size or modified
+	Order        string // asc (default) or desc
+	Family       string
+	Type         string // Matches the chat/embedding type or the model type
+	Quantization string
+}
+
+// ListModelsWithOptions returns the models matching the filters in the requested order
+func (s *ModelService) ListModelsWithOptions(options ModelListOptions) ([]*types.Model, error) {
+	models, err := s.ListModels()
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []*types.Model{}
+	for _, model := range models {
+		if options.Family != "" && !strings.EqualFold(model.Family, options.Family) {
+			continue
+		}
+		if options.Type != "" && !strings.EqualFold(model.Type, options.Type) && !strings.EqualFold(model.ModelType, options.Type) {
+			continue
+		}
+		if options.Quantization != "" && !strings.EqualFold(model.Quantization, options.Quantization) {
+			continue
+		}
+		filtered = append(filtered, model)
+	}
+
+	var less func(a, b *types.Model) bool
+	switch options.SortBy {
+	case "":
+		return filtered, nil
+	case "name":
+		less = func(a, b *types.Model) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	case "size":
+		less = func(a, b *types.Model) bool { return modelSizeBytes(a) < modelSizeBytes(b) }
+	case "modified":
+		// UTC RFC 3339 timestamps sort lexically
+		less = func(a, b *types.Model) bool { return a.ModifiedAt < b.ModifiedAt }
+	default:
+		return nil, fmt.Errorf("invalid sort field: %s (use name, size or modified)", options.SortBy)
+	}
+
+	descending := strings.EqualFold(options.Order, "desc")
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if descending {
+			return less(filtered[j], filtered[i])
+		}
+		return less(filtered[i], filtered[j])
+	})
+
+	return filtered, nil
+}
+
+// modelSizeBytes parses a model's formatted size, treating unknown sizes as 0
+func modelSizeBytes(model *types.Model) int64 {
+	size, err := utils.ParseFileSize(model.Size)
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
 func (s *ModelService) LoadModel(modelName string) error {
 	log.Printf("🔄 Loading model: %s", modelName)
 
@@ -176,6 +244,71 @@ func (s *ModelService) getModelDefinitions() map[string]ModelInfo {
 	}
 }
 
+// ModelFileStatus reports whether a defined model has a matching file in the models directory
+type ModelFileStatus struct {
+	OllamaName  string `json:"ollama_name"`
+	DisplayName string `json:"display_name"`
+	Present     bool   `json:"present"`
+	Filename    string `json:"filename,omitempty"` // The file that matched, if any
+}
+
+// ValidateModelDefinitions checks every defined model against the models directory and logs an inventory
+func (s *ModelService) ValidateModelDefinitions() []ModelFileStatus {
+	existingFiles := make(map[string]os.FileInfo)
+	if files, err := os.ReadDir(s.config.ModelsPath); err != nil {
+		log.Printf("⚠️ Cannot read models directory %s: %v", s.config.ModelsPath, err)
+	} else {
+		for _, file := range files {
+			if !file.IsDir() && s.isModelFile(file.Name()) {
+				if info, err := file.Info(); err == nil {
+					existingFiles[file.Name()] = info
+				}
+			}
+		}
+	}
+
+	var inventory []ModelFileStatus
+	for _, info := range s.getModelDefinitions() {
+		status := ModelFileStatus{
+			OllamaName:  info.OllamaName,
+			DisplayName: info.DisplayName,
+		}
+
+		for _, filename := range append([]string{info.Filename}, info.AlternativeFilenames...) {
+			if _, ok := existingFiles[filename]; ok {
+				status.Filename = filename
+				break
+			}
+		}
+		if status.Filename == "" {
+			status.Filename = s.findModelFileByPattern(info.OllamaName, existingFiles)
+		}
+		status.Present = status.Filename != ""
+
+		inventory = append(inventory, status)
+	}
+
+	sort.Slice(inventory, func(i, j int) bool { return inventory[i].OllamaName < inventory[j].OllamaName })
+
+	present := 0
+	for _, status := range inventory {
+		if status.Present {
+			present++
+		}
+	}
+
+	log.Printf("📊 Model inventory: %d of %d defined models have a file in %s", present, len(inventory), s.config.ModelsPath)
+	for _, status := range inventory {
+		if status.Present {
+			log.Printf("   ✅ %s (%s): %s", status.OllamaName, status.DisplayName, status.Filename)
+		} else {
+			log.Printf("   ❌ %s (%s): no matching file", status.OllamaName, status.DisplayName)
+		}
+	}
+
+	return inventory
+}
+
 func (s *ModelService) ValidateModelName(name string) error {
 	if strings.TrimSpace(name) == "" {
 		return fmt.Errorf("model name cannot be empty")
@@ -338,6 +471,11 @@ func (s *ModelService) GetModelInfo(name string) (*ModelInfo, error) {
 	return nil, fmt.Errorf("model not found: %s", name)
 }
 
+// GetModelDetails returns the template, system prompt and parameters Ollama has configured for a model
+func (s *ModelService) GetModelDetails(name string) (*ModelDetails, error) {
+	return s.ollamaService.ShowModel(name)
+}
+
 // GetAvailableModelTypes returns all available model types
 func (s *ModelService) GetAvailableModelTypes() []string {
 	return []string{
@@ -350,6 +488,26 @@ func (s *ModelService) GetAvailableModelTypes() []string {
 	}
 }
 
+// GetModelCapabilities reports, per available model, which task types it supports
+func (s *ModelService) GetModelCapabilities() (map[string][]string, error) {
+	models, err := s.ListModels()
+	if err != nil {
+		return nil, err
+	}
+
+	capabilities := make(map[string][]string)
+	for _, model := range models {
+		caps, err := s.ollamaService.ShowModelCapabilities(model.Name)
+		if err != nil {
+			// Ollama may be unreachable - fall back to what the listing inferred
+			caps = model.Capabilities
+		}
+		capabilities[model.Name] = caps
+	}
+
+	return capabilities, nil
+}
+
 // GetModelsByType returns models filtered by type
 func (s *ModelService) GetModelsByType(modelType string) ([]*types.Model, error) {
 	allModels, err := s.ListModels()
@@ -428,9 +586,9 @@ func (s *ModelService) DownloadModel(name, url string) error {
 		return fmt.Errorf("failed to create models directory: %w", err)
 	}
 
-	// Create HTTP client with timeout
+	// Create HTTP client with the configured download timeout
 	client := &http.Client{
-		Timeout: 30 * time.Minute, // 30 minutes for large model downloads
+		Timeout: time.Duration(s.config.ModelDownloadTimeout) * time.Second,
 	}
 
 	// Download the model file
@@ -460,7 +618,7 @@ func (s *ModelService) DownloadModel(name, url string) error {
 		return fmt.Errorf("failed to save model file: %w", err)
 	}
 
-	log.Printf("Successfully downloaded %s (%s)", name, s.formatFileSize(written))
+	log.Printf("Successfully downloaded %s (%s)", name, utils.FormatFileSize(written))
 	return nil
 }
 
@@ -588,25 +746,6 @@ func (s *ModelService) generateSearchPatterns(modelName string) []string {
 	return patterns
 }
 
-func (s *ModelService) formatFileSize(size int64) string {
-	const (
-		KB = 1024
-		MB = KB * 1024
-		GB = MB * 1024
-	)
-
-	switch {
-	case size >= GB:
-		return fmt.Sprintf("%.1f GB", float64(size)/GB)
-	case size >= MB:
-		return fmt.Sprintf("%.1f MB", float64(size)/MB)
-	case size >= KB:
-		return fmt.Sprintf("%.1f KB", float64(size)/KB)
-	default:
-		return fmt.Sprintf("%d B", size)
-	}
-}
-
 func (s *ModelService) isModelFile(filename string) bool {
 	validExtensions := []string{".gguf", ".bin", ".ggml"}
 	lower := strings.ToLower(filename)
@@ -647,25 +786,8 @@ func (s *ModelService) pullAndLoadModel(modelName string) error {
 
 // tryPullModel attempts to pull a specific model
 func (s *ModelService) tryPullModel(modelName string) error {
-	// Create a pull request
-	reqBody := map[string]interface{}{
-		"name": modelName,
-	}
-
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("failed to marshal pull request: %w", err)
-	}
-
-	client := &http.Client{Timeout: 5 * time.Minute}
-	resp, err := client.Post(s.config.OllamaURL+"/api/pull", "application/json", bytes.NewReader(jsonBody))
-	if err != nil {
-		return fmt.Errorf("failed to connect to Ollama: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to pull model: HTTP %d", resp.StatusCode)
+	if err := s.ollamaService.PullModel(modelName); err != nil {
+		return err
 	}
 
 	log.Printf("✅ Successfully pulled model: %s", modelName)
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// ErrInvalidOllamaOptions is returned for generation options outside the allowlist or their range
+var ErrInvalidOllamaOptions = errors.New("invalid generation options")
+
+// ollamaOption describes a generation option callers may set
+type ollamaOption struct {
+	kind     string // float, int, bool or stop
+	min, max float64
+}
+
+// ollamaOptions is the allowlist of Ollama generation options accepted from requests. Options
+// affecting the server's resources, such as num_gpu, num_thread or use_mlock, are left out.
+var ollamaOptions = map[string]ollamaOption{
+	"temperature":       {kind: "float", min: 0, max: 2},
+	"top_p":             {kind: "float", min: 0, max: 1},
+	"top_k":             {kind: "int", min: 0, max: 1000},
+	"min_p":             {kind: "float", min: 0, max: 1},
+	"typical_p":         {kind: "float", min: 0, max: 1},
+	"tfs_z":             {kind: "float", min: 0, max: 10},
+	"repeat_penalty":    {kind: "float", min: 0, max: 10},
+	"repeat_last_n":     {kind: "int", min: -1, max: 4096},
+	"presence_penalty":  {kind: "float", min: -2, max: 2},
+	"frequency_penalty": {kind: "float", min: -2, max: 2},
+	"penalize_newline":  {kind: "bool"},
+	"mirostat":          {kind: "int", min: 0, max: 2},
+	"mirostat_tau":      {kind: "float", min: 0, max: 20},
+	"mirostat_eta":      {kind: "float", min: 0, max: 1},
+	"seed":              {kind: "int", min: math.MinInt32, max: math.MaxInt32},
+	"num_predict":       {kind: "int", min: -2, max: 8192},
+	"num_ctx":           {kind: "int", min: 128, max: 32768},
+	"stop":              {kind: "stop"},
+}
+
+// maxStopSequences limits the stop option
+const maxStopSequences = 8
+
+// ValidateOllamaOptions checks request generation options against the allowlist
+func ValidateOllamaOptions(options map[string]interface{}) error {
+	for key, value := range options {
+		option, ok := ollamaOptions[key]
+		if !ok {
+			return fmt.Errorf("%w: unsupported option %q, allowed: %s", ErrInvalidOllamaOptions, key, strings.Join(AllowedOllamaOptions(), ", "))
+		}
+		if err := option.validate(value); err != nil {
+			return fmt.Errorf("%w: %s %v", ErrInvalidOllamaOptions, key, err)
+		}
+	}
+	return nil
+}
+
+// GenerationOptions combines a query's generation parameters with its Ollama options and
+// validates the result
+func GenerationOptions(req types.QueryRequest) (map[string]interface{}, error) {
+	options := maps.Clone(req.Options)
+	set := func(key string, value float64) {
+		if options == nil {
+			options = make(map[string]interface{})
+		}
+		options[key] = value // As a JSON number, like the other options
+	}
+	if req.Temperature != nil {
+		set("temperature", *req.Temperature)
+	}
+	if req.TopP != nil {
+		set("top_p", *req.TopP)
+	}
+	if req.TopK != nil {
+		set("top_k", float64(*req.TopK))
+	}
+	if req.MaxTokens != nil {
+		set("num_predict", float64(*req.MaxTokens))
+	}
+
+	if err := ValidateOllamaOptions(options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// AllowedOllamaOptions lists the generation options accepted from requests
+func AllowedOllamaOptions() []string {
+	keys := make([]string, 0, len(ollamaOptions))
+	for key := range ollamaOptions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (o ollamaOption) validate(value interface{}) error {
+	switch o.kind {
+	case "bool":
+		if _, ok := value.(bool); !ok {
+			return errors.New("must be true or false")
+		}
+		return nil
+	case "stop":
+		values, ok := value.([]interface{})
+		if !ok || len(values) > maxStopSequences {
+			return fmt.Errorf("must be a list of at most %d strings", maxStopSequences)
+		}
+		for _, v := range values {
+			if s, ok := v.(string); !ok || s == "" {
+				return errors.New("must only contain non-empty strings")
+			}
+		}
+		return nil
+	}
+
+	n, ok := value.(float64) // JSON numbers
+	if !ok || math.IsNaN(n) {
+		return errors.New("must be a number")
+	}
+	if o.kind == "int" && n != math.Trunc(n) {
+		return errors.New("must be a whole number")
+	}
+	if n < o.min || n > o.max {
+		return fmt.Errorf("must be between %g and %g", o.min, o.max)
+	}
+	return nil
+}
+
+// mergeOllamaOptions returns the defaults overridden by the request options
+func mergeOllamaOptions(defaults, overrides map[string]interface{}) map[string]interface{} {
+	merged := maps.Clone(defaults)
+	maps.Copy(merged, overrides)
+	return merged
+}
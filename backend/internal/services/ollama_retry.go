@@ -0,0 +1,84 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/config"
+)
+
+// maxOllamaBackoff caps the delay between two attempts
+const maxOllamaBackoff = 10 * time.Second
+
+// ollamaRetry retries Ollama POST requests that fail while Ollama is busy or restarting:
+// connection errors and 5xx responses. 4xx responses and client timeouts are returned right away.
+type ollamaRetry struct {
+	attempts int
+	backoff  time.Duration // Before the second attempt, doubled for each further one
+}
+
+func newOllamaRetry(cfg *config.Config) ollamaRetry {
+	return ollamaRetry{
+		attempts: max(cfg.OllamaMaxAttempts, 1),
+		backoff:  time.Duration(cfg.OllamaRetryBackoff) * time.Millisecond,
+	}
+}
+
+// post sends body to url as JSON. The response of the last attempt is returned, which may still be
+// a 5xx. Cancelling ctx aborts the request and any further attempts.
+func (r ollamaRetry) post(ctx context.Context, client *http.Client, url string, body []byte) (*http.Response, error) {
+	delay := r.backoff
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		switch {
+		case err != nil && (ctx.Err() != nil || isTimeout(err)):
+			return nil, err
+		case err == nil && resp.StatusCode < http.StatusInternalServerError:
+			return resp, nil
+		case attempt >= r.attempts:
+			return resp, err
+		}
+
+		reason := err
+		if resp != nil {
+			reason = fmt.Errorf("HTTP %d", resp.StatusCode)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		// Jitter keeps queued requests from hitting a restarted Ollama at the same moment
+		wait := delay
+		if jitter := delay / 2; jitter > 0 {
+			wait += rand.N(jitter)
+		}
+		log.Printf("⚠️ Ollama request to %s failed (attempt %d/%d), retrying in %v: %v",
+			url, attempt, r.attempts, wait.Round(time.Millisecond), reason)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		delay = min(delay*2, maxOllamaBackoff)
+	}
+}
+
+// isTimeout reports whether err is a client timeout, retrying one would multiply the wait
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
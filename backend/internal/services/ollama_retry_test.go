@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOllamaRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int         // Responses in order, the last one repeats
+		delay    time.Duration // Before each response
+		timeout  time.Duration // Client timeout, 0 is none
+		wantHits int32
+		wantCode int // 0 when an error is expected
+	}{
+		{"success", []int{http.StatusOK}, 0, 0, 1, http.StatusOK},
+		{"5xx then success", []int{http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusOK}, 0, 0, 3, http.StatusOK},
+		{"5xx on every attempt", []int{http.StatusBadGateway}, 0, 0, 3, http.StatusBadGateway},
+		{"4xx not retried", []int{http.StatusNotFound}, 0, 0, 1, http.StatusNotFound},
+		{"timeout not retried", []int{http.StatusOK}, 200 * time.Millisecond, 20 * time.Millisecond, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(atomic.AddInt32(&hits, 1))
+				time.Sleep(tt.delay)
+				w.WriteHeader(tt.statuses[min(n, len(tt.statuses))-1])
+			}))
+			defer server.Close()
+
+			retry := ollamaRetry{attempts: 3, backoff: time.Millisecond}
+			client := &http.Client{Timeout: tt.timeout}
+			resp, err := retry.post(context.Background(), client, server.URL, []byte(`{}`))
+
+			if tt.wantCode == 0 {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatalf("got HTTP %d, want an error", resp.StatusCode)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("post: %v", err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != tt.wantCode {
+					t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+				}
+			}
+			if got := atomic.LoadInt32(&hits); got != tt.wantHits {
+				t.Errorf("attempts = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestOllamaRetryStopsWhenCancelled(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// The backoff outlasts the context, so cancellation must cut the wait short
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	retry := ollamaRetry{attempts: 5, backoff: 5 * time.Second}
+
+	start := time.Now()
+	_, err := retry.post(ctx, http.DefaultClient, server.URL, []byte(`{}`))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want the context's deadline", err)
+	}
+	if waited := time.Since(start); waited > 2*time.Second {
+		t.Errorf("post waited %v after the context ended", waited)
+	}
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
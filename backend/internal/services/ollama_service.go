@@ -1,32 +1,54 @@
 package services
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/1DeliDolu/ki-ai-go/internal/config"
+	"github.com/1DeliDolu/ki-ai-go/internal/utils"
 	"github.com/1DeliDolu/ki-ai-go/pkg/types"
 )
 
 // OllamaService handles communication with Ollama API
 type OllamaService struct {
-	client  *http.Client
-	baseURL string
+	client         *http.Client // Short timeout for pings and listing
+	generateClient *http.Client
+	pullClient     *http.Client
+	baseURL        string
+	retry          ollamaRetry
 }
 
-func NewOllamaService() *OllamaService {
+func NewOllamaService(cfg *config.Config) *OllamaService {
 	return &OllamaService{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		baseURL: "http://localhost:11434", // Default Ollama URL
+		client:         &http.Client{Timeout: time.Duration(cfg.OllamaPingTimeout) * time.Second},
+		generateClient: &http.Client{Timeout: time.Duration(cfg.OllamaGenerateTimeout) * time.Second},
+		pullClient:     &http.Client{Timeout: time.Duration(cfg.OllamaPullTimeout) * time.Second},
+		baseURL:        cfg.OllamaURL,
+		retry:          newOllamaRetry(cfg),
 	}
 }
 
+// Ping checks that the Ollama server is reachable
+func (s *OllamaService) Ping() error {
+	resp, err := s.client.Get(s.baseURL + "/api/tags")
+	if err != nil {
+		return fmt.Errorf("failed to connect to Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Ollama API error: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
 func (s *OllamaService) ListModels() ([]*types.Model, error) {
 	log.Printf("🔄 Fetching models from Ollama...")
 
@@ -73,14 +95,18 @@ func (s *OllamaService) ListModels() ([]*types.Model, error) {
 		}
 
 		models = append(models, &types.Model{
-			ID:          name,
-			Name:        name,
-			Size:        s.formatBytes(model.Size),
-			Type:        "chat",
-			Status:      "available",
-			Description: fmt.Sprintf("Ollama model: %s (%s)", name, model.Details.Family),
-			ModelType:   "ollama",
-			URL:         fmt.Sprintf("ollama://%s", model.Name),
+			ID:           name,
+			Name:         name,
+			Size:         utils.FormatFileSize(model.Size),
+			Type:         "chat",
+			Status:       "available",
+			Description:  fmt.Sprintf("Ollama model: %s (%s)", name, model.Details.Family),
+			ModelType:    "ollama",
+			URL:          fmt.Sprintf("ollama://%s", model.Name),
+			Capabilities: inferCapabilities(model.Name, append([]string{model.Details.Family}, model.Details.Families...)),
+			Family:       model.Details.Family,
+			Quantization: model.Details.QuantizationLevel,
+			ModifiedAt:   formatModifiedAt(model.ModifiedAt),
 		})
 	}
 
@@ -93,9 +119,231 @@ func (s *OllamaService) ListModels() ([]*types.Model, error) {
 	return models, nil
 }
 
+// ShowModelCapabilities asks Ollama which capabilities a model reports and maps them to our task types
+func (s *OllamaService) ShowModelCapabilities(modelName string) ([]string, error) {
+	jsonBody, err := json.Marshal(map[string]string{"name": modelName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := s.retry.post(context.Background(), s.client, s.baseURL+"/api/show", jsonBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Ollama API error: HTTP %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Capabilities []string `json:"capabilities"`
+		Details      struct {
+			Family   string   `json:"family"`
+			Families []string `json:"families"`
+		} `json:"details"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// Older Ollama versions don't report capabilities, so infer them from the model family
+	if len(response.Capabilities) == 0 {
+		return inferCapabilities(modelName, append([]string{response.Details.Family}, response.Details.Families...)), nil
+	}
+
+	var capabilities []string
+	for _, capability := range response.Capabilities {
+		switch capability {
+		case "completion":
+			capabilities = append(capabilities, "chat", "completion")
+		case "embedding", "vision", "audio":
+			capabilities = append(capabilities, capability)
+		}
+	}
+
+	if isCodeModel(modelName) {
+		capabilities = append(capabilities, "code")
+	}
+
+	return capabilities, nil
+}
+
+// ModelDetails is what Ollama has configured for a model, as reported by /api/show
+type ModelDetails struct {
+	Name          string              `json:"name"`
+	Template      string              `json:"template"`
+	System        string              `json:"system,omitempty"`
+	Parameters    map[string][]string `json:"parameters,omitempty"` // e.g. num_ctx, stop; a key can repeat
+	Modelfile     string              `json:"modelfile"`
+	License       string              `json:"license,omitempty"`
+	Family        string              `json:"family,omitempty"`
+	Format        string              `json:"format,omitempty"`
+	ParameterSize string              `json:"parameter_size,omitempty"`
+	Quantization  string              `json:"quantization,omitempty"`
+	Capabilities  []string            `json:"capabilities,omitempty"`
+	ModifiedAt    string              `json:"modified_at,omitempty"` // RFC 3339
+}
+
+// ErrModelNotFound is returned when Ollama doesn't know the requested model
+var ErrModelNotFound = errors.New("model not found in Ollama")
+
+// ShowModel returns the template, system prompt, parameters and modelfile Ollama uses for a model
+func (s *OllamaService) ShowModel(modelName string) (*ModelDetails, error) {
+	jsonBody, err := json.Marshal(map[string]string{"name": modelName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := s.retry.post(context.Background(), s.client, s.baseURL+"/api/show", jsonBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, modelName)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Ollama API error: HTTP %d", resp.StatusCode)
+	}
+
+	var response struct {
+		License      string    `json:"license"`
+		Modelfile    string    `json:"modelfile"`
+		Parameters   string    `json:"parameters"`
+		Template     string    `json:"template"`
+		System       string    `json:"system"`
+		Capabilities []string  `json:"capabilities"`
+		ModifiedAt   time.Time `json:"modified_at"`
+		Details      struct {
+			Format            string `json:"format"`
+			Family            string `json:"family"`
+			ParameterSize     string `json:"parameter_size"`
+			QuantizationLevel string `json:"quantization_level"`
+		} `json:"details"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	details := &ModelDetails{
+		Name:          modelName,
+		Template:      response.Template,
+		System:        response.System,
+		Parameters:    parseModelParameters(response.Parameters),
+		Modelfile:     response.Modelfile,
+		License:       response.License,
+		Family:        response.Details.Family,
+		Format:        response.Details.Format,
+		ParameterSize: response.Details.ParameterSize,
+		Quantization:  response.Details.QuantizationLevel,
+		Capabilities:  response.Capabilities,
+	}
+	// Older Ollama versions only report the system prompt inside the modelfile
+	if details.System == "" {
+		details.System = modelfileSystem(response.Modelfile)
+	}
+	details.ModifiedAt = formatModifiedAt(response.ModifiedAt)
+
+	return details, nil
+}
+
+// formatModifiedAt renders Ollama's modified time as RFC 3339 in UTC, or "" when it is unset
+func formatModifiedAt(modifiedAt time.Time) string {
+	if modifiedAt.IsZero() {
+		return ""
+	}
+	return modifiedAt.UTC().Format(time.RFC3339)
+}
+
+// parseModelParameters splits Ollama's "name value" parameter lines, unquoting the values
+func parseModelParameters(parameters string) map[string][]string {
+	parsed := make(map[string][]string)
+	for _, line := range strings.Split(parameters, "\n") {
+		name, value, found := strings.Cut(strings.TrimSpace(line), " ")
+		if !found || name == "" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		parsed[name] = append(parsed[name], value)
+	}
+	if len(parsed) == 0 {
+		return nil
+	}
+	return parsed
+}
+
+// modelfileSystem returns the SYSTEM instruction of a modelfile, which may be a """ block
+func modelfileSystem(modelfile string) string {
+	index := strings.Index(modelfile, "\nSYSTEM ")
+	if index < 0 {
+		if !strings.HasPrefix(modelfile, "SYSTEM ") {
+			return ""
+		}
+		index = -1
+	}
+	rest := strings.TrimSpace(modelfile[index+len("\nSYSTEM "):])
+
+	if strings.HasPrefix(rest, `"""`) {
+		rest = rest[3:]
+		if end := strings.Index(rest, `"""`); end >= 0 {
+			return strings.TrimSpace(rest[:end])
+		}
+		return strings.TrimSpace(rest)
+	}
+
+	line, _, _ := strings.Cut(rest, "\n")
+	if unquoted, err := strconv.Unquote(strings.TrimSpace(line)); err == nil {
+		return unquoted
+	}
+	return strings.TrimSpace(line)
+}
+
+// inferCapabilities guesses task types from the model name and families reported by /api/tags
+func inferCapabilities(modelName string, families []string) []string {
+	lowerName := strings.ToLower(modelName)
+
+	for _, family := range families {
+		family = strings.ToLower(family)
+		if strings.Contains(family, "bert") || strings.Contains(lowerName, "embed") {
+			return []string{"embedding"}
+		}
+	}
+
+	capabilities := []string{"chat", "completion"}
+
+	isVision := strings.Contains(lowerName, "llava") || strings.Contains(lowerName, "vision")
+	for _, family := range families {
+		family = strings.ToLower(family)
+		if family == "clip" || family == "mllama" {
+			isVision = true
+		}
+	}
+	if isVision {
+		capabilities = append(capabilities, "vision")
+	}
+
+	if isCodeModel(modelName) {
+		capabilities = append(capabilities, "code")
+	}
+
+	return capabilities
+}
+
+func isCodeModel(modelName string) bool {
+	lowerName := strings.ToLower(modelName)
+	return strings.Contains(lowerName, "code") || strings.Contains(lowerName, "coder") || strings.Contains(lowerName, "starcoder")
+}
+
 // getFallbackModels returns a list of common models when Ollama is not available
 func (s *OllamaService) getFallbackModels() []*types.Model {
-	return []*types.Model{
+	models := []*types.Model{
 		{
 			ID:          "llama2",
 			Name:        "llama2",
@@ -137,6 +385,11 @@ func (s *OllamaService) getFallbackModels() []*types.Model {
 			URL:         "ollama://codellama",
 		},
 	}
+
+	for _, model := range models {
+		model.Capabilities = inferCapabilities(model.Name, nil)
+	}
+	return models
 }
 
 func (s *OllamaService) LoadModel(modelName string) error {
@@ -160,7 +413,7 @@ func (s *OllamaService) LoadModel(modelName string) error {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	resp, err := s.client.Post(s.baseURL+"/api/generate", "application/json", bytes.NewReader(jsonBody))
+	resp, err := s.retry.post(context.Background(), s.generateClient, s.baseURL+"/api/generate", jsonBody)
 	if err != nil {
 		return fmt.Errorf("failed to connect to Ollama: %w", err)
 	}
@@ -195,7 +448,7 @@ func (s *OllamaService) GenerateText(prompt, modelName string) (string, error) {
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	resp, err := s.client.Post(s.baseURL+"/api/generate", "application/json", bytes.NewReader(jsonBody))
+	resp, err := s.retry.post(context.Background(), s.generateClient, s.baseURL+"/api/generate", jsonBody)
 	if err != nil {
 		return "", fmt.Errorf("failed to connect to Ollama: %w", err)
 	}
@@ -208,29 +461,76 @@ func (s *OllamaService) GenerateText(prompt, modelName string) (string, error) {
 	var response struct {
 		Response string `json:"response"`
 		Done     bool   `json:"done"`
+		Error    string `json:"error"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
 		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
+	if response.Error != "" {
+		return "", fmt.Errorf("Ollama generation failed: %s", response.Error)
+	}
 
 	return response.Response, nil
 }
 
-func (s *OllamaService) CreateModel(model *types.Model) error {
-	// For now, just return nil as Ollama manages its own models
+// PullModel downloads a model into Ollama from its registry
+func (s *OllamaService) PullModel(modelName string) error {
+	jsonBody, err := json.Marshal(map[string]interface{}{
+		"name":   modelName,
+		"stream": false,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal pull request: %w", err)
+	}
+
+	resp, err := s.retry.post(context.Background(), s.pullClient, s.baseURL+"/api/pull", jsonBody)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to pull model: HTTP %d", resp.StatusCode)
+	}
+
 	return nil
 }
 
-func (s *OllamaService) formatBytes(bytes int64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
+// Embed returns the embedding vector of text computed by an embedding model
+func (s *OllamaService) Embed(modelName, text string) ([]float64, error) {
+	jsonBody, err := json.Marshal(map[string]interface{}{
+		"model":  modelName,
+		"prompt": text,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
 	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
+
+	resp, err := s.retry.post(context.Background(), s.generateClient, s.baseURL+"/api/embeddings", jsonBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ollama: %w", err)
 	}
-	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Ollama API error: HTTP %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Embedding []float64 `json:"embedding"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding: %w", err)
+	}
+	if len(response.Embedding) == 0 {
+		return nil, fmt.Errorf("model %s returned an empty embedding", modelName)
+	}
+
+	return response.Embedding, nil
+}
+
+func (s *OllamaService) CreateModel(model *types.Model) error {
+	// For now, just return nil as Ollama manages its own models
+	return nil
 }
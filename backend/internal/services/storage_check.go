@@ -0,0 +1,65 @@
+package services
+
+import (
+	"fmt"
+	"os"
+)
+
+// StorageCheck reports whether a data directory can be written
+type StorageCheck struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Writable bool   `json:"writable"`
+	Error    string `json:"error,omitempty"`
+}
+
+// CheckStorage verifies that the models, uploads and test documents directories exist and are
+// writable by creating and deleting a probe file in each
+func (s *DocumentService) CheckStorage() []StorageCheck {
+	directories := []struct{ name, path string }{
+		{"models", s.config.ModelsPath},
+		{"uploads", s.config.UploadsPath},
+		{"test_documents", s.config.TestDocumentsPath},
+	}
+
+	checks := make([]StorageCheck, 0, len(directories))
+	for _, dir := range directories {
+		check := StorageCheck{Name: dir.name, Path: dir.path}
+		if err := probeWritable(dir.path); err != nil {
+			check.Error = err.Error()
+		} else {
+			check.Writable = true
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// probeWritable writes and deletes a file in dir
+func probeWritable(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("directory is missing: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	probe, err := os.CreateTemp(dir, ".write-probe-*")
+	if err != nil {
+		return fmt.Errorf("directory is not writable: %w", err)
+	}
+	_, writeErr := probe.Write([]byte("probe"))
+	closeErr := probe.Close()
+	removeErr := os.Remove(probe.Name())
+
+	switch {
+	case writeErr != nil:
+		return fmt.Errorf("directory is not writable: %w", writeErr)
+	case closeErr != nil:
+		return fmt.Errorf("directory is not writable: %w", closeErr)
+	case removeErr != nil:
+		return fmt.Errorf("probe file can't be deleted: %w", removeErr)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/config"
+)
+
+// ErrMalwareDetected is returned when an uploaded file fails the malware scan
+var ErrMalwareDetected = errors.New("malware detected in uploaded file")
+
+// Scanner checks an uploaded file before it is stored
+type Scanner interface {
+	Scan(path string) (clean bool, err error)
+}
+
+// NewScanner returns the scanner selected by config, or a no-op scanner when scanning is disabled
+func NewScanner(cfg *config.Config) Scanner {
+	if !cfg.UploadScanEnabled {
+		return NoopScanner{}
+	}
+
+	return &ClamAVScanner{
+		network: cfg.ClamAVNetwork,
+		address: cfg.ClamAVAddress,
+		timeout: time.Duration(cfg.ClamAVTimeout) * time.Second,
+	}
+}
+
+// NoopScanner accepts every file
+type NoopScanner struct{}
+
+// Scan always reports the file as clean
+func (NoopScanner) Scan(path string) (bool, error) {
+	return true, nil
+}
+
+// ClamAVScanner streams files to a clamd daemon using the INSTREAM command
+type ClamAVScanner struct {
+	network string // "tcp" or "unix"
+	address string
+	timeout time.Duration
+}
+
+// clamdChunkSize stays well below clamd's default StreamMaxLength chunking limits
+const clamdChunkSize = 64 * 1024
+
+// Scan sends the file to clamd and reports whether it was found clean
+func (s *ClamAVScanner) Scan(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file for scanning: %w", err)
+	}
+	defer f.Close()
+
+	conn, err := net.DialTimeout(s.network, s.address, s.timeout)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	if s.timeout > 0 {
+		conn.SetDeadline(time.Now().Add(s.timeout))
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return false, fmt.Errorf("failed to start clamd stream: %w", err)
+	}
+
+	buf := make([]byte, clamdChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := f.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return false, fmt.Errorf("failed to stream file to clamd: %w", err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return false, fmt.Errorf("failed to stream file to clamd: %w", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return false, fmt.Errorf("failed to read file for scanning: %w", readErr)
+		}
+	}
+
+	// A zero-length chunk ends the stream
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return false, fmt.Errorf("failed to finish clamd stream: %w", err)
+	}
+
+	reply, err := io.ReadAll(conn)
+	if err != nil {
+		return false, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	result := strings.TrimSpace(string(bytes.TrimRight(reply, "\x00")))
+
+	switch {
+	case strings.HasSuffix(result, "OK"):
+		return true, nil
+	case strings.HasSuffix(result, "FOUND"):
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected clamd reply: %s", result)
+	}
+}
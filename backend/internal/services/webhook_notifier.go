@@ -0,0 +1,139 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/config"
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// WebhookNotifier delivers processing callbacks to caller-supplied URLs
+type WebhookNotifier struct {
+	client     *http.Client
+	secret     string
+	maxRetries int
+	backoff    time.Duration
+}
+
+func NewWebhookNotifier(cfg *config.Config) *WebhookNotifier {
+	dialer := &net.Dialer{}
+	if !cfg.WebhookAllowLocal {
+		dialer.Control = refuseLocalAddress
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // The dialer must see the address of the callback host itself
+	transport.DialContext = dialer.DialContext
+
+	return &WebhookNotifier{
+		client: &http.Client{
+			Timeout:   time.Duration(cfg.WebhookTimeout) * time.Second,
+			Transport: transport,
+		},
+		secret:     cfg.WebhookSecret,
+		maxRetries: cfg.WebhookMaxRetries,
+		backoff:    time.Duration(cfg.WebhookBackoff) * time.Second,
+	}
+}
+
+// ValidateCallbackURL checks that a callback URL is an absolute http(s) URL
+func ValidateCallbackURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid callback_url: %w", err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("callback_url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// refuseLocalAddress stops callbacks from reaching the server's own network. It runs after DNS
+// resolution for every connection, redirects included, so a public name pointing at an internal
+// address is refused as well.
+func refuseLocalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isLocalAddress(ip) {
+		return fmt.Errorf("callback to %s refused: local or private address", host)
+	}
+	return nil
+}
+
+// isLocalAddress reports whether ip is loopback, link-local (cloud metadata included), private or unspecified
+func isLocalAddress(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast()
+}
+
+// Sign returns the hex HMAC-SHA256 of body using the configured secret
+func (n *WebhookNotifier) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(n.secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notify posts the callback in the background, retrying with exponential backoff
+func (n *WebhookNotifier) Notify(callbackURL string, callback types.ProcessingCallback) {
+	body, err := json.Marshal(callback)
+	if err != nil {
+		log.Printf("⚠️ Failed to marshal callback for %s: %v", callback.DocumentID, err)
+		return
+	}
+
+	go func() {
+		delay := n.backoff
+		for attempt := 0; attempt <= n.maxRetries; attempt++ {
+			if attempt > 0 {
+				time.Sleep(delay)
+				delay *= 2
+			}
+
+			if err := n.deliver(callbackURL, body); err != nil {
+				log.Printf("⚠️ Callback for %s failed (attempt %d/%d): %v", callback.DocumentID, attempt+1, n.maxRetries+1, err)
+				continue
+			}
+
+			log.Printf("✅ Callback delivered for %s", callback.DocumentID)
+			return
+		}
+
+		log.Printf("❌ Giving up on callback for %s to %s", callback.DocumentID, callbackURL)
+	}()
+}
+
+// deliver performs a single signed POST
+func (n *WebhookNotifier) deliver(callbackURL string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.secret != "" {
+		req.Header.Set("X-Signature-256", "sha256="+n.Sign(body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	return nil
+}
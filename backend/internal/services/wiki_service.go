@@ -6,21 +6,123 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/1DeliDolu/ki-ai-go/internal/config"
 	"github.com/1DeliDolu/ki-ai-go/pkg/types"
 )
 
 type WikiService struct {
+	lang    string
 	baseURL string
+
+	cacheTTL        time.Duration
+	cacheMaxEntries int
+	mu              sync.Mutex
+	cache           map[string]cachedWikiResult
+}
+
+// cachedWikiResult is a search result kept until it expires
+type cachedWikiResult struct {
+	results []types.WikiResult
+	expires time.Time
 }
 
-func NewWikiService() *WikiService {
+func NewWikiService(cfg *config.Config) *WikiService {
+	lang := strings.ToLower(strings.TrimSpace(cfg.WikiLanguage))
+	if lang == "" {
+		lang = "de"
+	}
+
 	return &WikiService{
-		baseURL: "https://de.wikipedia.org/api/rest_v1",
+		lang:            lang,
+		baseURL:         fmt.Sprintf("https://%s.wikipedia.org/api/rest_v1", lang),
+		cacheTTL:        time.Duration(cfg.WikiCacheTTL) * time.Second,
+		cacheMaxEntries: cfg.WikiCacheMaxEntries,
+		cache:           make(map[string]cachedWikiResult),
 	}
 }
 
+// Search returns the Wikipedia articles matching query. Results are cached per normalized query
+// and language, so repeating a query within the cache TTL doesn't call Wikipedia again.
 func (s *WikiService) Search(query string) ([]types.WikiResult, error) {
+	key := s.lang + "\n" + strings.ToLower(strings.Join(strings.Fields(query), " "))
+	if results, ok := s.cached(key); ok {
+		return results, nil
+	}
+
+	results, err := s.fetch(query)
+	if err != nil {
+		return nil, err
+	}
+	s.store(key, results)
+	return results, nil
+}
+
+// ClearCache drops every cached search result and returns how many there were
+func (s *WikiService) ClearCache() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cleared := len(s.cache)
+	s.cache = make(map[string]cachedWikiResult)
+	return cleared
+}
+
+// cached returns the unexpired results stored for key
+func (s *WikiService) cached(key string) ([]types.WikiResult, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(s.cache, key)
+		return nil, false
+	}
+	return append([]types.WikiResult(nil), entry.results...), true
+}
+
+// store caches results for key, first dropping expired entries and, when the cache is still full,
+// the entry closest to expiring
+func (s *WikiService) store(key string, results []types.WikiResult) {
+	if s.cacheTTL <= 0 || s.cacheMaxEntries <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if len(s.cache) >= s.cacheMaxEntries {
+		for cachedKey, entry := range s.cache {
+			if now.After(entry.expires) {
+				delete(s.cache, cachedKey)
+			}
+		}
+	}
+	for len(s.cache) >= s.cacheMaxEntries {
+		oldestKey, oldest := "", time.Time{}
+		for cachedKey, entry := range s.cache {
+			if oldestKey == "" || entry.expires.Before(oldest) {
+				oldestKey, oldest = cachedKey, entry.expires
+			}
+		}
+		delete(s.cache, oldestKey)
+	}
+
+	s.cache[key] = cachedWikiResult{
+		results: append([]types.WikiResult(nil), results...),
+		expires: now.Add(s.cacheTTL),
+	}
+}
+
+// fetch asks Wikipedia for the article summary of query, falling back to a search
+func (s *WikiService) fetch(query string) ([]types.WikiResult, error) {
 	// Wikipedia search API
 	searchURL := fmt.Sprintf("%s/page/summary/%s", s.baseURL, url.QueryEscape(query))
 
@@ -36,6 +138,7 @@ func (s *WikiService) Search(query string) ([]types.WikiResult, error) {
 	}
 
 	var result struct {
+		PageID      int64  `json:"pageid"`
 		Title       string `json:"title"`
 		Extract     string `json:"extract"`
 		Description string `json:"description"`
@@ -55,9 +158,11 @@ func (s *WikiService) Search(query string) ([]types.WikiResult, error) {
 
 	return []types.WikiResult{
 		{
+			PageID:      strconv.FormatInt(result.PageID, 10),
 			Title:       result.Title,
 			Extract:     result.Extract,
 			Description: result.Description,
+			Summary:     wikiSummary(result.Extract, result.Description),
 			URL:         result.ContentURLs.Desktop.Page,
 			Thumbnail:   result.Thumbnail.Source,
 		},
@@ -66,8 +171,8 @@ func (s *WikiService) Search(query string) ([]types.WikiResult, error) {
 
 func (s *WikiService) searchMultiple(query string) ([]types.WikiResult, error) {
 	// Use OpenSearch API for multiple results
-	searchURL := fmt.Sprintf("https://de.wikipedia.org/w/api.php?action=opensearch&search=%s&limit=5&format=json",
-		url.QueryEscape(query))
+	searchURL := fmt.Sprintf("https://%s.wikipedia.org/w/api.php?action=opensearch&search=%s&limit=5&format=json",
+		s.lang, url.QueryEscape(query))
 
 	resp, err := http.Get(searchURL)
 	if err != nil {
@@ -102,19 +207,32 @@ func (s *WikiService) searchMultiple(query string) ([]types.WikiResult, error) {
 	var results []types.WikiResult
 	for i, title := range titles {
 		if i < len(descriptions) && i < len(urls) {
-			desc := ""
-			if descriptions[i] != nil {
-				desc = descriptions[i].(string)
+			titleText, _ := title.(string)
+			pageURL, _ := urls[i].(string)
+			if titleText == "" || pageURL == "" {
+				continue
 			}
+			desc, _ := descriptions[i].(string)
 
 			results = append(results, types.WikiResult{
-				Title:       title.(string),
+				Title:       titleText,
 				Description: desc,
 				Extract:     desc,
-				URL:         urls[i].(string),
+				Summary:     wikiSummary(desc, titleText),
+				URL:         pageURL,
 			})
 		}
 	}
 
 	return results, nil
 }
+
+// wikiSummary picks the text a result is summarized by in prompts, the first non-empty candidate
+func wikiSummary(candidates ...string) string {
+	for _, candidate := range candidates {
+		if candidate = strings.TrimSpace(candidate); candidate != "" {
+			return candidate
+		}
+	}
+	return ""
+}
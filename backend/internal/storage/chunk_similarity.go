@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/utils"
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// ErrInvalidQueryEmbedding is returned for query embeddings that can't be compared
+var ErrInvalidQueryEmbedding = errors.New("query embedding must be a non-zero vector")
+
+// ScoredChunk is a chunk with its cosine similarity to a query embedding
+type ScoredChunk struct {
+	*types.DocumentChunk
+	Score float64 `json:"score"`
+}
+
+// SearchChunksByEmbedding returns copies of the topK chunks of the given documents most similar
+// to the query embedding, best first. A nil documentIDs searches all documents. Chunks without an
+// embedding of the query's dimension are skipped, topK <= 0 returns all of them.
+func (db *MemoryDB) SearchChunksByEmbedding(query []float64, documentIDs []string, topK int) ([]ScoredChunk, error) {
+	if isZeroVector(query) {
+		return nil, ErrInvalidQueryEmbedding
+	}
+
+	db.mu.RLock()
+	var candidates []*types.DocumentChunk
+	collect := func(chunks []*types.DocumentChunk) {
+		for _, chunk := range chunks {
+			chunkCopy := *chunk
+			candidates = append(candidates, &chunkCopy)
+		}
+	}
+	if documentIDs == nil {
+		for _, chunks := range db.chunks {
+			collect(chunks)
+		}
+	} else {
+		for _, id := range documentIDs {
+			collect(db.chunks[id])
+		}
+	}
+	db.mu.RUnlock()
+
+	return rankByEmbedding(query, candidates, topK), nil
+}
+
+// rankByEmbedding scores chunks by cosine similarity to the query and returns the topK best
+func rankByEmbedding(query []float64, chunks []*types.DocumentChunk, topK int) []ScoredChunk {
+	results := []ScoredChunk{}
+	for _, chunk := range chunks {
+		if len(chunk.Embedding) != len(query) || isZeroVector(chunk.Embedding) {
+			continue
+		}
+		results = append(results, ScoredChunk{DocumentChunk: chunk, Score: utils.CosineSimilarity(query, chunk.Embedding)})
+	}
+
+	// Ties are broken by position so results don't depend on map order
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].DocumentID != results[j].DocumentID {
+			return results[i].DocumentID < results[j].DocumentID
+		}
+		return results[i].ChunkIndex < results[j].ChunkIndex
+	})
+	if topK > 0 && len(results) > topK {
+		results = results[:topK]
+	}
+	return results
+}
+
+// isZeroVector reports whether v is empty or all zeros
+func isZeroVector(v []float64) bool {
+	for _, x := range v {
+		if x != 0 {
+			return false
+		}
+	}
+	return true
+}
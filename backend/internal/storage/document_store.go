@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// ErrDuplicateExternalID is returned when a document is stored with an external ID another
+// document already has
+var ErrDuplicateExternalID = errors.New("external ID is already used by another document")
+
+// DocumentStore keeps document records and their chunks. MemoryDB keeps them in the process,
+// PostgresDB shares them between instances. DeleteDocument also deletes the document's chunks.
+// External IDs are unique among all documents.
+type DocumentStore interface {
+	CreateDocument(doc *types.Document) error
+	GetDocument(id string) (*types.Document, error)
+	GetDocumentByExternalID(externalID string) (*types.Document, error)
+	ListDocuments() ([]*types.Document, error)
+	QueryDocuments(query types.DocumentQuery) ([]*types.Document, error)
+	UpdateDocument(doc *types.Document) error
+	UpdateDocumentMetadata(id string, updates map[string]string) error
+	DeleteDocument(id string) error
+
+	CreateChunk(chunk *types.DocumentChunk) error
+	GetChunks(documentID string) ([]*types.DocumentChunk, error)
+	ReplaceChunks(documentID string, chunks []*types.DocumentChunk) error
+	ListAllChunks() ([]*types.DocumentChunk, error)
+	SearchChunksByEmbedding(query []float64, documentIDs []string, topK int) ([]ScoredChunk, error)
+}
+
+var (
+	_ DocumentStore = (*MemoryDB)(nil)
+	_ DocumentStore = (*PostgresDB)(nil)
+)
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// memorySnapshot is the on-disk form of the persisted MemoryDB tables
+type memorySnapshot struct {
+	SavedAt     time.Time                         `json:"saved_at"`
+	NextID      int                               `json:"next_id"`
+	NextChunkID int                               `json:"next_chunk_id"`
+	Documents   map[string]*types.Document        `json:"documents"`
+	Models      map[string]*types.Model           `json:"models"`
+	Chunks      map[string][]*types.DocumentChunk `json:"chunks"`
+}
+
+// SaveToFile writes the documents, models and chunks to path as JSON
+func (db *MemoryDB) SaveToFile(path string) error {
+	db.saveMu.Lock()
+	defer db.saveMu.Unlock()
+
+	db.mu.RLock()
+	changes := db.changes
+	data, err := json.Marshal(memorySnapshot{
+		SavedAt:     time.Now(),
+		NextID:      db.nextID,
+		NextChunkID: db.nextChunkID,
+		Documents:   db.documents,
+		Models:      db.models,
+		Chunks:      db.chunks,
+	})
+	db.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal memory database: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	// Write to a temp file first so a crash never leaves a half-written snapshot
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+
+	db.mu.Lock()
+	db.savedChanges = changes
+	db.mu.Unlock()
+	return nil
+}
+
+// LoadFromFile replaces the documents, models and chunks with those saved at path. A missing file
+// leaves the database unchanged. Suggestions are not persisted and start out empty.
+func (db *MemoryDB) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snapshot memorySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	if snapshot.Documents == nil {
+		snapshot.Documents = make(map[string]*types.Document)
+	}
+	if snapshot.Models == nil {
+		snapshot.Models = make(map[string]*types.Model)
+	}
+	if snapshot.Chunks == nil {
+		snapshot.Chunks = make(map[string][]*types.DocumentChunk)
+	}
+
+	// Never hand out an ID that is already taken, even if the counters were edited or are missing
+	nextID, nextChunkID := snapshot.NextID, snapshot.NextChunkID
+	for id := range snapshot.Documents {
+		if n, err := strconv.Atoi(id); err == nil && n >= nextID {
+			nextID = n + 1
+		}
+	}
+	for _, chunks := range snapshot.Chunks {
+		for _, chunk := range chunks {
+			if n, err := strconv.Atoi(strings.TrimPrefix(chunk.ID, "chunk_")); err == nil && n >= nextChunkID {
+				nextChunkID = n + 1
+			}
+		}
+	}
+
+	externalIDs := make(map[string]string)
+	for id, doc := range snapshot.Documents {
+		if doc.ExternalID != "" {
+			externalIDs[doc.ExternalID] = id
+		}
+	}
+
+	db.mu.Lock()
+	db.documents = snapshot.Documents
+	db.externalIDs = externalIDs
+	db.models = snapshot.Models
+	db.chunks = snapshot.Chunks
+	db.suggestions = newSuggestIndex()
+	db.nextID = max(nextID, 1)
+	db.nextChunkID = max(nextChunkID, 1)
+	db.changes, db.savedChanges = 0, 0
+	db.mu.Unlock()
+
+	log.Printf("💾 Restored %d documents, %d models and chunks of %d documents from %s",
+		len(snapshot.Documents), len(snapshot.Models), len(snapshot.Chunks), path)
+	return nil
+}
+
+// EnablePersistence loads the snapshot at path and saves it back every interval while there are
+// unsaved changes, and on Close. A zero interval only saves on Close.
+func (db *MemoryDB) EnablePersistence(path string, interval time.Duration) error {
+	if err := db.LoadFromFile(path); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	db.snapshotPath = path
+	db.mu.Unlock()
+
+	if interval > 0 {
+		db.stopSnapshots = make(chan struct{})
+		go db.snapshotLoop(path, interval, db.stopSnapshots)
+	}
+	return nil
+}
+
+// snapshotLoop periodically saves unsaved changes until stop is closed
+func (db *MemoryDB) snapshotLoop(path string, interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			db.mu.RLock()
+			dirty := db.changes != db.savedChanges
+			db.mu.RUnlock()
+			if !dirty {
+				continue
+			}
+			if err := db.SaveToFile(path); err != nil {
+				log.Printf("⚠️ Failed to save memory database snapshot: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
@@ -1,320 +1,629 @@
-package storage
-
-import (
-	"database/sql"
-	"fmt"
-	"log"
-	"sync"
-	"time"
-
-	"github.com/1DeliDolu/ki-ai-go/pkg/types"
-)
-
-// MemoryDB implements a simple in-memory database using maps and slices
-type MemoryDB struct {
-	mu           sync.RWMutex
-	users        map[int]*User
-	prompts      map[int]*Prompt
-	documents    map[string]*types.Document
-	models       map[string]*types.Model
-	chunks       map[string][]*types.DocumentChunk
-	nextID       int
-	nextUserID   int
-	nextPromptID int
-}
-
-// User represents a user in the system
-type User struct {
-	UserID    int    `json:"user_id"`
-	Username  string `json:"username"`
-	CreatedAt string `json:"created_at"`
-}
-
-// Prompt represents a prompt and its answer
-type Prompt struct {
-	ID         int    `json:"id"`
-	UserID     int    `json:"user_id"`
-	PromptText string `json:"prompt_text"`
-	AnswerText string `json:"answer_text"`
-	CreatedAt  string `json:"created_at"`
-}
-
-// NewMemoryDB creates a new in-memory database
-func NewMemoryDB() *MemoryDB {
-	return &MemoryDB{
-		users:        make(map[int]*User),
-		prompts:      make(map[int]*Prompt),
-		documents:    make(map[string]*types.Document),
-		models:       make(map[string]*types.Model),
-		chunks:       make(map[string][]*types.DocumentChunk),
-		nextID:       1,
-		nextUserID:   1,
-		nextPromptID: 1,
-	}
-}
-
-// Implement sql.DB interface methods we need
-func (db *MemoryDB) Close() error {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	// Clear all data
-	db.documents = make(map[string]*types.Document)
-	db.models = make(map[string]*types.Model)
-	db.chunks = make(map[string][]*types.DocumentChunk)
-	db.users = make(map[int]*User)
-	db.prompts = make(map[int]*Prompt)
-	db.nextID = 1
-	db.nextUserID = 1
-	db.nextPromptID = 1
-
-	log.Println("Memory database closed and cleared")
-	return nil
-}
-
-func (db *MemoryDB) Exec(query string, args ...interface{}) (sql.Result, error) {
-	// For memory DB, most exec operations are no-ops or handled internally
-	log.Printf("Memory DB Exec (no-op): %s", query)
-	return &memoryResult{}, nil
-}
-
-func (db *MemoryDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	// Memory DB doesn't use SQL queries
-	return nil, fmt.Errorf("memory DB doesn't support SQL queries")
-}
-
-// Document operations
-func (db *MemoryDB) CreateDocument(doc *types.Document) error {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	if doc.ID == "" {
-		doc.ID = fmt.Sprintf("%d", db.nextID)
-		db.nextID++
-	}
-
-	if doc.UploadDate == "" {
-		doc.UploadDate = time.Now().Format(time.RFC3339)
-	}
-
-	db.documents[doc.ID] = doc
-	log.Printf("Document created: %s (%s)", doc.Name, doc.ID)
-	return nil
-}
-
-func (db *MemoryDB) GetDocument(id string) (*types.Document, error) {
-	db.mu.RLock()
-	defer db.mu.RUnlock()
-
-	doc, exists := db.documents[id]
-	if !exists {
-		return nil, fmt.Errorf("document not found: %s", id)
-	}
-
-	// Return a copy
-	docCopy := *doc
-	return &docCopy, nil
-}
-
-func (db *MemoryDB) ListDocuments() ([]*types.Document, error) {
-	db.mu.RLock()
-	defer db.mu.RUnlock()
-
-	docs := make([]*types.Document, 0, len(db.documents))
-	for _, doc := range db.documents {
-		// Return copies
-		docCopy := *doc
-		docs = append(docs, &docCopy)
-	}
-
-	log.Printf("Listed %d documents", len(docs))
-	return docs, nil
-}
-
-func (db *MemoryDB) DeleteDocument(id string) error {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	if _, exists := db.documents[id]; !exists {
-		return fmt.Errorf("document not found: %s", id)
-	}
-
-	delete(db.documents, id)
-	delete(db.chunks, id) // Also delete associated chunks
-	log.Printf("Document deleted: %s", id)
-	return nil
-}
-
-// Model operations
-func (db *MemoryDB) CreateModel(model *types.Model) error {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	db.models[model.ID] = model
-	log.Printf("Model created: %s", model.ID)
-	return nil
-}
-
-func (db *MemoryDB) GetModel(id string) (*types.Model, error) {
-	db.mu.RLock()
-	defer db.mu.RUnlock()
-
-	model, exists := db.models[id]
-	if !exists {
-		return nil, fmt.Errorf("model not found: %s", id)
-	}
-
-	// Return a copy
-	modelCopy := *model
-	return &modelCopy, nil
-}
-
-func (db *MemoryDB) ListModels() ([]*types.Model, error) {
-	db.mu.RLock()
-	defer db.mu.RUnlock()
-
-	models := make([]*types.Model, 0, len(db.models))
-	for _, model := range db.models {
-		// Return copies
-		modelCopy := *model
-		models = append(models, &modelCopy)
-	}
-
-	return models, nil
-}
-
-// Chunk operations
-func (db *MemoryDB) CreateChunk(chunk *types.DocumentChunk) error {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	if chunk.ID == "" {
-		chunk.ID = fmt.Sprintf("chunk_%d", db.nextID)
-		db.nextID++
-	}
-
-	db.chunks[chunk.DocumentID] = append(db.chunks[chunk.DocumentID], chunk)
-	log.Printf("Chunk created for document: %s", chunk.DocumentID)
-	return nil
-}
-
-func (db *MemoryDB) GetChunks(documentID string) ([]*types.DocumentChunk, error) {
-	db.mu.RLock()
-	defer db.mu.RUnlock()
-
-	chunks := db.chunks[documentID]
-	if chunks == nil {
-		return []*types.DocumentChunk{}, nil
-	}
-
-	// Return copies
-	result := make([]*types.DocumentChunk, len(chunks))
-	for i, chunk := range chunks {
-		chunkCopy := *chunk
-		result[i] = &chunkCopy
-	}
-
-	return result, nil
-}
-
-// User operations
-func (db *MemoryDB) CreateUser(username string) (*User, error) {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	// Check if username already exists
-	for _, user := range db.users {
-		if user.Username == username {
-			return nil, fmt.Errorf("username already exists: %s", username)
-		}
-	}
-
-	user := &User{
-		UserID:    db.nextUserID,
-		Username:  username,
-		CreatedAt: time.Now().Format(time.RFC3339),
-	}
-
-	db.users[db.nextUserID] = user
-	db.nextUserID++
-
-	log.Printf("User created: %s (ID: %d)", username, user.UserID)
-	return user, nil
-}
-
-func (db *MemoryDB) GetUser(userID int) (*User, error) {
-	db.mu.RLock()
-	defer db.mu.RUnlock()
-
-	user, exists := db.users[userID]
-	if !exists {
-		return nil, fmt.Errorf("user not found: %d", userID)
-	}
-
-	userCopy := *user
-	return &userCopy, nil
-}
-
-// Prompt operations
-func (db *MemoryDB) CreatePrompt(userID int, promptText, answerText string) (*Prompt, error) {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	// Check if user exists
-	if _, exists := db.users[userID]; !exists {
-		return nil, fmt.Errorf("user not found: %d", userID)
-	}
-
-	prompt := &Prompt{
-		ID:         db.nextPromptID,
-		UserID:     userID,
-		PromptText: promptText,
-		AnswerText: answerText,
-		CreatedAt:  time.Now().Format(time.RFC3339),
-	}
-
-	db.prompts[db.nextPromptID] = prompt
-	db.nextPromptID++
-
-	log.Printf("Prompt created for user %d (ID: %d)", userID, prompt.ID)
-	return prompt, nil
-}
-
-func (db *MemoryDB) GetUserPrompts(userID int, limit int) ([]*Prompt, error) {
-	db.mu.RLock()
-	defer db.mu.RUnlock()
-
-	var userPrompts []*Prompt
-	count := 0
-
-	for _, prompt := range db.prompts {
-		if prompt.UserID == userID {
-			if limit > 0 && count >= limit {
-				break
-			}
-			promptCopy := *prompt
-			userPrompts = append(userPrompts, &promptCopy)
-			count++
-		}
-	}
-
-	return userPrompts, nil
-}
-
-// Helper types for sql.Result interface
-type memoryResult struct{}
-
-func (r *memoryResult) LastInsertId() (int64, error) { return 0, nil }
-func (r *memoryResult) RowsAffected() (int64, error) { return 1, nil }
-
-// Global memory database instance
-var memoryDBInstance *MemoryDB
-
-// InitMemoryDB initializes the in-memory database
-func InitMemoryDB() *MemoryDB {
-	if memoryDBInstance == nil {
-		memoryDBInstance = NewMemoryDB()
-		log.Println("✅ Memory database initialized")
-	}
-	return memoryDBInstance
-}
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/logging"
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// MemoryDB implements a simple in-memory database using maps and slices
+type MemoryDB struct {
+	mu           sync.RWMutex
+	users        map[int]*User
+	prompts      map[int]*Prompt
+	documents    map[string]*types.Document
+	models       map[string]*types.Model
+	chunks       map[string][]*types.DocumentChunk
+	externalIDs  map[string]string // External ID -> document ID
+	suggestions  *suggestIndex
+	nextID       int // Next document ID
+	nextChunkID  int
+	nextUserID   int
+	nextPromptID int
+
+	// Optional persistence, see EnablePersistence
+	snapshotPath  string
+	stopSnapshots chan struct{}
+	saveMu        sync.Mutex // Serializes snapshot writes
+	changes       int        // Mutations of persisted tables, compared to savedChanges
+	savedChanges  int
+}
+
+// User represents a user in the system
+type User struct {
+	UserID    int    `json:"user_id"`
+	Username  string `json:"username"`
+	CreatedAt string `json:"created_at"`
+}
+
+// Prompt represents a prompt and its answer
+type Prompt struct {
+	ID         int    `json:"id"`
+	UserID     int    `json:"user_id"`
+	PromptText string `json:"prompt_text"`
+	AnswerText string `json:"answer_text"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// NewMemoryDB creates a new in-memory database
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{
+		users:        make(map[int]*User),
+		prompts:      make(map[int]*Prompt),
+		documents:    make(map[string]*types.Document),
+		models:       make(map[string]*types.Model),
+		chunks:       make(map[string][]*types.DocumentChunk),
+		externalIDs:  make(map[string]string),
+		suggestions:  newSuggestIndex(),
+		nextID:       1,
+		nextChunkID:  1,
+		nextUserID:   1,
+		nextPromptID: 1,
+	}
+}
+
+// Implement sql.DB interface methods we need
+func (db *MemoryDB) Close() error {
+	if db.stopSnapshots != nil {
+		close(db.stopSnapshots)
+		db.stopSnapshots = nil
+	}
+	if db.snapshotPath != "" {
+		if err := db.SaveToFile(db.snapshotPath); err != nil {
+			log.Printf("⚠️ Failed to save memory database snapshot: %v", err)
+		} else {
+			log.Printf("💾 Memory database saved to %s", db.snapshotPath)
+		}
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	// Clear all data
+	db.documents = make(map[string]*types.Document)
+	db.models = make(map[string]*types.Model)
+	db.chunks = make(map[string][]*types.DocumentChunk)
+	db.externalIDs = make(map[string]string)
+	db.users = make(map[int]*User)
+	db.prompts = make(map[int]*Prompt)
+	db.nextID = 1
+	db.nextChunkID = 1
+	db.nextUserID = 1
+	db.nextPromptID = 1
+
+	log.Println("Memory database closed and cleared")
+	return nil
+}
+
+func (db *MemoryDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	// For memory DB, most exec operations are no-ops or handled internally
+	log.Printf("Memory DB Exec (no-op): %s", query)
+	return &memoryResult{}, nil
+}
+
+func (db *MemoryDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	// Memory DB doesn't use SQL queries
+	return nil, fmt.Errorf("memory DB doesn't support SQL queries")
+}
+
+// Document operations
+func (db *MemoryDB) CreateDocument(doc *types.Document) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if doc.ExternalID != "" {
+		if _, taken := db.externalIDs[doc.ExternalID]; taken {
+			return fmt.Errorf("%w: %s", ErrDuplicateExternalID, doc.ExternalID)
+		}
+	}
+
+	if doc.ID == "" {
+		doc.ID = fmt.Sprintf("%d", db.nextID)
+		db.nextID++
+	}
+
+	if doc.UploadDate == "" {
+		doc.UploadDate = time.Now().Format(time.RFC3339)
+	}
+
+	docCopy := *doc
+	docCopy.Metadata = maps.Clone(doc.Metadata)
+	db.documents[doc.ID] = &docCopy
+	if doc.ExternalID != "" {
+		db.externalIDs[doc.ExternalID] = doc.ID
+	}
+	db.changes++
+	log.Printf("Document created: %s (%s)", logging.Document(doc.Name, doc.ID), doc.ID)
+	return nil
+}
+
+func (db *MemoryDB) GetDocument(id string) (*types.Document, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	doc, exists := db.documents[id]
+	if !exists {
+		return nil, fmt.Errorf("document not found: %s", id)
+	}
+
+	// Return a copy, metadata included, so callers can change it freely
+	docCopy := *doc
+	docCopy.Metadata = maps.Clone(doc.Metadata)
+	return &docCopy, nil
+}
+
+// GetDocumentByExternalID returns the document with the given external ID
+func (db *MemoryDB) GetDocumentByExternalID(externalID string) (*types.Document, error) {
+	db.mu.RLock()
+	id, exists := db.externalIDs[externalID]
+	db.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("document not found: external ID %s", externalID)
+	}
+	return db.GetDocument(id)
+}
+
+func (db *MemoryDB) ListDocuments() ([]*types.Document, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	docs := make([]*types.Document, 0, len(db.documents))
+	for _, doc := range db.documents {
+		// Return copies
+		docCopy := *doc
+		docCopy.Metadata = maps.Clone(doc.Metadata)
+		docs = append(docs, &docCopy)
+	}
+
+	log.Printf("Listed %d documents", len(docs))
+	return docs, nil
+}
+
+// QueryDocuments returns the documents matching the query's type, tag, size, date and metadata
+// filters, sorted as requested. Text matching and pagination are left to the caller.
+func (db *MemoryDB) QueryDocuments(query types.DocumentQuery) ([]*types.Document, error) {
+	db.mu.RLock()
+	docs := make([]*types.Document, 0, len(db.documents))
+	for _, doc := range db.documents {
+		docCopy := *doc
+		docCopy.Metadata = maps.Clone(doc.Metadata)
+		docs = append(docs, &docCopy)
+	}
+	db.mu.RUnlock()
+
+	return filterDocuments(docs, query)
+}
+
+// filterDocuments returns the documents matching the query's filters, sorted as requested
+func filterDocuments(docs []*types.Document, query types.DocumentQuery) ([]*types.Document, error) {
+	var after, before time.Time
+	var err error
+	if query.UploadedAfter != "" {
+		if after, err = types.ParseDocumentDate(query.UploadedAfter); err != nil {
+			return nil, err
+		}
+	}
+	if query.UploadedBefore != "" {
+		if before, err = types.ParseDocumentDate(query.UploadedBefore); err != nil {
+			return nil, err
+		}
+	}
+
+	matched := []*types.Document{}
+	for _, doc := range docs {
+		if matchesQuery(doc, query, after, before) {
+			matched = append(matched, doc)
+		}
+	}
+
+	sortDocuments(matched, query.SortBy, query.Order == "desc")
+	return matched, nil
+}
+
+// matchesQuery applies the structured filters of a query to a document
+func matchesQuery(doc *types.Document, query types.DocumentQuery, after, before time.Time) bool {
+	if len(query.Types) > 0 {
+		found := false
+		for _, t := range query.Types {
+			if strings.EqualFold(strings.TrimPrefix(t, "."), doc.Type) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if query.MinSize > 0 && doc.Size < query.MinSize {
+		return false
+	}
+	if query.MaxSize > 0 && doc.Size > query.MaxSize {
+		return false
+	}
+
+	if !after.IsZero() || !before.IsZero() {
+		uploaded, err := types.ParseDocumentDate(doc.UploadDate)
+		if err != nil {
+			return false
+		}
+		if (!after.IsZero() && uploaded.Before(after)) || (!before.IsZero() && !uploaded.Before(before)) {
+			return false
+		}
+	}
+
+	if len(query.Tags) > 0 {
+		tags := make(map[string]bool)
+		for _, tag := range strings.Split(doc.Metadata["user.tags"], ",") {
+			tags[strings.ToLower(strings.TrimSpace(tag))] = true
+		}
+		for _, tag := range query.Tags {
+			if !tags[strings.ToLower(strings.TrimSpace(tag))] {
+				return false
+			}
+		}
+	}
+
+	for key, value := range query.Metadata {
+		if !strings.EqualFold(doc.Metadata[key], value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sortDocuments orders documents by the given field, by upload date when none is given
+func sortDocuments(docs []*types.Document, sortBy string, descending bool) {
+	less := func(a, b *types.Document) bool {
+		switch sortBy {
+		case "name":
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		case "type":
+			return a.Type < b.Type
+		case "size":
+			return a.Size < b.Size
+		case "created_date":
+			ta, _ := types.ParseDocumentDate(a.CreatedDate)
+			tb, _ := types.ParseDocumentDate(b.CreatedDate)
+			return ta.Before(tb)
+		case "modified_date":
+			ta, _ := types.ParseDocumentDate(a.ModifiedDate)
+			tb, _ := types.ParseDocumentDate(b.ModifiedDate)
+			return ta.Before(tb)
+		default:
+			ta, _ := types.ParseDocumentDate(a.UploadDate)
+			tb, _ := types.ParseDocumentDate(b.UploadDate)
+			return ta.Before(tb)
+		}
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		if descending {
+			return less(docs[j], docs[i])
+		}
+		return less(docs[i], docs[j])
+	})
+}
+
+// UpdateDocument replaces the stored fields of an existing document
+func (db *MemoryDB) UpdateDocument(doc *types.Document) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	existing, exists := db.documents[doc.ID]
+	if !exists {
+		return fmt.Errorf("document not found: %s", doc.ID)
+	}
+	if doc.ExternalID != existing.ExternalID {
+		if doc.ExternalID != "" {
+			if _, taken := db.externalIDs[doc.ExternalID]; taken {
+				return fmt.Errorf("%w: %s", ErrDuplicateExternalID, doc.ExternalID)
+			}
+			db.externalIDs[doc.ExternalID] = doc.ID
+		}
+		delete(db.externalIDs, existing.ExternalID)
+	}
+
+	docCopy := *doc
+	docCopy.Metadata = maps.Clone(doc.Metadata)
+	db.documents[doc.ID] = &docCopy
+	db.changes++
+	return nil
+}
+
+// UpdateDocumentMetadata merges the given keys into a document's metadata
+func (db *MemoryDB) UpdateDocumentMetadata(id string, updates map[string]string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	doc, exists := db.documents[id]
+	if !exists {
+		return fmt.Errorf("document not found: %s", id)
+	}
+
+	// Copy so documents handed out earlier don't observe the change
+	metadata := make(map[string]string, len(doc.Metadata)+len(updates))
+	for k, v := range doc.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range updates {
+		metadata[k] = v
+	}
+	doc.Metadata = metadata
+	db.changes++
+
+	return nil
+}
+
+func (db *MemoryDB) DeleteDocument(id string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	doc, exists := db.documents[id]
+	if !exists {
+		return fmt.Errorf("document not found: %s", id)
+	}
+
+	delete(db.externalIDs, doc.ExternalID)
+	delete(db.documents, id)
+	delete(db.chunks, id) // Also delete associated chunks
+	db.suggestions.remove(id)
+	db.changes++
+	log.Printf("Document deleted: %s", id)
+	return nil
+}
+
+// IndexSuggestions replaces the autocomplete terms of a document, weighted by importance. Terms
+// from its content and from its title and tags are passed separately; a nil content keeps the
+// content terms indexed before. The document may be stored elsewhere, so Suggest callers decide
+// which documents still exist.
+func (db *MemoryDB) IndexSuggestions(documentID string, content, metadata map[string]int) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.suggestions.set(documentID, content, metadata)
+}
+
+// Suggest returns the highest ranked autocomplete terms starting with prefix, counting only
+// documents for which visible returns true
+func (db *MemoryDB) Suggest(prefix string, visible func(documentID string) bool, limit int) []types.Suggestion {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.suggestions.find(prefix, visible, limit)
+}
+
+// DeleteSuggestions removes a document's suggestions, for documents kept in another DocumentStore
+func (db *MemoryDB) DeleteSuggestions(documentID string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.suggestions.remove(documentID)
+}
+
+// Model operations
+func (db *MemoryDB) CreateModel(model *types.Model) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.models[model.ID] = model
+	db.changes++
+	log.Printf("Model created: %s", model.ID)
+	return nil
+}
+
+func (db *MemoryDB) GetModel(id string) (*types.Model, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	model, exists := db.models[id]
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", id)
+	}
+
+	// Return a copy
+	modelCopy := *model
+	return &modelCopy, nil
+}
+
+func (db *MemoryDB) ListModels() ([]*types.Model, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	models := make([]*types.Model, 0, len(db.models))
+	for _, model := range db.models {
+		// Return copies
+		modelCopy := *model
+		models = append(models, &modelCopy)
+	}
+
+	return models, nil
+}
+
+// Chunk operations
+func (db *MemoryDB) CreateChunk(chunk *types.DocumentChunk) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if chunk.ID == "" {
+		chunk.ID = fmt.Sprintf("chunk_%d", db.nextChunkID)
+		db.nextChunkID++
+	}
+
+	db.chunks[chunk.DocumentID] = append(db.chunks[chunk.DocumentID], chunk)
+	db.changes++
+	log.Printf("Chunk created for document: %s", chunk.DocumentID)
+	return nil
+}
+
+func (db *MemoryDB) GetChunks(documentID string) ([]*types.DocumentChunk, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	chunks := db.chunks[documentID]
+	if chunks == nil {
+		return []*types.DocumentChunk{}, nil
+	}
+
+	// Return copies
+	result := make([]*types.DocumentChunk, len(chunks))
+	for i, chunk := range chunks {
+		chunkCopy := *chunk
+		result[i] = &chunkCopy
+	}
+
+	return result, nil
+}
+
+// ReplaceChunks replaces all chunks of a document with the given ones
+func (db *MemoryDB) ReplaceChunks(documentID string, chunks []*types.DocumentChunk) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	stored := make([]*types.DocumentChunk, len(chunks))
+	for i, chunk := range chunks {
+		if chunk.ID == "" {
+			chunk.ID = fmt.Sprintf("chunk_%d", db.nextChunkID)
+			db.nextChunkID++
+		}
+		chunkCopy := *chunk
+		stored[i] = &chunkCopy
+	}
+
+	db.chunks[documentID] = stored
+	db.changes++
+	log.Printf("Replaced chunks of document %s with %d chunks", documentID, len(chunks))
+	return nil
+}
+
+// ListAllChunks returns copies of every stored chunk, grouped by document
+func (db *MemoryDB) ListAllChunks() ([]*types.DocumentChunk, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var result []*types.DocumentChunk
+	for _, chunks := range db.chunks {
+		for _, chunk := range chunks {
+			chunkCopy := *chunk
+			result = append(result, &chunkCopy)
+		}
+	}
+	return result, nil
+}
+
+// User operations
+func (db *MemoryDB) CreateUser(username string) (*User, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	// Check if username already exists
+	for _, user := range db.users {
+		if user.Username == username {
+			return nil, fmt.Errorf("username already exists: %s", username)
+		}
+	}
+
+	user := &User{
+		UserID:    db.nextUserID,
+		Username:  username,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+
+	db.users[db.nextUserID] = user
+	db.nextUserID++
+
+	log.Printf("User created: %s (ID: %d)", username, user.UserID)
+	return user, nil
+}
+
+func (db *MemoryDB) GetUser(userID int) (*User, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	user, exists := db.users[userID]
+	if !exists {
+		return nil, fmt.Errorf("user not found: %d", userID)
+	}
+
+	userCopy := *user
+	return &userCopy, nil
+}
+
+// Prompt operations
+func (db *MemoryDB) CreatePrompt(userID int, promptText, answerText string) (*Prompt, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	// Check if user exists
+	if _, exists := db.users[userID]; !exists {
+		return nil, fmt.Errorf("user not found: %d", userID)
+	}
+
+	prompt := &Prompt{
+		ID:         db.nextPromptID,
+		UserID:     userID,
+		PromptText: promptText,
+		AnswerText: answerText,
+		CreatedAt:  time.Now().Format(time.RFC3339),
+	}
+
+	db.prompts[db.nextPromptID] = prompt
+	db.nextPromptID++
+
+	log.Printf("Prompt created for user %d (ID: %d)", userID, prompt.ID)
+	return prompt, nil
+}
+
+func (db *MemoryDB) GetUserPrompts(userID int, limit int) ([]*Prompt, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var userPrompts []*Prompt
+	count := 0
+
+	for _, prompt := range db.prompts {
+		if prompt.UserID == userID {
+			if limit > 0 && count >= limit {
+				break
+			}
+			promptCopy := *prompt
+			userPrompts = append(userPrompts, &promptCopy)
+			count++
+		}
+	}
+
+	return userPrompts, nil
+}
+
+// Helper types for sql.Result interface
+type memoryResult struct{}
+
+func (r *memoryResult) LastInsertId() (int64, error) { return 0, nil }
+func (r *memoryResult) RowsAffected() (int64, error) { return 1, nil }
+
+// Global memory database instance
+var memoryDBInstance *MemoryDB
+
+// InitMemoryDB initializes the in-memory database. With a snapshot path, documents, models and
+// chunks are restored from it and saved back every snapshotInterval and on Close.
+func InitMemoryDB(snapshotPath string, snapshotInterval time.Duration) *MemoryDB {
+	if memoryDBInstance == nil {
+		memoryDBInstance = NewMemoryDB()
+		if snapshotPath != "" {
+			if err := memoryDBInstance.EnablePersistence(snapshotPath, snapshotInterval); err != nil {
+				log.Printf("⚠️ Starting with an empty memory database: %v", err)
+			}
+		}
+		log.Println("✅ Memory database initialized")
+	}
+	return memoryDBInstance
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+func TestMemoryDBCountsDocumentAndChunkIDsSeparately(t *testing.T) {
+	db := NewMemoryDB()
+
+	var docIDs, chunkIDs []string
+	for i := 0; i < 3; i++ {
+		doc := &types.Document{Name: fmt.Sprintf("doc%d.txt", i)}
+		if err := db.CreateDocument(doc); err != nil {
+			t.Fatalf("CreateDocument: %v", err)
+		}
+		docIDs = append(docIDs, doc.ID)
+
+		for j := 0; j < 2; j++ {
+			chunk := &types.DocumentChunk{DocumentID: doc.ID, ChunkIndex: j, Content: "text"}
+			if err := db.CreateChunk(chunk); err != nil {
+				t.Fatalf("CreateChunk: %v", err)
+			}
+			chunkIDs = append(chunkIDs, chunk.ID)
+		}
+	}
+
+	for i, id := range docIDs {
+		if want := fmt.Sprintf("%d", i+1); id != want {
+			t.Errorf("document %d got ID %s, want %s", i, id, want)
+		}
+	}
+	for i, id := range chunkIDs {
+		if want := fmt.Sprintf("chunk_%d", i+1); id != want {
+			t.Errorf("chunk %d got ID %s, want %s", i, id, want)
+		}
+	}
+}
@@ -68,6 +68,22 @@ func createPostgresTables(db *sql.DB) error {
 		)`,
 	}
 
+	// Columns for the types.Document and types.DocumentChunk fields the original tables have no place for
+	queries = append(queries,
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS status TEXT`,
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS owner TEXT`,
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS visibility TEXT`,
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS metadata JSONB`,
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS chunks INTEGER DEFAULT 0`,
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS embeddings BOOLEAN DEFAULT FALSE`,
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS created_date TEXT`,
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS modified_date TEXT`,
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS external_id TEXT`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS documents_external_id_idx ON documents (external_id)`,
+		`ALTER TABLE document_chunks ADD COLUMN IF NOT EXISTS page INTEGER DEFAULT 0`,
+		`ALTER TABLE document_chunks ADD COLUMN IF NOT EXISTS created_date TEXT`,
+	)
+
 	for _, query := range queries {
 		if _, err := db.Exec(query); err != nil {
 			return fmt.Errorf("failed to create table: %w", err)
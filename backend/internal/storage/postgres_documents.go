@@ -0,0 +1,527 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/logging"
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+	"github.com/lib/pq"
+)
+
+// postgresUploadDateLayout is how created_at is reported as Document.UploadDate
+const postgresUploadDateLayout = "2006-01-02 15:04:05"
+
+// documentColumns are the documents columns read into a types.Document, in scanDocument order
+const documentColumns = `id, filename, original_name, path, size, type, created_at,
+	status, owner, visibility, metadata, chunks, embeddings, created_date, modified_date, external_id`
+
+// PostgresDB keeps document records in the documents table so several instances share them.
+// Columns map to types.Document as follows:
+//
+//	id            ID (SERIAL, as a decimal string)
+//	filename      the saved_filename metadata, or the base name of Path
+//	original_name Name
+//	path          Path, empty once an embeddings-only document's file is removed
+//	size, type    Size, Type
+//	created_at    UploadDate
+//	metadata      Metadata as a JSON object
+//	external_id   ExternalID, NULL when unset so the unique index only covers set IDs
+//
+// status, owner, visibility, chunks, embeddings, created_date and modified_date hold the fields
+// of the same name. The content column is not used, extracted text isn't part of a Document.
+type PostgresDB struct {
+	db *sql.DB
+}
+
+// NewPostgresDB stores documents in db, which must have the tables of InitPostgresDB
+func NewPostgresDB(db *sql.DB) *PostgresDB {
+	return &PostgresDB{db: db}
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanDocument reads a row of documentColumns
+func scanDocument(row rowScanner) (*types.Document, error) {
+	var (
+		id                                int64
+		filename, name, path              string
+		size                              sql.NullInt64
+		fileType, status, owner           sql.NullString
+		visibility, createdDate, modified sql.NullString
+		externalID                        sql.NullString
+		createdAt                         sql.NullTime
+		metadata                          []byte
+		chunks                            sql.NullInt64
+		embeddings                        sql.NullBool
+	)
+	err := row.Scan(&id, &filename, &name, &path, &size, &fileType, &createdAt,
+		&status, &owner, &visibility, &metadata, &chunks, &embeddings, &createdDate, &modified, &externalID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &types.Document{
+		ID:           strconv.FormatInt(id, 10),
+		Name:         name,
+		Type:         fileType.String,
+		Size:         size.Int64,
+		Status:       status.String,
+		Path:         path,
+		Chunks:       int(chunks.Int64),
+		Embeddings:   embeddings.Bool,
+		Owner:        owner.String,
+		Visibility:   visibility.String,
+		ExternalID:   externalID.String,
+		CreatedDate:  createdDate.String,
+		ModifiedDate: modified.String,
+	}
+	if createdAt.Valid {
+		doc.UploadDate = createdAt.Time.Format(postgresUploadDateLayout)
+	}
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &doc.Metadata); err != nil {
+			return nil, fmt.Errorf("invalid metadata of document %d: %w", id, err)
+		}
+	}
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]string)
+	}
+	if _, ok := doc.Metadata["saved_filename"]; !ok && filename != "" {
+		doc.Metadata["saved_filename"] = filename
+	}
+	return doc, nil
+}
+
+// documentValues returns the column values of a document, starting with filename
+func documentValues(doc *types.Document) ([]interface{}, error) {
+	// A nil map would be stored as the jsonb value null, which UpdateDocumentMetadata can't merge into
+	metadata, err := json.Marshal(nonNilMetadata(doc.Metadata))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	filename := doc.Metadata["saved_filename"]
+	if filename == "" && doc.Path != "" {
+		filename = filepath.Base(doc.Path)
+	}
+
+	externalID := sql.NullString{String: doc.ExternalID, Valid: doc.ExternalID != ""}
+
+	return []interface{}{filename, doc.Name, doc.Path, doc.Size, doc.Type, parseUploadDate(doc.UploadDate),
+		doc.Status, doc.Owner, doc.Visibility, metadata, doc.Chunks, doc.Embeddings, doc.CreatedDate, doc.ModifiedDate,
+		externalID}, nil
+}
+
+// nonNilMetadata returns metadata, or an empty map in place of nil so it marshals as {}
+func nonNilMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return map[string]string{}
+	}
+	return metadata
+}
+
+// parseUploadDate reads the upload date formats used by the services, defaulting to now
+func parseUploadDate(value string) time.Time {
+	for _, layout := range []string{postgresUploadDateLayout, time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
+
+// documentID converts a document ID to the documents primary key
+func documentID(id string) (int64, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("document not found: %s", id)
+	}
+	return n, nil
+}
+
+// checkExternalID returns ErrDuplicateExternalID if a document other than id has the external ID.
+// The unique index still rejects a concurrent insert, this only gives the common case a clear error.
+func (p *PostgresDB) checkExternalID(externalID, id string) error {
+	if externalID == "" {
+		return nil
+	}
+	existing, err := p.GetDocumentByExternalID(externalID)
+	if err != nil || existing.ID == id {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrDuplicateExternalID, externalID)
+}
+
+// CreateDocument inserts a document, assigning its ID unless it already has a numeric one
+func (p *PostgresDB) CreateDocument(doc *types.Document) error {
+	if err := p.checkExternalID(doc.ExternalID, doc.ID); err != nil {
+		return err
+	}
+	if doc.UploadDate == "" {
+		doc.UploadDate = time.Now().Format(postgresUploadDateLayout)
+	}
+	values, err := documentValues(doc)
+	if err != nil {
+		return err
+	}
+
+	const insertColumns = `filename, original_name, path, size, type, created_at,
+		status, owner, visibility, metadata, chunks, embeddings, created_date, modified_date, external_id`
+	const placeholders = `$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15`
+
+	if doc.ID == "" {
+		var id int64
+		err := p.db.QueryRow(`INSERT INTO documents (`+insertColumns+`) VALUES (`+placeholders+`) RETURNING id`,
+			values...).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to insert document: %w", err)
+		}
+		doc.ID = strconv.FormatInt(id, 10)
+	} else {
+		id, err := documentID(doc.ID)
+		if err != nil {
+			return fmt.Errorf("document IDs must be numeric, got %q", doc.ID)
+		}
+		_, err = p.db.Exec(`INSERT INTO documents (id, `+insertColumns+`) VALUES ($16, `+placeholders+`)`,
+			append(values, id)...)
+		if err != nil {
+			return fmt.Errorf("failed to insert document: %w", err)
+		}
+		// Keep the sequence ahead of explicitly chosen IDs
+		_, err = p.db.Exec(`SELECT setval(pg_get_serial_sequence('documents', 'id'), (SELECT MAX(id) FROM documents))`)
+		if err != nil {
+			return fmt.Errorf("failed to advance document IDs: %w", err)
+		}
+	}
+
+	log.Printf("Document created: %s (%s)", logging.Document(doc.Name, doc.ID), doc.ID)
+	return nil
+}
+
+func (p *PostgresDB) GetDocument(id string) (*types.Document, error) {
+	key, err := documentID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := scanDocument(p.db.QueryRow(`SELECT `+documentColumns+` FROM documents WHERE id = $1`, key))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("document not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document %s: %w", id, err)
+	}
+	return doc, nil
+}
+
+// GetDocumentByExternalID returns the document with the given external ID
+func (p *PostgresDB) GetDocumentByExternalID(externalID string) (*types.Document, error) {
+	doc, err := scanDocument(p.db.QueryRow(`SELECT `+documentColumns+` FROM documents WHERE external_id = $1`, externalID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("document not found: external ID %s", externalID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document with external ID %s: %w", externalID, err)
+	}
+	return doc, nil
+}
+
+func (p *PostgresDB) ListDocuments() ([]*types.Document, error) {
+	rows, err := p.db.Query(`SELECT ` + documentColumns + ` FROM documents ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	defer rows.Close()
+
+	docs := []*types.Document{}
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read document: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	log.Printf("Listed %d documents", len(docs))
+	return docs, nil
+}
+
+// QueryDocuments returns the documents matching the query's filters, sorted as requested.
+// Filtering happens in the process with the same rules as MemoryDB.
+func (p *PostgresDB) QueryDocuments(query types.DocumentQuery) ([]*types.Document, error) {
+	docs, err := p.ListDocuments()
+	if err != nil {
+		return nil, err
+	}
+	return filterDocuments(docs, query)
+}
+
+// UpdateDocument replaces the stored fields of an existing document
+func (p *PostgresDB) UpdateDocument(doc *types.Document) error {
+	key, err := documentID(doc.ID)
+	if err != nil {
+		return err
+	}
+	if err := p.checkExternalID(doc.ExternalID, doc.ID); err != nil {
+		return err
+	}
+	values, err := documentValues(doc)
+	if err != nil {
+		return err
+	}
+
+	result, err := p.db.Exec(`UPDATE documents SET filename = $1, original_name = $2, path = $3, size = $4,
+		type = $5, created_at = $6, status = $7, owner = $8, visibility = $9, metadata = $10, chunks = $11,
+		embeddings = $12, created_date = $13, modified_date = $14, external_id = $15 WHERE id = $16`, append(values, key)...)
+	if err != nil {
+		return fmt.Errorf("failed to update document %s: %w", doc.ID, err)
+	}
+	return expectRow(result, doc.ID)
+}
+
+// UpdateDocumentMetadata merges the given keys into a document's metadata in a single statement,
+// so concurrent updates from several instances don't overwrite each other
+func (p *PostgresDB) UpdateDocumentMetadata(id string, updates map[string]string) error {
+	key, err := documentID(id)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(nonNilMetadata(updates))
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	// Rows written before metadata was always an object may hold SQL NULL or the jsonb null,
+	// merging into either would not give an object
+	result, err := p.db.Exec(`UPDATE documents SET metadata =
+		CASE WHEN jsonb_typeof(metadata) = 'object' THEN metadata ELSE '{}'::jsonb END || $2::jsonb
+		WHERE id = $1`, key, data)
+	if err != nil {
+		return fmt.Errorf("failed to update metadata of document %s: %w", id, err)
+	}
+	return expectRow(result, id)
+}
+
+// DeleteDocument deletes a document together with its stored chunks
+func (p *PostgresDB) DeleteDocument(id string) error {
+	key, err := documentID(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // No-op after Commit
+
+	if _, err := tx.Exec(`DELETE FROM document_chunks WHERE document_id = $1`, key); err != nil {
+		return fmt.Errorf("failed to delete chunks of document %s: %w", id, err)
+	}
+	result, err := tx.Exec(`DELETE FROM documents WHERE id = $1`, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete document %s: %w", id, err)
+	}
+	if err := expectRow(result, id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete document %s: %w", id, err)
+	}
+
+	log.Printf("Document deleted: %s", id)
+	return nil
+}
+
+// expectRow turns a statement that affected no rows into a not found error
+func expectRow(result sql.Result, id string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check document %s: %w", id, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("document not found: %s", id)
+	}
+	return nil
+}
+
+// chunkColumns are the document_chunks columns read into a types.DocumentChunk, in scanChunk order
+const chunkColumns = `id, document_id, content, embedding, chunk_index, page, created_date`
+
+// scanChunk reads a row of chunkColumns
+func scanChunk(row rowScanner) (*types.DocumentChunk, error) {
+	var (
+		id, documentID   int64
+		content          string
+		embedding        []byte
+		chunkIndex, page sql.NullInt64
+		createdDate      sql.NullString
+	)
+	if err := row.Scan(&id, &documentID, &content, &embedding, &chunkIndex, &page, &createdDate); err != nil {
+		return nil, err
+	}
+
+	return &types.DocumentChunk{
+		ID:         strconv.FormatInt(id, 10),
+		DocumentID: strconv.FormatInt(documentID, 10),
+		Content:    content,
+		ChunkIndex: int(chunkIndex.Int64),
+		Page:       int(page.Int64),
+		Embedding:  decodeEmbedding(embedding),
+		CreatedAt:  createdDate.String,
+	}, nil
+}
+
+// encodeEmbedding stores an embedding as little-endian float64 values, nil when there is none
+func encodeEmbedding(embedding []float64) []byte {
+	if len(embedding) == 0 {
+		return nil
+	}
+	data := make([]byte, 8*len(embedding))
+	for i, v := range embedding {
+		binary.LittleEndian.PutUint64(data[8*i:], math.Float64bits(v))
+	}
+	return data
+}
+
+// decodeEmbedding reverses encodeEmbedding
+func decodeEmbedding(data []byte) []float64 {
+	if len(data) == 0 {
+		return nil
+	}
+	embedding := make([]float64, len(data)/8)
+	for i := range embedding {
+		embedding[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+	}
+	return embedding
+}
+
+// CreateChunk stores a chunk of a document, assigning its ID
+func (p *PostgresDB) CreateChunk(chunk *types.DocumentChunk) error {
+	documentKey, err := documentID(chunk.DocumentID)
+	if err != nil {
+		return err
+	}
+
+	var id int64
+	err = p.db.QueryRow(`INSERT INTO document_chunks (document_id, content, embedding, chunk_index, page, created_date)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		documentKey, chunk.Content, encodeEmbedding(chunk.Embedding), chunk.ChunkIndex, chunk.Page, chunk.CreatedAt).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("failed to insert chunk: %w", err)
+	}
+	chunk.ID = strconv.FormatInt(id, 10)
+
+	log.Printf("Chunk created for document: %s", chunk.DocumentID)
+	return nil
+}
+
+// ReplaceChunks replaces all chunks of a document with the given ones in one transaction
+func (p *PostgresDB) ReplaceChunks(id string, chunks []*types.DocumentChunk) error {
+	documentKey, err := documentID(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // No-op after Commit
+
+	if _, err := tx.Exec(`DELETE FROM document_chunks WHERE document_id = $1`, documentKey); err != nil {
+		return fmt.Errorf("failed to delete chunks of document %s: %w", id, err)
+	}
+	for _, chunk := range chunks {
+		var id int64
+		err := tx.QueryRow(`INSERT INTO document_chunks (document_id, content, embedding, chunk_index, page, created_date)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+			documentKey, chunk.Content, encodeEmbedding(chunk.Embedding), chunk.ChunkIndex, chunk.Page, chunk.CreatedAt).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to insert chunk: %w", err)
+		}
+		chunk.ID = strconv.FormatInt(id, 10)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to replace chunks of document %s: %w", id, err)
+	}
+
+	log.Printf("Replaced chunks of document %s with %d chunks", id, len(chunks))
+	return nil
+}
+
+func (p *PostgresDB) GetChunks(documentID string) ([]*types.DocumentChunk, error) {
+	key, err := strconv.ParseInt(documentID, 10, 64)
+	if err != nil {
+		return []*types.DocumentChunk{}, nil
+	}
+	return p.queryChunks(`SELECT `+chunkColumns+` FROM document_chunks WHERE document_id = $1 ORDER BY chunk_index, id`, key)
+}
+
+// SearchChunksByEmbedding returns the topK chunks of the given documents most similar to the
+// query embedding, best first, see MemoryDB.SearchChunksByEmbedding. Embeddings are stored as
+// plain bytes, so the similarity is computed here rather than in the database.
+func (p *PostgresDB) SearchChunksByEmbedding(query []float64, documentIDs []string, topK int) ([]ScoredChunk, error) {
+	if isZeroVector(query) {
+		return nil, ErrInvalidQueryEmbedding
+	}
+
+	var chunks []*types.DocumentChunk
+	var err error
+	if documentIDs == nil {
+		chunks, err = p.queryChunks(`SELECT ` + chunkColumns + ` FROM document_chunks WHERE embedding IS NOT NULL`)
+	} else {
+		keys := make([]int64, 0, len(documentIDs))
+		for _, id := range documentIDs {
+			if key, err := strconv.ParseInt(id, 10, 64); err == nil {
+				keys = append(keys, key)
+			}
+		}
+		chunks, err = p.queryChunks(`SELECT `+chunkColumns+` FROM document_chunks
+			WHERE embedding IS NOT NULL AND document_id = ANY($1)`, pq.Array(keys))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rankByEmbedding(query, chunks, topK), nil
+}
+
+// ListAllChunks returns every stored chunk, grouped by document
+func (p *PostgresDB) ListAllChunks() ([]*types.DocumentChunk, error) {
+	return p.queryChunks(`SELECT ` + chunkColumns + ` FROM document_chunks ORDER BY document_id, chunk_index, id`)
+}
+
+func (p *PostgresDB) queryChunks(query string, args ...interface{}) ([]*types.DocumentChunk, error) {
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chunks: %w", err)
+	}
+	defer rows.Close()
+
+	chunks := []*types.DocumentChunk{}
+	for rows.Next() {
+		chunk, err := scanChunk(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunk: %w", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list chunks: %w", err)
+	}
+	return chunks, nil
+}
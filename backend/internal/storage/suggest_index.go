@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/1DeliDolu/ki-ai-go/pkg/types"
+)
+
+// suggestNode is a node of the suggestion trie, keyed by rune
+type suggestNode struct {
+	children map[rune]*suggestNode
+	term     string         // Display form of the term ending at this node, "" if none does
+	docs     map[string]int // Document ID -> weight of the term in that document
+}
+
+// suggestIndex is a prefix trie over the terms of document titles, tags and content
+type suggestIndex struct {
+	root        *suggestNode
+	docTerms    map[string][]string       // Lowercased terms indexed per document, for removal
+	docContents map[string]map[string]int // Content terms per document, kept when only metadata changes
+}
+
+func newSuggestIndex() *suggestIndex {
+	return &suggestIndex{
+		root:        &suggestNode{children: make(map[rune]*suggestNode)},
+		docTerms:    make(map[string][]string),
+		docContents: make(map[string]map[string]int),
+	}
+}
+
+// set replaces the terms indexed for a document with its content and metadata terms. A nil
+// content keeps the content terms indexed before, so metadata changes don't need the document's
+// text. Keys of terms are display forms; lookups are case-insensitive.
+func (idx *suggestIndex) set(documentID string, content, metadata map[string]int) {
+	if content == nil {
+		content = idx.docContents[documentID]
+	}
+	idx.remove(documentID)
+	if len(content) > 0 {
+		idx.docContents[documentID] = content
+	}
+
+	terms := make(map[string]int, len(content)+len(metadata))
+	for term, weight := range content {
+		terms[term] += weight
+	}
+	for term, weight := range metadata {
+		terms[term] += weight
+	}
+
+	keys := make([]string, 0, len(terms))
+	for term, weight := range terms {
+		key := strings.ToLower(term)
+		if key == "" || weight <= 0 {
+			continue
+		}
+
+		node := idx.root
+		for _, r := range key {
+			child, ok := node.children[r]
+			if !ok {
+				child = &suggestNode{children: make(map[rune]*suggestNode)}
+				node.children[r] = child
+			}
+			node = child
+		}
+		if node.docs == nil {
+			node.docs = make(map[string]int)
+			node.term = term
+		}
+		if _, seen := node.docs[documentID]; !seen {
+			keys = append(keys, key)
+		}
+		node.docs[documentID] += weight
+	}
+	if len(keys) > 0 {
+		idx.docTerms[documentID] = keys
+	}
+}
+
+// remove drops a document's terms, pruning branches no other document uses
+func (idx *suggestIndex) remove(documentID string) {
+	for _, key := range idx.docTerms[documentID] {
+		path := []*suggestNode{idx.root}
+		runes := []rune(key)
+		for _, r := range runes {
+			next, ok := path[len(path)-1].children[r]
+			if !ok {
+				break
+			}
+			path = append(path, next)
+		}
+		if len(path) != len(runes)+1 {
+			continue
+		}
+
+		node := path[len(path)-1]
+		delete(node.docs, documentID)
+		if len(node.docs) == 0 {
+			node.docs = nil
+			node.term = ""
+		}
+		for i := len(path) - 1; i > 0; i-- {
+			if path[i].docs != nil || len(path[i].children) > 0 {
+				break
+			}
+			delete(path[i-1].children, runes[i-1])
+		}
+	}
+	delete(idx.docTerms, documentID)
+	delete(idx.docContents, documentID)
+}
+
+// find returns up to limit terms starting with prefix, counting only documents visible reports as
+// visible. Terms are ranked by their total weight, then by how many documents contain them.
+func (idx *suggestIndex) find(prefix string, visible func(documentID string) bool, limit int) []types.Suggestion {
+	node := idx.root
+	for _, r := range strings.ToLower(prefix) {
+		next, ok := node.children[r]
+		if !ok {
+			return []types.Suggestion{}
+		}
+		node = next
+	}
+
+	suggestions := []types.Suggestion{}
+	var walk func(n *suggestNode)
+	walk = func(n *suggestNode) {
+		if n.docs != nil {
+			suggestion := types.Suggestion{Term: n.term}
+			for documentID, weight := range n.docs {
+				if visible(documentID) {
+					suggestion.Score += weight
+					suggestion.Documents++
+				}
+			}
+			if suggestion.Documents > 0 {
+				suggestions = append(suggestions, suggestion)
+			}
+		}
+		for _, child := range n.children {
+			walk(child)
+		}
+	}
+	walk(node)
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Documents != b.Documents {
+			return a.Documents > b.Documents
+		}
+		return a.Term < b.Term
+	})
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
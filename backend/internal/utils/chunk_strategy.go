@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Chunking strategies
+const (
+	ChunkStrategyFixed     = "fixed"     // Fixed-size chunks with overlap, see ChunkText
+	ChunkStrategySentence  = "sentence"  // Whole sentences packed into chunks
+	ChunkStrategyParagraph = "paragraph" // Whole paragraphs packed into chunks
+)
+
+// ValidChunkStrategy reports whether strategy is a known chunking strategy
+func ValidChunkStrategy(strategy string) bool {
+	return strategy == ChunkStrategyFixed || strategy == ChunkStrategySentence || strategy == ChunkStrategyParagraph
+}
+
+// ChunkTextWithStrategy splits text into chunks of at most size runes. The sentence and paragraph
+// strategies only cut between sentences or paragraphs, except inside units longer than size, and
+// don't overlap so the chunks always join back to the text. Unknown strategies chunk fixed-size.
+func ChunkTextWithStrategy(text, strategy string, size, overlap int) []string {
+	switch strategy {
+	case ChunkStrategySentence:
+		return packUnits(SplitSentences(text), size, nil)
+	case ChunkStrategyParagraph:
+		// Paragraphs too long for a chunk are cut between sentences
+		return packUnits(SplitParagraphs(text), size, SplitSentences)
+	default:
+		return ChunkText(text, size, overlap)
+	}
+}
+
+// ChunkOverlapFor returns the overlap actually used by a strategy
+func ChunkOverlapFor(strategy string, overlap int) int {
+	if strategy == ChunkStrategySentence || strategy == ChunkStrategyParagraph {
+		return 0
+	}
+	return overlap
+}
+
+// SplitSentences splits text after sentence-ending punctuation followed by whitespace. Each
+// sentence keeps its trailing whitespace, so the sentences join back to text.
+func SplitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i, r := range text {
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		// Closing quotes and brackets belong to the sentence
+		end := i + utf8.RuneLen(r)
+		next, width := utf8.DecodeRuneInString(text[end:])
+		for width > 0 && strings.ContainsRune(`"')]»”’`, next) {
+			end += width
+			next, width = utf8.DecodeRuneInString(text[end:])
+		}
+		if width == 0 || !unicode.IsSpace(next) {
+			continue
+		}
+		for end < len(text) {
+			next, width = utf8.DecodeRuneInString(text[end:])
+			if !unicode.IsSpace(next) {
+				break
+			}
+			end += width
+		}
+		if end > start {
+			sentences = append(sentences, text[start:end])
+			start = end
+		}
+	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+	return sentences
+}
+
+// paragraphBreakPattern matches the blank lines between paragraphs
+var paragraphBreakPattern = regexp.MustCompile(`\n[ \t]*\n\s*`)
+
+// SplitParagraphs splits text at blank lines. Each paragraph keeps the blank lines after it, so
+// the paragraphs join back to text.
+func SplitParagraphs(text string) []string {
+	var paragraphs []string
+	start := 0
+	for _, match := range paragraphBreakPattern.FindAllStringIndex(text, -1) {
+		if match[1] > start {
+			paragraphs = append(paragraphs, text[start:match[1]])
+			start = match[1]
+		}
+	}
+	if start < len(text) {
+		paragraphs = append(paragraphs, text[start:])
+	}
+	return paragraphs
+}
+
+// packUnits joins consecutive units into chunks of at most size runes. Units longer than size are
+// packed from their parts when split is set, and cut into fixed-size pieces otherwise.
+func packUnits(units []string, size int, split func(string) []string) []string {
+	if size <= 0 {
+		return nil
+	}
+
+	var chunks []string
+	var current strings.Builder
+	currentLen := 0
+	flush := func() {
+		if currentLen > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			currentLen = 0
+		}
+	}
+
+	for _, unit := range units {
+		n := utf8.RuneCountInString(unit)
+		if n > size {
+			flush()
+			if split != nil {
+				chunks = append(chunks, packUnits(split(unit), size, nil)...)
+			} else {
+				chunks = append(chunks, ChunkText(unit, size, 0)...)
+			}
+			continue
+		}
+		if currentLen+n > size {
+			flush()
+		}
+		current.WriteString(unit)
+		currentLen += n
+	}
+	flush()
+	return chunks
+}
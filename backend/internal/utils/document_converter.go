@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/processors"
 )
 
 // DocumentConverter provides document format conversion
@@ -16,8 +18,8 @@ func NewDocumentConverter() *DocumentConverter {
 	return &DocumentConverter{}
 }
 
-// ConvertToMarkdown converts document to markdown format
-func (dc *DocumentConverter) ConvertToMarkdown(inputPath, outputPath string) error {
+// ConvertToMarkdown converts document to markdown format, written in outputEncoding (UTF-8 when empty)
+func (dc *DocumentConverter) ConvertToMarkdown(inputPath, outputPath, outputEncoding string) error {
 	content, err := os.ReadFile(inputPath)
 	if err != nil {
 		return fmt.Errorf("failed to read input file: %w", err)
@@ -41,11 +43,11 @@ func (dc *DocumentConverter) ConvertToMarkdown(inputPath, outputPath string) err
 		markdown = fmt.Sprintf("# %s\n\n%s", filepath.Base(inputPath), string(content))
 	}
 
-	return os.WriteFile(outputPath, []byte(markdown), 0644)
+	return writeEncoded(outputPath, markdown, outputEncoding)
 }
 
-// ConvertToHTML converts document to HTML format
-func (dc *DocumentConverter) ConvertToHTML(inputPath, outputPath string) error {
+// ConvertToHTML converts document to HTML format, written in outputEncoding (UTF-8 when empty)
+func (dc *DocumentConverter) ConvertToHTML(inputPath, outputPath, outputEncoding string) error {
 	content, err := os.ReadFile(inputPath)
 	if err != nil {
 		return fmt.Errorf("failed to read input file: %w", err)
@@ -77,11 +79,16 @@ func (dc *DocumentConverter) ConvertToHTML(inputPath, outputPath string) error {
 </html>`, filepath.Base(inputPath), string(content))
 	}
 
-	return os.WriteFile(outputPath, []byte(html), 0644)
+	// Declare the charset the file is actually written in
+	if charset, err := NormalizeOutputEncoding(outputEncoding); err == nil && charset != DefaultOutputEncoding {
+		html = strings.Replace(html, `<meta charset="UTF-8">`, fmt.Sprintf(`<meta charset="%s">`, charset), 1)
+	}
+
+	return writeEncoded(outputPath, html, outputEncoding)
 }
 
-// ConvertToPlainText converts document to plain text
-func (dc *DocumentConverter) ConvertToPlainText(inputPath, outputPath string) error {
+// ConvertToPlainText converts document to plain text, written in outputEncoding (UTF-8 when empty)
+func (dc *DocumentConverter) ConvertToPlainText(inputPath, outputPath, outputEncoding string) error {
 	content, err := os.ReadFile(inputPath)
 	if err != nil {
 		return fmt.Errorf("failed to read input file: %w", err)
@@ -104,7 +111,7 @@ func (dc *DocumentConverter) ConvertToPlainText(inputPath, outputPath string) er
 		plainText = string(content)
 	}
 
-	return os.WriteFile(outputPath, []byte(plainText), 0644)
+	return writeEncoded(outputPath, plainText, outputEncoding)
 }
 
 // Helper methods for conversion
@@ -201,18 +208,7 @@ func (dc *DocumentConverter) convertTextToHTML(text string) string {
 }
 
 func (dc *DocumentConverter) convertMarkdownToText(markdown string) string {
-	// Remove markdown formatting
-	text := markdown
-
-	// Remove headers
-	text = regexp.MustCompile(`(?m)^#+\s*`).ReplaceAllString(text, "")
-
-	// Remove formatting
-	text = regexp.MustCompile(`\*\*(.+?)\*\*`).ReplaceAllString(text, "$1")
-	text = regexp.MustCompile(`\*(.+?)\*`).ReplaceAllString(text, "$1")
-	text = regexp.MustCompile("`(.+?)`").ReplaceAllString(text, "$1")
-
-	return text
+	return processors.StripMarkdown(markdown)
 }
 
 func (dc *DocumentConverter) convertHTMLToMarkdown(htmlContent string) string {
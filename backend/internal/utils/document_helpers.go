@@ -1,9 +1,16 @@
 package utils
 
 import (
+	"crypto/rand"
+	"fmt"
 	"html"
+	"math"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // FileInfo represents comprehensive file information
@@ -81,6 +88,188 @@ func CalculateComplexityScore(text string) int {
 	return complexity
 }
 
+// CalculateReadabilityScore estimates Flesch reading ease (0-100, higher is easier to read)
+func CalculateReadabilityScore(text string) int {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return 0
+	}
+
+	sentences := strings.FieldsFunc(text, func(c rune) bool {
+		return c == '.' || c == '!' || c == '?'
+	})
+	if len(sentences) == 0 {
+		sentences = []string{text}
+	}
+
+	syllables := 0
+	for _, word := range words {
+		syllables += countSyllables(word)
+	}
+
+	wordsPerSentence := float64(len(words)) / float64(len(sentences))
+	syllablesPerWord := float64(syllables) / float64(len(words))
+	score := int(206.835 - 1.015*wordsPerSentence - 84.6*syllablesPerWord)
+
+	if score < 0 {
+		score = 0
+	} else if score > 100 {
+		score = 100
+	}
+
+	return score
+}
+
+// countSyllables approximates syllables by counting vowel groups
+func countSyllables(word string) int {
+	count := 0
+	inVowel := false
+	for _, r := range strings.ToLower(word) {
+		isVowel := strings.ContainsRune("aeiouyäöüıâîû", r)
+		if isVowel && !inVowel {
+			count++
+		}
+		inVowel = isVowel
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+// RelevanceScore returns the fraction of the query's distinct terms found in text, ignoring terms
+// shorter than three letters. A query without such terms scores 1.
+func RelevanceScore(query, text string) float64 {
+	isSeparator := func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) }
+
+	terms := make(map[string]bool)
+	for _, term := range strings.FieldsFunc(strings.ToLower(query), isSeparator) {
+		if utf8.RuneCountInString(term) >= 3 {
+			terms[term] = true
+		}
+	}
+	if len(terms) == 0 {
+		return 1
+	}
+
+	found := 0
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), isSeparator) {
+		if terms[word] && !words[word] {
+			words[word] = true
+			found++
+		}
+	}
+	return float64(found) / float64(len(terms))
+}
+
+// stopWords are frequent English, German and Turkish words that make poor search terms
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "was": true, "with": true, "that": true,
+	"this": true, "from": true, "have": true, "not": true, "but": true, "you": true, "all": true,
+	"can": true, "will": true, "has": true, "its": true, "our": true, "they": true, "which": true,
+	"der": true, "die": true, "das": true, "und": true, "ist": true, "mit": true, "den": true,
+	"dem": true, "des": true, "ein": true, "eine": true, "nicht": true, "auf": true, "für": true,
+	"von": true, "sie": true, "auch": true, "sich": true, "bir": true, "için": true, "ile": true,
+	"olarak": true, "daha": true, "gibi": true, "çok": true, "kadar": true,
+}
+
+// TopTerms returns the limit most frequent words of text with their counts, skipping stop words,
+// numbers and words shorter than three letters
+func TopTerms(text string, limit int) map[string]int {
+	isSeparator := func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) }
+
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), isSeparator) {
+		if utf8.RuneCountInString(word) < 3 || stopWords[word] {
+			continue
+		}
+		if strings.IndexFunc(word, unicode.IsLetter) < 0 {
+			continue
+		}
+		counts[word]++
+	}
+
+	words := make([]string, 0, len(counts))
+	for word := range counts {
+		words = append(words, word)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] != counts[words[j]] {
+			return counts[words[i]] > counts[words[j]]
+		}
+		return words[i] < words[j]
+	})
+	if len(words) > limit {
+		words = words[:limit]
+	}
+
+	top := make(map[string]int, len(words))
+	for _, word := range words {
+		top[word] = counts[word]
+	}
+	return top
+}
+
+// ChunkText splits text into chunks of at most size runes, each overlapping the previous one by overlap runes
+func ChunkText(text string, size, overlap int) []string {
+	runes := []rune(text)
+	if size <= 0 || len(runes) == 0 {
+		return nil
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	var chunks []string
+	step := size - overlap
+	for start := 0; start < len(runes); start += step {
+		end := start + size
+		if end > len(runes) {
+			end = len(runes)
+		}
+		chunks = append(chunks, string(runes[start:end]))
+		if end == len(runes) {
+			break
+		}
+	}
+	return chunks
+}
+
+// JoinChunks reverses ChunkText, dropping the overlap repeated at the start of each chunk
+func JoinChunks(chunks []string, overlap int) string {
+	var text strings.Builder
+	for i, chunk := range chunks {
+		runes := []rune(chunk)
+		if i > 0 && overlap > 0 {
+			if overlap >= len(runes) {
+				continue
+			}
+			runes = runes[overlap:]
+		}
+		text.WriteString(string(runes))
+	}
+	return text.String()
+}
+
+// CosineSimilarity returns the cosine similarity of two vectors, or 0 if they can't be compared
+func CosineSimilarity(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
 // StripHTML removes HTML tags from text
 func StripHTML(content string) string {
 	re := regexp.MustCompile(`<[^>]*>`)
@@ -94,6 +283,17 @@ func CountWords(text string) int {
 	return len(words)
 }
 
+// CharsPerToken is the average characters per token of LLaMA-style tokenizers on prose
+const CharsPerToken = 4
+
+// EstimateTokens roughly estimates the tokens text takes in a model's context. It errs high,
+// taking the larger of the character and word based estimates.
+func EstimateTokens(text string) int {
+	byChars := (utf8.RuneCountInString(text) + CharsPerToken - 1) / CharsPerToken
+	byWords := CountWords(text) * 4 / 3
+	return max(byChars, byWords)
+}
+
 // ExtractLinks extracts URLs from text
 func ExtractLinks(text string) []string {
 	urlPattern := `https?://[^\s<>"{}|\\^` + "`" + `\[\]]+`
@@ -106,6 +306,10 @@ func TruncateString(s string, length int) string {
 	if len(s) <= length {
 		return s
 	}
+	// Cut on a rune boundary so multi-byte characters aren't split
+	for length > 0 && !utf8.RuneStart(s[length]) {
+		length--
+	}
 	return s[:length] + "..."
 }
 
@@ -119,3 +323,15 @@ func CleanText(text string) string {
 
 	return text
 }
+
+// NewUUID returns a random RFC 4122 version 4 UUID
+func NewUUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand should never fail; fall back to a time-based value
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // Version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // Variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -1,13 +1,21 @@
 package utils
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
+	"github.com/1DeliDolu/ki-ai-go/internal/logging"
 	"github.com/1DeliDolu/ki-ai-go/internal/processors"
 )
 
@@ -18,6 +26,91 @@ type SearchOptions struct {
 	UseRegex      bool `json:"use_regex"` // Added missing field
 	MaxMatches    int  `json:"max_matches"`
 	ContextLines  int  `json:"context_lines"`
+	// Also match document metadata, including user-supplied fields
+	IncludeMetadata bool `json:"include_metadata"`
+	// Characters of the per-document preview snippet, 0 disables it
+	PreviewLength int `json:"preview_length"`
+	// Return only the preview and match count, without the match list
+	PreviewOnly bool `json:"preview_only"`
+	// Match words within this many edits of the query words, 0 disables fuzzy matching.
+	// Ignored for regex searches.
+	FuzzyThreshold int `json:"fuzzy_threshold"`
+	// Treat uppercase AND, OR and NOT in the query as operators between terms, e.g.
+	// "error AND timeout NOT retry". Each term is matched with the other options.
+	BooleanMode bool `json:"boolean_mode"`
+	// Page through the matches of all documents, see PaginateResults. Limit 0 returns everything.
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+	// Characters shown on each side of the match in Match.Snippet, DefaultSnippetContext when 0
+	SnippetContext int `json:"snippet_context"`
+}
+
+// DefaultSnippetContext is the number of characters around a match in its snippet
+const DefaultSnippetContext = 40
+
+// booleanTerm is one term of a boolean query
+type booleanTerm struct {
+	Text    string
+	Negated bool
+}
+
+// booleanQuery is a parsed boolean query: a line matches if it matches every term of any group.
+// NOT binds tighter than AND, which binds tighter than OR.
+type booleanQuery [][]booleanTerm
+
+// parseBooleanQuery splits a query on the AND, OR and NOT keywords. Words not separated by a
+// keyword form a single phrase term.
+func parseBooleanQuery(query string) booleanQuery {
+	var (
+		q       booleanQuery
+		group   []booleanTerm
+		words   []string
+		negated bool
+	)
+	flushTerm := func() {
+		if len(words) > 0 {
+			group = append(group, booleanTerm{Text: strings.Join(words, " "), Negated: negated})
+		}
+		words, negated = nil, false
+	}
+	flushGroup := func() {
+		flushTerm()
+		if len(group) > 0 {
+			q = append(q, group)
+		}
+		group = nil
+	}
+
+	for _, word := range strings.Fields(query) {
+		switch word {
+		case "AND":
+			flushTerm()
+		case "OR":
+			flushGroup()
+		case "NOT":
+			flushTerm()
+			negated = true
+		default:
+			words = append(words, word)
+		}
+	}
+	flushGroup()
+	return q
+}
+
+// positiveTerms returns the distinct non-negated terms of the query
+func (q booleanQuery) positiveTerms() []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, group := range q {
+		for _, term := range group {
+			if !term.Negated && !seen[term.Text] {
+				seen[term.Text] = true
+				terms = append(terms, term.Text)
+			}
+		}
+	}
+	return terms
 }
 
 // SearchResult represents search results for a document
@@ -26,14 +119,40 @@ type SearchResult struct {
 	FileName     string    `json:"file_name"`
 	Matches      []Match   `json:"matches"`
 	TotalMatches int       `json:"total_matches"`
+	Preview      string    `json:"preview,omitempty"` // Snippet around the first match for list display
 	ProcessedAt  time.Time `json:"processed_at"`
 }
 
+// PagedMatch is a match together with the document it was found in
+type PagedMatch struct {
+	FilePath string `json:"file_path"`
+	FileName string `json:"file_name"`
+	Match
+}
+
+// SearchPage is one page of the matches across several documents
+type SearchPage struct {
+	Matches        []PagedMatch `json:"matches"`
+	Offset         int          `json:"offset"`
+	Limit          int          `json:"limit"`
+	TotalAvailable int          `json:"total_available"`
+	HasMore        bool         `json:"has_more"`
+}
+
 // Match represents a single search match
 type Match struct {
 	LineNumber int    `json:"line_number"`
 	Content    string `json:"content"`
 	Context    string `json:"context"`
+	// Character offsets of the match within Content, end exclusive. Both are 0 when the line
+	// matched without a locatable span, e.g. a boolean query of only NOT terms.
+	ColumnStart int    `json:"column_start"`
+	ColumnEnd   int    `json:"column_end"`
+	Snippet     string `json:"snippet,omitempty"` // The match wrapped in <mark> with surrounding text
+	// Set for metadata matches only
+	MetadataKey  string `json:"metadata_key,omitempty"`
+	MatchedValue string `json:"matched_value,omitempty"`
+	ElementIndex *int   `json:"element_index,omitempty"` // Position within a list-valued field
 }
 
 // DocumentSearcher provides document search functionality
@@ -50,14 +169,25 @@ func NewDocumentSearcher() *DocumentSearcher {
 
 // SearchInMultipleDocuments searches for a query in multiple documents
 func (ds *DocumentSearcher) SearchInMultipleDocuments(paths []string, query string, options SearchOptions) (map[string]*SearchResult, error) {
-	log.Printf("🔍 Searching in %d documents for: %s", len(paths), query)
+	return ds.SearchInMultipleDocumentsContext(context.Background(), paths, query, options)
+}
+
+// SearchInMultipleDocumentsContext searches documents one after another until ctx is cancelled.
+// A cancelled search returns the results found so far together with ctx's error.
+func (ds *DocumentSearcher) SearchInMultipleDocumentsContext(ctx context.Context, paths []string, query string, options SearchOptions) (map[string]*SearchResult, error) {
+	log.Printf("🔍 Searching in %d documents for: %s", len(paths), logging.Text(query))
 
 	results := make(map[string]*SearchResult)
 
-	for _, path := range paths {
-		result, err := ds.SearchInDocument(path, query, options)
+	for i, path := range paths {
+		if err := ctx.Err(); err != nil {
+			log.Printf("⏹️ Search stopped after %d of %d documents: %v", i, len(paths), err)
+			return results, err
+		}
+
+		result, err := ds.SearchInDocumentContext(ctx, path, query, options)
 		if err != nil {
-			log.Printf("❌ Error searching %s: %v", path, err)
+			log.Printf("❌ Error searching %s: %v", logging.File(path), err)
 			continue
 		}
 
@@ -65,6 +195,9 @@ func (ds *DocumentSearcher) SearchInMultipleDocuments(paths []string, query stri
 			results[path] = result
 		}
 	}
+	if err := ctx.Err(); err != nil {
+		return results, err // The last document was abandoned
+	}
 
 	log.Printf("✅ Search completed. Found matches in %d out of %d documents", len(results), len(paths))
 	return results, nil
@@ -72,10 +205,15 @@ func (ds *DocumentSearcher) SearchInMultipleDocuments(paths []string, query stri
 
 // SearchInDocument searches for a query within a single document
 func (ds *DocumentSearcher) SearchInDocument(path, query string, options SearchOptions) (*SearchResult, error) {
-	log.Printf("🔍 Searching in document: %s for query: %s", filepath.Base(path), query)
+	return ds.SearchInDocumentContext(context.Background(), path, query, options)
+}
+
+// SearchInDocumentContext searches a single document, abandoning its extraction when ctx is cancelled
+func (ds *DocumentSearcher) SearchInDocumentContext(ctx context.Context, path, query string, options SearchOptions) (*SearchResult, error) {
+	log.Printf("🔍 Searching in document: %s for query: %s", logging.File(path), logging.Text(query))
 
 	// Process the document
-	content, err := ds.manager.ProcessDocument(path)
+	content, err := ds.manager.ProcessDocumentContext(ctx, path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to process document: %w", err)
 	}
@@ -90,11 +228,38 @@ func (ds *DocumentSearcher) SearchInDocument(path, query string, options SearchO
 		TotalMatches: len(matches),
 		ProcessedAt:  time.Now(),
 	}
+	ds.applyPreview(result, query, options)
 
-	log.Printf("✅ Found %d matches in %s", len(matches), filepath.Base(path))
+	log.Printf("✅ Found %d matches in %s", len(matches), logging.File(path))
 	return result, nil
 }
 
+// PaginateResults returns the page of matches selected by options.Offset and options.Limit.
+// Documents are ordered by path and matches keep their order within a document, so pages are
+// stable for an unchanged document set.
+func (ds *DocumentSearcher) PaginateResults(results map[string]*SearchResult, options SearchOptions) *SearchPage {
+	paths := make([]string, 0, len(results))
+	for path := range results {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	page := &SearchPage{Matches: []PagedMatch{}, Offset: options.Offset, Limit: options.Limit}
+	for _, path := range paths {
+		result := results[path]
+		for _, match := range result.Matches {
+			index := page.TotalAvailable
+			page.TotalAvailable++
+			if index < options.Offset || (options.Limit > 0 && index >= options.Offset+options.Limit) {
+				continue
+			}
+			page.Matches = append(page.Matches, PagedMatch{FilePath: result.FilePath, FileName: result.FileName, Match: match})
+		}
+	}
+	page.HasMore = options.Offset+len(page.Matches) < page.TotalAvailable
+	return page
+}
+
 // SearchByFileType searches in documents of specific types
 func (ds *DocumentSearcher) SearchByFileType(basePath, fileType, query string, options SearchOptions) (map[string]*SearchResult, error) {
 	// This would require a file system walker - simplified implementation
@@ -107,46 +272,201 @@ func (ds *DocumentSearcher) SearchByFileType(basePath, fileType, query string, o
 
 // SearchWithMetadata searches in both content and metadata
 func (ds *DocumentSearcher) SearchWithMetadata(paths []string, query string, options SearchOptions) (map[string]*SearchResult, error) {
+	return ds.SearchWithDocumentMetadata(paths, nil, query, options)
+}
+
+// SearchWithDocumentMetadata searches content and metadata, also matching stored document
+// metadata (keyed by path) that the processors don't know about, such as user fields
+func (ds *DocumentSearcher) SearchWithDocumentMetadata(paths []string, documentMetadata map[string]map[string]string, query string, options SearchOptions) (map[string]*SearchResult, error) {
+	return ds.SearchWithDocumentMetadataContext(context.Background(), paths, documentMetadata, query, options)
+}
+
+// SearchWithDocumentMetadataContext searches like SearchWithDocumentMetadata until ctx is cancelled.
+// A cancelled search returns the results found so far together with ctx's error.
+func (ds *DocumentSearcher) SearchWithDocumentMetadataContext(ctx context.Context, paths []string, documentMetadata map[string]map[string]string, query string, options SearchOptions) (map[string]*SearchResult, error) {
 	log.Printf("🔍 Searching with metadata in %d documents", len(paths))
 
 	results := make(map[string]*SearchResult)
 
-	for _, path := range paths {
-		// Process the document
-		content, err := ds.manager.ProcessDocument(path)
+	for i, path := range paths {
+		if err := ctx.Err(); err != nil {
+			log.Printf("⏹️ Search stopped after %d of %d documents: %v", i, len(paths), err)
+			return results, err
+		}
+
+		result, err := ds.searchDocumentWithMetadata(ctx, path, documentMetadata[path], query, options)
 		if err != nil {
 			continue
 		}
+		if result.TotalMatches > 0 {
+			results[path] = result
+		}
+	}
 
-		// Search in content
-		contentMatches := ds.searchInText(content.Text, query, options)
+	return results, ctx.Err()
+}
 
-		// Search in metadata - create Match objects properly
-		var metadataMatches []Match
-		for key, value := range content.Metadata {
-			if ds.matchesQuery(key+": "+value, query, options) {
-				metadataMatches = append(metadataMatches, Match{
-					LineNumber: 0, // Metadata doesn't have line numbers
-					Content:    fmt.Sprintf("[META] %s: %s", key, value),
-					Context:    fmt.Sprintf("Metadata field: %s", key),
-				})
+// searchDocumentWithMetadata searches a document's content, its processor metadata and the given
+// stored metadata
+func (ds *DocumentSearcher) searchDocumentWithMetadata(ctx context.Context, path string, documentMetadata map[string]string, query string, options SearchOptions) (*SearchResult, error) {
+	// Process the document
+	content, err := ds.manager.ProcessDocumentContext(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process document: %w", err)
+	}
+
+	// Search in content
+	contentMatches := ds.searchInText(content.Text, query, options)
+
+	// Search in metadata - list-valued fields are matched element by element
+	var metadataMatches []Match
+	for key, value := range content.Metadata {
+		metadataMatches = append(metadataMatches, ds.searchMetadataField(key, value, query, options)...)
+	}
+	for key, value := range documentMetadata {
+		metadataMatches = append(metadataMatches, ds.searchMetadataField(key, value, query, options)...)
+	}
+
+	// Combine results
+	allMatches := append(contentMatches, metadataMatches...)
+	result := &SearchResult{
+		FilePath:     path,
+		FileName:     filepath.Base(path),
+		Matches:      allMatches,
+		TotalMatches: len(allMatches),
+		ProcessedAt:  time.Now(),
+	}
+	ds.applyPreview(result, query, options)
+	return result, nil
+}
+
+// StreamSearch searches documents with up to workers at a time (all CPUs when workers <= 0) and
+// sends each document's result as soon as it has matches. Metadata is searched too when
+// options.IncludeMetadata is set. The channel is closed once every document was searched or ctx
+// was cancelled.
+func (ds *DocumentSearcher) StreamSearch(ctx context.Context, paths []string, documentMetadata map[string]map[string]string, query string, options SearchOptions, workers int) <-chan *SearchResult {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	log.Printf("🔍 Streaming search in %d documents with %d workers for: %s", len(paths), workers, logging.Text(query))
+
+	results := make(chan *SearchResult)
+	go func() {
+		defer close(results)
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, workers)
+		for _, path := range paths {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				break
 			}
+
+			wg.Add(1)
+			go func(path string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				var result *SearchResult
+				var err error
+				if options.IncludeMetadata {
+					result, err = ds.searchDocumentWithMetadata(ctx, path, documentMetadata[path], query, options)
+				} else {
+					result, err = ds.SearchInDocumentContext(ctx, path, query, options)
+				}
+				if err != nil {
+					log.Printf("❌ Error searching %s: %v", logging.File(path), err)
+					return
+				}
+				if result.TotalMatches == 0 {
+					return
+				}
+
+				select {
+				case results <- result:
+				case <-ctx.Done():
+				}
+			}(path)
 		}
+		wg.Wait()
+	}()
+	return results
+}
 
-		// Combine results
-		allMatches := append(contentMatches, metadataMatches...)
-		if len(allMatches) > 0 {
-			results[path] = &SearchResult{
-				FilePath:     path,
-				FileName:     filepath.Base(path),
-				Matches:      allMatches,
-				TotalMatches: len(allMatches),
-				ProcessedAt:  time.Now(),
-			}
+// searchMetadataField matches a metadata field, reporting which element matched for list values
+func (ds *DocumentSearcher) searchMetadataField(key, value, query string, options SearchOptions) []Match {
+	var matches []Match
+
+	elements, isList := ParseMetadataList(value)
+	if !isList {
+		if ds.matchesQuery(key+": "+value, query, options) {
+			matches = append(matches, Match{
+				LineNumber:   0, // Metadata doesn't have line numbers
+				Content:      fmt.Sprintf("[META] %s: %s", key, value),
+				Context:      fmt.Sprintf("Metadata field: %s", key),
+				MetadataKey:  key,
+				MatchedValue: value,
+			})
 		}
+		return matches
 	}
 
-	return results, nil
+	for i, element := range elements {
+		if ds.matchesQuery(key+": "+element, query, options) {
+			index := i
+			matches = append(matches, Match{
+				LineNumber:   0,
+				Content:      fmt.Sprintf("[META] %s[%d]: %s", key, i, element),
+				Context:      fmt.Sprintf("Metadata field: %s (element %d of %d)", key, i+1, len(elements)),
+				MetadataKey:  key,
+				MatchedValue: element,
+				ElementIndex: &index,
+			})
+		}
+	}
+
+	return matches
+}
+
+// ParseMetadataList decodes a list-valued metadata field stored as a JSON array.
+// The second return value is false for plain scalar values.
+func ParseMetadataList(value string) ([]string, bool) {
+	trimmed := strings.TrimSpace(value)
+	if !strings.HasPrefix(trimmed, "[") || !strings.HasSuffix(trimmed, "]") {
+		return nil, false
+	}
+
+	var raw []interface{}
+	if err := json.Unmarshal([]byte(trimmed), &raw); err != nil {
+		return nil, false
+	}
+
+	elements := make([]string, len(raw))
+	for i, item := range raw {
+		if str, ok := item.(string); ok {
+			elements[i] = str
+		} else {
+			elements[i] = fmt.Sprint(item)
+		}
+	}
+	return elements, true
+}
+
+// FormatMetadataList encodes a list of values for storage in a metadata field
+func FormatMetadataList(values []string) string {
+	if values == nil {
+		values = []string{}
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+// SearchText returns the lines of text matching query
+func (ds *DocumentSearcher) SearchText(text, query string, options SearchOptions) []Match {
+	return ds.searchInText(text, query, options)
 }
 
 // searchInText performs the actual text search
@@ -155,19 +475,48 @@ func (ds *DocumentSearcher) searchInText(text, query string, options SearchOptio
 
 	lines := strings.Split(text, "\n")
 
+	var parsed booleanQuery
+	if options.BooleanMode {
+		parsed = parseBooleanQuery(query)
+	}
+
 	for i, line := range lines {
-		if ds.matchesQuery(line, query, options) {
-			// Extract context around match
-			context := ds.extractContext(lines, i, options.ContextLines)
+		var hits []string
+		matched := false
+		if options.BooleanMode {
+			matched, hits = ds.matchesBoolean(line, parsed, options)
+		} else {
+			matched = ds.matchesQuery(line, query, options)
+		}
+
+		if !matched {
+			continue
+		}
+
+		// Extract context around match
+		context := ds.extractContext(lines, i, options.ContextLines)
+		if len(hits) > 0 {
+			context += "\n[terms: " + strings.Join(hits, ", ") + "]"
+		}
+
+		// Every occurrence on the line is its own match
+		spans := ds.matchSpans(line, query, hits, options)
+		if len(spans) == 0 {
+			spans = [][]int{{0, 0}}
+		}
+		for _, span := range spans {
 			matches = append(matches, Match{
-				LineNumber: i + 1, // 1-based line numbers
-				Content:    line,
-				Context:    context,
+				LineNumber:  i + 1, // 1-based line numbers
+				Content:     line,
+				Context:     context,
+				ColumnStart: utf8.RuneCountInString(line[:span[0]]),
+				ColumnEnd:   utf8.RuneCountInString(line[:span[1]]),
+				Snippet:     ds.buildSnippet(line, span, options.SnippetContext),
 			})
 
 			// Check max matches limit
 			if options.MaxMatches > 0 && len(matches) >= options.MaxMatches {
-				break
+				return matches
 			}
 		}
 	}
@@ -175,8 +524,157 @@ func (ds *DocumentSearcher) searchInText(text, query string, options SearchOptio
 	return matches
 }
 
+// matchSpans returns the byte ranges of the non-overlapping occurrences of query in line. For boolean
+// queries these are the occurrences of terms, the positive terms that hit.
+func (ds *DocumentSearcher) matchSpans(line, query string, terms []string, options SearchOptions) [][]int {
+	if options.BooleanMode {
+		options.BooleanMode = false
+		var spans [][]int
+		for _, term := range terms {
+			spans = append(spans, ds.matchSpans(line, term, nil, options)...)
+		}
+		sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+		merged := [][]int{}
+		for _, span := range spans {
+			if len(merged) > 0 && span[0] < merged[len(merged)-1][1] {
+				continue // Overlaps an earlier term's occurrence
+			}
+			merged = append(merged, span)
+		}
+		return merged
+	}
+
+	if options.FuzzyThreshold > 0 && !options.UseRegex {
+		queryWords := fuzzyQueryWords(ds.foldCase(query, options))
+		var spans [][]int
+		for _, loc := range fuzzyWordPattern.FindAllStringIndex(line, -1) {
+			if fuzzyMatchesWord(ds.foldCase(line[loc[0]:loc[1]], options), queryWords, options.FuzzyThreshold) {
+				spans = append(spans, loc)
+			}
+		}
+		return spans
+	}
+
+	pattern := query
+	if !options.UseRegex {
+		pattern = regexp.QuoteMeta(query)
+		if options.WholeWords {
+			pattern = `\b` + pattern + `\b`
+		}
+	}
+	if !options.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil
+	}
+
+	var spans [][]int
+	for _, loc := range regex.FindAllStringIndex(line, -1) {
+		if loc[0] < loc[1] { // Empty regex matches can't be highlighted
+			spans = append(spans, loc)
+		}
+	}
+	return spans
+}
+
+// buildSnippet returns the span of line wrapped in <mark>, with up to contextChars characters on each side
+func (ds *DocumentSearcher) buildSnippet(line string, span []int, contextChars int) string {
+	if contextChars <= 0 {
+		contextChars = DefaultSnippetContext
+	}
+
+	before := []rune(line[:span[0]])
+	after := []rune(line[span[1]:])
+
+	snippet := string(before)
+	if len(before) > contextChars {
+		snippet = "..." + string(before[len(before)-contextChars:])
+	}
+	if span[0] < span[1] {
+		snippet += "<mark>" + line[span[0]:span[1]] + "</mark>"
+	}
+	if len(after) > contextChars {
+		snippet += string(after[:contextChars]) + "..."
+	} else {
+		snippet += string(after)
+	}
+	return strings.TrimSpace(snippet)
+}
+
+// applyPreview sets the result's preview snippet from its first match and drops the
+// match list when only the preview was requested
+func (ds *DocumentSearcher) applyPreview(result *SearchResult, query string, options SearchOptions) {
+	if options.PreviewLength > 0 && len(result.Matches) > 0 {
+		result.Preview = ds.buildPreview(result.Matches[0].Content, query, options, options.PreviewLength)
+	}
+	if options.PreviewOnly {
+		result.Matches = []Match{}
+	}
+}
+
+// buildPreview returns up to length characters of line, centered on the first match of query
+func (ds *DocumentSearcher) buildPreview(line, query string, options SearchOptions, length int) string {
+	runes := []rune(strings.TrimSpace(line))
+	if len(runes) <= length {
+		return string(runes)
+	}
+
+	// Locate the match so the snippet shows it, via the first term that hit for boolean queries
+	if options.BooleanMode {
+		_, hits := ds.matchesBoolean(string(runes), parseBooleanQuery(query), options)
+		if len(hits) > 0 {
+			query = hits[0]
+		}
+		options.BooleanMode = false
+	}
+	pattern := regexp.QuoteMeta(query)
+	if options.UseRegex {
+		pattern = query
+	}
+	if !options.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	matchStart := 0
+	if options.FuzzyThreshold > 0 && !options.UseRegex {
+		if loc := ds.fuzzyWordIndex(string(runes), query, options); loc != nil {
+			matchStart = len([]rune(string(runes)[:loc[0]]))
+		}
+	} else if regex, err := regexp.Compile(pattern); err == nil {
+		if loc := regex.FindStringIndex(string(runes)); loc != nil {
+			matchStart = len([]rune(string(runes)[:loc[0]]))
+		}
+	}
+
+	start := matchStart - length/4
+	if start < 0 {
+		start = 0
+	}
+	end := start + length
+	if end > len(runes) {
+		end = len(runes)
+		start = end - length
+	}
+
+	preview := string(runes[start:end])
+	if start > 0 {
+		preview = "..." + preview
+	}
+	if end < len(runes) {
+		preview += "..."
+	}
+	return preview
+}
+
 // matchesQuery checks if a line matches the search query
 func (ds *DocumentSearcher) matchesQuery(line, query string, options SearchOptions) bool {
+	if options.BooleanMode {
+		matched, _ := ds.matchesBoolean(line, parseBooleanQuery(query), options)
+		return matched
+	}
+
 	searchLine := line
 	searchQuery := query
 
@@ -195,6 +693,11 @@ func (ds *DocumentSearcher) matchesQuery(line, query string, options SearchOptio
 		return regex.MatchString(searchLine)
 	}
 
+	// Handle typo-tolerant search, word by word
+	if options.FuzzyThreshold > 0 {
+		return fuzzyMatchesLine(searchLine, searchQuery, options.FuzzyThreshold)
+	}
+
 	// Handle whole words
 	if options.WholeWords {
 		regex, err := regexp.Compile(`\b` + regexp.QuoteMeta(searchQuery) + `\b`)
@@ -208,6 +711,58 @@ func (ds *DocumentSearcher) matchesQuery(line, query string, options SearchOptio
 	return strings.Contains(searchLine, searchQuery)
 }
 
+// matchesBoolean evaluates a parsed boolean query against a line, returning the positive terms
+// that hit in the groups that matched
+func (ds *DocumentSearcher) matchesBoolean(line string, q booleanQuery, options SearchOptions) (bool, []string) {
+	options.BooleanMode = false // Terms are matched literally
+
+	matched := false
+	var hits []string
+	for _, group := range q {
+		groupMatched := true
+		var groupHits []string
+		for _, term := range group {
+			if ds.matchesQuery(line, term.Text, options) == term.Negated {
+				groupMatched = false
+				break
+			}
+			if !term.Negated {
+				groupHits = append(groupHits, term.Text)
+			}
+		}
+		if !groupMatched {
+			continue
+		}
+
+		matched = true
+		for _, hit := range groupHits {
+			if !slices.Contains(hits, hit) {
+				hits = append(hits, hit)
+			}
+		}
+	}
+	return matched, hits
+}
+
+// fuzzyWordIndex returns the byte range of the first word of line that fuzzily matches query
+func (ds *DocumentSearcher) fuzzyWordIndex(line, query string, options SearchOptions) []int {
+	queryWords := fuzzyQueryWords(ds.foldCase(query, options))
+	for _, loc := range fuzzyWordPattern.FindAllStringIndex(line, -1) {
+		if fuzzyMatchesWord(ds.foldCase(line[loc[0]:loc[1]], options), queryWords, options.FuzzyThreshold) {
+			return loc
+		}
+	}
+	return nil
+}
+
+// foldCase lowercases text unless the search is case-sensitive
+func (ds *DocumentSearcher) foldCase(text string, options SearchOptions) string {
+	if options.CaseSensitive {
+		return text
+	}
+	return strings.ToLower(text)
+}
+
 // extractContext extracts context lines around a match
 func (ds *DocumentSearcher) extractContext(lines []string, matchIndex, contextLines int) string {
 	start := matchIndex - contextLines
@@ -252,23 +807,48 @@ func (ds *DocumentSearcher) GetSearchStatistics(results map[string]*SearchResult
 		fileTypes[ext]++
 	}
 
+	// An empty result set would give NaN, which encoding/json refuses to serialize
+	averageMatches := 0.0
+	if totalFiles > 0 {
+		averageMatches = float64(totalMatches) / float64(totalFiles)
+	}
+
 	return map[string]interface{}{
 		"total_files_searched":     totalFiles,
 		"total_matches":            totalMatches,
 		"file_types":               fileTypes,
-		"average_matches_per_file": float64(totalMatches) / float64(totalFiles),
+		"average_matches_per_file": averageMatches,
 	}
 }
 
 // HighlightMatches adds HTML highlighting to search results
 func (ds *DocumentSearcher) HighlightMatches(text, query string, options SearchOptions) string {
+	return ds.HighlightMatchesWithMarkers(text, query, options, "<mark>", "</mark>")
+}
+
+// HighlightMatchesWithMarkers wraps every match of query in the given opening and closing markers
+func (ds *DocumentSearcher) HighlightMatchesWithMarkers(text, query string, options SearchOptions, pre, post string) string {
+	if options.BooleanMode {
+		return ds.highlightBooleanTerms(text, parseBooleanQuery(query).positiveTerms(), options, pre, post)
+	}
+
+	if options.FuzzyThreshold > 0 && !options.UseRegex {
+		queryWords := fuzzyQueryWords(ds.foldCase(query, options))
+		return fuzzyWordPattern.ReplaceAllStringFunc(text, func(word string) string {
+			if fuzzyMatchesWord(ds.foldCase(word, options), queryWords, options.FuzzyThreshold) {
+				return pre + word + post
+			}
+			return word
+		})
+	}
+
 	if options.UseRegex {
 		regex, err := regexp.Compile(query)
 		if err != nil {
 			return text
 		}
 		return regex.ReplaceAllStringFunc(text, func(match string) string {
-			return fmt.Sprintf("<mark>%s</mark>", match)
+			return pre + match + post
 		})
 	}
 
@@ -280,9 +860,43 @@ func (ds *DocumentSearcher) HighlightMatches(text, query string, options SearchO
 			return text
 		}
 		return regex.ReplaceAllStringFunc(text, func(match string) string {
-			return fmt.Sprintf("<mark>%s</mark>", match)
+			return pre + match + post
 		})
 	}
 
-	return strings.ReplaceAll(text, searchQuery, fmt.Sprintf("<mark>%s</mark>", searchQuery))
+	return strings.ReplaceAll(text, searchQuery, pre+searchQuery+post)
+}
+
+// highlightBooleanTerms wraps every match of any of the terms in the given markers
+func (ds *DocumentSearcher) highlightBooleanTerms(text string, terms []string, options SearchOptions, pre, post string) string {
+	if len(terms) == 0 {
+		return text
+	}
+	options.BooleanMode = false
+
+	// Fuzzy matching already works word by word, so the terms can be highlighted as one query
+	if options.FuzzyThreshold > 0 && !options.UseRegex {
+		return ds.HighlightMatchesWithMarkers(text, strings.Join(terms, " "), options, pre, post)
+	}
+
+	alternatives := make([]string, len(terms))
+	for i, term := range terms {
+		if options.UseRegex {
+			alternatives[i] = "(?:" + term + ")"
+		} else {
+			alternatives[i] = regexp.QuoteMeta(term)
+		}
+	}
+	pattern := strings.Join(alternatives, "|")
+	if !options.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return text
+	}
+	return regex.ReplaceAllStringFunc(text, func(match string) string {
+		return pre + match + post
+	})
 }
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGetSearchStatisticsEmptyResults(t *testing.T) {
+	stats := NewDocumentSearcher().GetSearchStatistics(map[string]*SearchResult{})
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatalf("marshal statistics of empty results: %v", err)
+	}
+
+	var decoded struct {
+		TotalFilesSearched    int            `json:"total_files_searched"`
+		TotalMatches          int            `json:"total_matches"`
+		FileTypes             map[string]int `json:"file_types"`
+		AverageMatchesPerFile float64        `json:"average_matches_per_file"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal %s: %v", data, err)
+	}
+
+	if decoded.TotalFilesSearched != 0 || decoded.TotalMatches != 0 || decoded.AverageMatchesPerFile != 0 {
+		t.Errorf("statistics of empty results = %s, want all zero", data)
+	}
+	if len(decoded.FileTypes) != 0 {
+		t.Errorf("file_types = %v, want empty", decoded.FileTypes)
+	}
+}
@@ -2,8 +2,11 @@ package utils
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -58,6 +61,29 @@ func FormatFileSize(bytes int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
+// ParseFileSize converts a size formatted like "3.8 GB", "600MB" or "512 B" back to bytes
+func ParseFileSize(size string) (int64, error) {
+	matches := fileSizePattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(size)))
+	if matches == nil {
+		return 0, fmt.Errorf("invalid size: %q", size)
+	}
+
+	value, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size: %q", size)
+	}
+
+	multiplier := float64(1)
+	if matches[2] != "" {
+		exp := strings.Index("KMGTPE", matches[2]) + 1
+		multiplier = math.Pow(1024, float64(exp))
+	}
+
+	return int64(value * multiplier), nil
+}
+
+var fileSizePattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([KMGTPE]?)I?B$`)
+
 // AnalyzeContent provides content analysis
 func AnalyzeContent(content string) map[string]interface{} {
 	lines := strings.Split(content, "\n")
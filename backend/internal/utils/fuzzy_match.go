@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// fuzzyWordPattern finds the words of a line for fuzzy matching
+var fuzzyWordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// EditDistance returns the optimal string alignment distance between a and b: the number of
+// single-rune insertions, deletions, substitutions and adjacent transpositions between them
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 {
+		return len(rb)
+	}
+	if len(rb) == 0 {
+		return len(ra)
+	}
+
+	// Three rolling rows are enough to look back for transpositions
+	prevPrev := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prevPrev[j-2]+1)
+			}
+		}
+		prevPrev, prev, curr = prev, curr, prevPrev
+	}
+	return prev[len(rb)]
+}
+
+// fuzzyQueryWords splits a query into the words matched by fuzzyMatchesLine
+func fuzzyQueryWords(query string) []string {
+	return strings.FieldsFunc(query, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) })
+}
+
+// fuzzyMatchesWord reports whether word is within threshold edits of any of the query words
+func fuzzyMatchesWord(word string, queryWords []string, threshold int) bool {
+	length := len([]rune(word))
+	for _, queryWord := range queryWords {
+		if diff := length - len([]rune(queryWord)); diff > threshold || -diff > threshold {
+			continue // Too different in length to be within the threshold
+		}
+		if EditDistance(word, queryWord) <= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzyMatchesLine reports whether every word of query is within threshold edits of some word of line
+func fuzzyMatchesLine(line, query string, threshold int) bool {
+	queryWords := fuzzyQueryWords(query)
+	if len(queryWords) == 0 {
+		return false
+	}
+
+	lineWords := fuzzyWordPattern.FindAllString(line, -1)
+	for _, queryWord := range queryWords {
+		found := false
+		for _, word := range lineWords {
+			if fuzzyMatchesWord(word, []string{queryWord}, threshold) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
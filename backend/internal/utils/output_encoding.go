@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+// DefaultOutputEncoding is used when a conversion doesn't request an encoding
+const DefaultOutputEncoding = "utf-8"
+
+// outputEncodings are the encodings converted documents can be written in
+var outputEncodings = map[string]encoding.Encoding{
+	"utf-8":        xunicode.UTF8,
+	"utf-16":       xunicode.UTF16(xunicode.LittleEndian, xunicode.UseBOM),
+	"utf-16le":     xunicode.UTF16(xunicode.LittleEndian, xunicode.IgnoreBOM),
+	"utf-16be":     xunicode.UTF16(xunicode.BigEndian, xunicode.IgnoreBOM),
+	"windows-1252": charmap.Windows1252,
+	"iso-8859-1":   charmap.ISO8859_1,
+	"iso-8859-15":  charmap.ISO8859_15,
+}
+
+// outputEncodingAliases maps common alternative names to outputEncodings keys
+var outputEncodingAliases = map[string]string{
+	"utf8":   "utf-8",
+	"cp1252": "windows-1252",
+	"latin1": "iso-8859-1",
+	"latin9": "iso-8859-15",
+}
+
+// NormalizeOutputEncoding returns the canonical name of a supported output encoding. An empty
+// name means DefaultOutputEncoding.
+func NormalizeOutputEncoding(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return DefaultOutputEncoding, nil
+	}
+	if alias, ok := outputEncodingAliases[name]; ok {
+		name = alias
+	}
+	if _, ok := outputEncodings[name]; !ok {
+		return "", fmt.Errorf("unsupported output encoding %q, use one of: %s", name, strings.Join(OutputEncodings(), ", "))
+	}
+	return name, nil
+}
+
+// OutputEncodings lists the supported output encodings
+func OutputEncodings() []string {
+	names := make([]string, 0, len(outputEncodings))
+	for name := range outputEncodings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EncodeOutput transcodes UTF-8 text to the named encoding. Characters the encoding can't
+// represent are an error rather than being silently replaced.
+func EncodeOutput(text, name string) ([]byte, error) {
+	name, err := NormalizeOutputEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	if name == DefaultOutputEncoding {
+		return []byte(text), nil
+	}
+
+	encoded, err := outputEncodings[name].NewEncoder().String(text)
+	if err != nil {
+		return nil, fmt.Errorf("text can't be encoded as %s: %w", name, err)
+	}
+	return []byte(encoded), nil
+}
+
+// writeEncoded writes text to path in the named encoding
+func writeEncoded(path, text, encodingName string) error {
+	data, err := EncodeOutput(text, encodingName)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
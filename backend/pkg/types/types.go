@@ -2,7 +2,9 @@
 package types
 
 import (
+	"fmt"
 	"mime/multipart"
+	"strings"
 	"time"
 )
 
@@ -15,7 +17,7 @@ type WikiResult struct {
 	Extract        string  `json:"extract,omitempty"`
 	Thumbnail      string  `json:"thumbnail,omitempty"`
 	RelevanceScore float64 `json:"relevanceScore,omitempty"`
-	Summary        string  `json:"summary"` // Added missing field
+	Summary        string  `json:"summary"` // Text the result contributes to prompts
 }
 
 // Document represents a document in the system
@@ -27,9 +29,69 @@ type Document struct {
 	UploadDate string            `json:"upload_date"`
 	Status     string            `json:"status"`
 	Path       string            `json:"path"`
-	Metadata   map[string]string `json:"metadata,omitempty"`   // Added metadata field
-	Chunks     int               `json:"chunks,omitempty"`     // Number of chunks
-	Embeddings bool              `json:"embeddings,omitempty"` // Whether embeddings are created
+	Metadata   map[string]string `json:"metadata,omitempty"`    // Added metadata field
+	Chunks     int               `json:"chunks,omitempty"`      // Number of chunks
+	Embeddings bool              `json:"embeddings,omitempty"`  // Whether embeddings are created
+	Owner      string            `json:"owner,omitempty"`       // Uploading user, empty for anonymous uploads
+	Visibility string            `json:"visibility,omitempty"`  // private, shared or public
+	ExternalID string            `json:"external_id,omitempty"` // Unique key from an integrating system
+	// Authored dates in RFC 3339, embedded in the file or its modification time as a fallback
+	CreatedDate  string `json:"created_date,omitempty"`
+	ModifiedDate string `json:"modified_date,omitempty"`
+}
+
+// DocumentGroup is one entry of a grouped or deduplicated listing: the latest document of a name
+// or content, and the documents it stands for
+type DocumentGroup struct {
+	Document
+	Versions     int      `json:"versions"`                // Distinct uploads under this name
+	VersionIDs   []string `json:"version_ids"`             // Newest first, including the shown document
+	Duplicates   int      `json:"duplicates,omitempty"`    // Uploads with identical content folded into this entry
+	DuplicateIDs []string `json:"duplicate_ids,omitempty"` // The folded documents, newest first
+}
+
+// Document visibility levels
+const (
+	VisibilityPrivate = "private" // Only the owner (and admins)
+	VisibilityShared  = "shared"  // Any authenticated user
+	VisibilityPublic  = "public"  // Everyone, including anonymous requests
+)
+
+// IsValidVisibility reports whether v is a known visibility level
+func IsValidVisibility(v string) bool {
+	return v == VisibilityPrivate || v == VisibilityShared || v == VisibilityPublic
+}
+
+// AccessContext identifies who is making a request for document access checks
+type AccessContext struct {
+	User    string `json:"user,omitempty"`
+	IsAdmin bool   `json:"is_admin"`
+}
+
+// CanAccess reports whether the requester may see the document
+func (a AccessContext) CanAccess(doc *Document) bool {
+	if a.IsAdmin {
+		return true
+	}
+
+	switch doc.Visibility {
+	case VisibilityPrivate:
+		return a.User != "" && doc.Owner == a.User
+	case VisibilityShared:
+		return a.User != ""
+	default:
+		// Public, or documents created before visibility existed
+		return true
+	}
+}
+
+// CanModify reports whether the requester may change or delete the document. Only its owner and
+// admins can; anonymous uploads have no owner, so only admins can change them.
+func (a AccessContext) CanModify(doc *Document) bool {
+	if a.IsAdmin {
+		return true
+	}
+	return a.User != "" && doc.Owner == a.User
 }
 
 // DocumentChunk represents a chunk of a document for vector storage
@@ -38,30 +100,78 @@ type DocumentChunk struct {
 	DocumentID string    `json:"document_id"`
 	Content    string    `json:"content"`
 	ChunkIndex int       `json:"chunk_index"`
+	Page       int       `json:"page,omitempty"` // Source PDF page, 0 when the document has no pages
 	Embedding  []float64 `json:"embedding,omitempty"`
 	CreatedAt  string    `json:"created_at"`
 }
 
+// ChunkSearchResult is a stored chunk matching a chunk-level search
+type ChunkSearchResult struct {
+	DocumentID   string  `json:"document_id"`
+	DocumentName string  `json:"document_name"`
+	ChunkIndex   int     `json:"chunk_index"`
+	Page         int     `json:"page,omitempty"`
+	Score        float64 `json:"score"`  // Cosine similarity for vector search, matching lines for text search
+	Method       string  `json:"method"` // vector or text
+	Content      string  `json:"content"`
+}
+
+// Suggestion is an autocomplete term for the search box
+type Suggestion struct {
+	Term      string `json:"term"`
+	Documents int    `json:"documents"` // Documents containing the term
+	Score     int    `json:"score"`     // Summed weight; title and tag terms weigh more than content terms
+}
+
 // Model represents an AI model
 type Model struct {
-	ID               string  `json:"id"`
-	Name             string  `json:"name"`
-	Size             string  `json:"size"`
-	Type             string  `json:"type"` // Added missing field
-	Status           string  `json:"status"`
-	DownloadProgress float64 `json:"downloadProgress,omitempty"`
-	Description      string  `json:"description,omitempty"`
-	ModelType        string  `json:"modelType"`
-	URL              string  `json:"url,omitempty"` // Added for download links
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	Size             string   `json:"size"`
+	Type             string   `json:"type"` // Added missing field
+	Status           string   `json:"status"`
+	DownloadProgress float64  `json:"downloadProgress,omitempty"`
+	Description      string   `json:"description,omitempty"`
+	ModelType        string   `json:"modelType"`
+	URL              string   `json:"url,omitempty"`          // Added for download links
+	Capabilities     []string `json:"capabilities,omitempty"` // Task types the model supports (chat, embedding, vision, ...)
+	Family           string   `json:"family,omitempty"`
+	Quantization     string   `json:"quantization,omitempty"`
+	ModifiedAt       string   `json:"modified_at,omitempty"` // RFC 3339
 }
 
 // QueryRequest represents a query request
 type QueryRequest struct {
-	Query            string `json:"query"`
-	ModelName        string `json:"model_name"`
-	IncludeWiki      bool   `json:"include_wiki"`
-	IncludeDocuments bool   `json:"include_documents"`
-	MaxSources       int    `json:"max_sources,omitempty"`
+	Query            string   `json:"query"`
+	ModelName        string   `json:"model_name"`
+	IncludeWiki      bool     `json:"include_wiki"`
+	IncludeDocuments bool     `json:"include_documents"`
+	MaxSources       int      `json:"max_sources,omitempty"`
+	Language         string   `json:"language,omitempty"`     // e.g. "de", "en"; empty uses the configured default
+	DocumentIDs      []string `json:"document_ids,omitempty"` // Ground the answer in exactly these documents instead of searching
+	// Common generation parameters, they take precedence over the same keys in Options
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	TopK        *int     `json:"top_k,omitempty"`
+	MaxTokens   *int     `json:"max_tokens,omitempty"` // Longest answer in tokens, Ollama's num_predict
+	// Ollama generation options overriding the defaults, e.g. repeat_penalty or mirostat
+	Options map[string]interface{} `json:"options,omitempty"`
+	// Continues an earlier conversation, its previous turns are added to the prompt. IDs are
+	// issued by the server in the response to a query sent with new_conversation.
+	ConversationID  string `json:"conversation_id,omitempty"`
+	NewConversation bool   `json:"new_conversation,omitempty"` // Start a conversation and return its ID
+}
+
+// Roles of the messages in a conversation
+const (
+	ChatRoleUser      = "user"
+	ChatRoleAssistant = "assistant"
+)
+
+// ChatMessage is one turn of a conversation
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
 }
 
 // QueryResponse represents a query response
@@ -71,8 +181,33 @@ type QueryResponse struct {
 		Documents []Document   `json:"documents"`
 		Wiki      []WikiResult `json:"wiki"`
 	} `json:"sources"`
-	ModelUsed      string  `json:"modelUsed"`
-	ProcessingTime float64 `json:"processingTime"`
+	ModelUsed      string            `json:"modelUsed"`
+	ProcessingTime float64           `json:"processingTime"`
+	Context        *ContextSelection `json:"context,omitempty"`
+	Debug          *QueryDebug       `json:"debug,omitempty"`
+	ConversationID string            `json:"conversation_id,omitempty"`
+}
+
+// ContextSelection reports which candidate documents made it into the prompt
+type ContextSelection struct {
+	Candidates     int      `json:"candidates"`
+	Included       []string `json:"included"`        // Document IDs, most relevant first
+	BelowThreshold int      `json:"below_threshold"` // Left out for a relevance score under the minimum
+	OverBudget     int      `json:"over_budget"`     // Left out because the context budget was spent
+	Truncated      int      `json:"truncated"`       // Included but cut to fit the budget
+	Unreadable     int      `json:"unreadable"`
+	BudgetChars    int      `json:"budget_chars"`   // Document context that fit the model's context window, 0 is unlimited
+	IncludedChars  int      `json:"included_chars"` // Document text in the prompt
+	OmittedChars   int      `json:"omitted_chars"`  // Document text cut or left out to fit the budget
+}
+
+// QueryDebug exposes the assembled prompt and selected sources for diagnostics
+type QueryDebug struct {
+	Prompt          string   `json:"prompt"`
+	PromptLength    int      `json:"prompt_length"`
+	PromptTruncated bool     `json:"prompt_truncated"`
+	DocumentIDs     []string `json:"document_ids"`
+	DocumentNames   []string `json:"document_names"`
 }
 
 // Request types
@@ -81,6 +216,13 @@ type DownloadModelRequest struct {
 	URL  string `json:"url" binding:"required"`
 }
 
+// DefaultModelStatus reports whether the configured default model was loaded on startup
+type DefaultModelStatus struct {
+	Name   string `json:"name,omitempty"`
+	Loaded bool   `json:"loaded"`
+	Error  string `json:"error,omitempty"`
+}
+
 type LoadModelRequest struct {
 	Name string `json:"name" binding:"required"`
 }
@@ -89,6 +231,101 @@ type UploadDocumentRequest struct {
 	File *multipart.FileHeader `form:"file" binding:"required"`
 }
 
+// Outcomes of a file in a batch upload
+const (
+	UploadOutcomeSuccess         = "success"
+	UploadOutcomeRetried         = "retried" // Succeeded after transient storage failures
+	UploadOutcomeValidationError = "validation_error"
+	UploadOutcomeStorageError    = "storage_error"
+	UploadOutcomeStorageLimit    = "storage_limit"    // Rejected because the storage limits were reached
+	UploadOutcomeMalwareDetected = "malware_detected" // Rejected by the malware scan
+)
+
+// BatchUploadResult reports the outcome of one file in a batch upload
+type BatchUploadResult struct {
+	Filename string    `json:"filename"`
+	Success  bool      `json:"success"`
+	Outcome  string    `json:"outcome"`
+	Attempts int       `json:"attempts"`
+	Document *Document `json:"document,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// ProcessingCallback is posted to an upload's callback URL when background processing completes.
+// It only identifies the document, receivers fetch anything else through the API.
+type ProcessingCallback struct {
+	DocumentID  string `json:"document_id"`
+	ExternalID  string `json:"external_id,omitempty"`
+	Status      string `json:"status"` // "processed" or "failed"
+	Error       string `json:"error,omitempty"`
+	CompletedAt string `json:"completed_at"`
+}
+
+// DocumentQuery combines document filters with sorting and pagination. Empty fields don't filter.
+type DocumentQuery struct {
+	Types          []string          `json:"types,omitempty"`           // Any of these file types
+	Tags           []string          `json:"tags,omitempty"`            // All of these, from the comma-separated user.tags metadata
+	MinSize        int64             `json:"min_size,omitempty"`        // Bytes
+	MaxSize        int64             `json:"max_size,omitempty"`        // Bytes
+	UploadedAfter  string            `json:"uploaded_after,omitempty"`  // RFC 3339 or YYYY-MM-DD, inclusive
+	UploadedBefore string            `json:"uploaded_before,omitempty"` // RFC 3339 or YYYY-MM-DD, exclusive
+	Text           string            `json:"text,omitempty"`            // Matched against name and content
+	Metadata       map[string]string `json:"metadata,omitempty"`        // Exact metadata values, case-insensitive
+	SortBy         string            `json:"sort_by,omitempty"`         // name, type, size, upload_date, created_date or modified_date
+	Order          string            `json:"order,omitempty"`           // asc or desc
+	Offset         int               `json:"offset,omitempty"`
+	Limit          int               `json:"limit,omitempty"`
+}
+
+// Validate checks the query's sort options, ranges and dates
+func (q DocumentQuery) Validate() error {
+	switch q.SortBy {
+	case "", "name", "type", "size", "upload_date", "created_date", "modified_date":
+	default:
+		return fmt.Errorf("sort_by must be one of name, type, size, upload_date, created_date, modified_date")
+	}
+	if q.Order != "" && q.Order != "asc" && q.Order != "desc" {
+		return fmt.Errorf("order must be asc or desc")
+	}
+	if q.Offset < 0 || q.Limit < 0 {
+		return fmt.Errorf("offset and limit must not be negative")
+	}
+	if q.MaxSize > 0 && q.MinSize > q.MaxSize {
+		return fmt.Errorf("min_size must not exceed max_size")
+	}
+	for _, date := range []string{q.UploadedAfter, q.UploadedBefore} {
+		if date == "" {
+			continue
+		}
+		if _, err := ParseDocumentDate(date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// documentDateLayouts are the upload date formats in use, most specific first
+var documentDateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// ParseDocumentDate parses upload dates and query bounds in any of the supported layouts
+func ParseDocumentDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range documentDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, use RFC 3339 or YYYY-MM-DD", value)
+}
+
+// DocumentQueryResult is one page of documents matching a DocumentQuery
+type DocumentQueryResult struct {
+	Documents []Document `json:"documents"`
+	Total     int        `json:"total"` // Matches before pagination
+	Offset    int        `json:"offset"`
+	Limit     int        `json:"limit"`
+}
+
 // Response types
 type ErrorResponse struct {
 	Error   string `json:"error"`
@@ -103,8 +340,36 @@ type SuccessResponse struct {
 
 // DocumentContent represents processed content from a document
 type DocumentContent struct {
-	Text        string            `json:"text"`
-	Type        string            `json:"type"`
-	Metadata    map[string]string `json:"metadata"`
-	ProcessedAt time.Time         `json:"processed_at"`
+	Text              string            `json:"text"`
+	PlainText         string            `json:"plain_text,omitempty"` // Text without markup, set by processors whose Text keeps it
+	Type              string            `json:"type"`
+	Metadata          map[string]string `json:"metadata"`
+	ExtractionMethod  string            `json:"extraction_method"`  // Library or strategy that produced Text
+	ExtractionQuality string            `json:"extraction_quality"` // high or degraded
+	ProcessedAt       time.Time         `json:"processed_at"`
+}
+
+// RetrievalText returns the text to chunk and embed, preferring the markup-free PlainText
+func (c *DocumentContent) RetrievalText() string {
+	if c.PlainText != "" {
+		return c.PlainText
+	}
+	return c.Text
+}
+
+// Extraction quality levels for DocumentContent
+const (
+	ExtractionQualityHigh     = "high"     // Text was fully extracted
+	ExtractionQualityDegraded = "degraded" // Fallback or placeholder text, don't rely on it
+)
+
+// AuditEntry represents a single access or modification event on a document
+type AuditEntry struct {
+	Timestamp  string `json:"timestamp"`
+	DocumentID string `json:"document_id"`
+	Event      string `json:"event"`
+	RequestID  string `json:"request_id,omitempty"`
+	ClientIP   string `json:"client_ip,omitempty"`
+	User       string `json:"user,omitempty"` // Acting user, empty for anonymous callers
+	Details    string `json:"details,omitempty"`
 }
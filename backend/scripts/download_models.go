@@ -3,12 +3,21 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/1DeliDolu/ki-ai-go/internal/utils"
 )
 
 type ModelInfo struct {
@@ -46,41 +55,28 @@ var models = []ModelInfo{
 }
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("🤖 Available models for Local AI Project:")
-		fmt.Println("=========================================")
-		for i, model := range models {
-			fmt.Printf("%d. %s (%s)\n", i+1, model.Name, model.Size)
-			fmt.Printf("   📝 %s\n", model.Description)
-			fmt.Println()
-		}
-		fmt.Println("📥 Usage: go run download_models.go <model_number>")
-		fmt.Println("📁 Models will be saved to: ./models/ directory")
+	timeout := flag.Duration("timeout", 30*time.Second, "connect timeout, also aborts an attempt when no data arrives for this long")
+	retries := flag.Int("retries", 5, "download attempts before giving up, resuming where the last one stopped")
+	name := flag.String("name", "", "file name to save a URL download as (defaults to the last URL path segment)")
+	flag.Usage = printUsage
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		printUsage()
 		return
 	}
 
-	modelIndex := os.Args[1]
-	var selectedModel ModelInfo
-
-	switch modelIndex {
-	case "1":
-		selectedModel = models[0]
-	case "2":
-		selectedModel = models[1]
-	case "3":
-		selectedModel = models[2]
-	case "4":
-		selectedModel = models[3]
-	default:
-		fmt.Println("❌ Invalid model number. Please choose 1-4.")
-		return
+	selectedModel, err := resolveModel(flag.Arg(0), *name)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
 	}
 
 	// Use local models directory relative to project root
 	projectRoot, err := getProjectRoot()
 	if err != nil {
 		fmt.Printf("❌ Error finding project root: %v\n", err)
-		return
+		os.Exit(1)
 	}
 
 	modelsDir := filepath.Join(projectRoot, "models")
@@ -88,7 +84,7 @@ func main() {
 	// Create models directory if it doesn't exist
 	if err := os.MkdirAll(modelsDir, 0755); err != nil {
 		fmt.Printf("❌ Error creating models directory: %v\n", err)
-		return
+		os.Exit(1)
 	}
 
 	modelPath := filepath.Join(modelsDir, selectedModel.Name)
@@ -106,11 +102,10 @@ func main() {
 	fmt.Println()
 
 	startTime := time.Now()
-	if err := downloadFileWithProgress(selectedModel.URL, modelPath); err != nil {
-		fmt.Printf("❌ Error downloading model: %v\n", err)
-		// Clean up partial file
-		os.Remove(modelPath)
-		return
+	if err := downloadWithRetry(selectedModel.URL, modelPath, *timeout, *retries); err != nil {
+		fmt.Printf("\n❌ Error downloading model: %v\n", err)
+		fmt.Printf("💡 The partial download was kept in %s; run the same command again to resume\n", modelPath+partialSuffix)
+		os.Exit(1)
 	}
 
 	duration := time.Since(startTime)
@@ -119,7 +114,7 @@ func main() {
 
 	// Show file size
 	if stat, err := os.Stat(modelPath); err == nil {
-		fmt.Printf("📊 File size: %s\n", formatFileSize(stat.Size()))
+		fmt.Printf("📊 File size: %s\n", utils.FormatFileSize(stat.Size()))
 	}
 
 	fmt.Println("\n💡 Next steps:")
@@ -128,6 +123,57 @@ func main() {
 	fmt.Printf("3. Model is available as: %s\n", selectedModel.Name)
 }
 
+// printUsage lists the known models and the command line options
+func printUsage() {
+	fmt.Println("🤖 Available models for Local AI Project:")
+	fmt.Println("=========================================")
+	for i, model := range models {
+		fmt.Printf("%d. %s (%s)\n", i+1, model.Name, model.Size)
+		fmt.Printf("   📝 %s\n", model.Description)
+		fmt.Println()
+	}
+	fmt.Println("📥 Usage: go run download_models.go [options] <model_number | model_name | url>")
+	fmt.Println("📁 Models will be saved to: ./models/ directory")
+	fmt.Println()
+	fmt.Println("Options:")
+	flag.PrintDefaults()
+}
+
+// resolveModel accepts a menu number, a known model name or a direct download URL
+func resolveModel(arg, name string) (ModelInfo, error) {
+	if n, err := strconv.Atoi(arg); err == nil {
+		if n < 1 || n > len(models) {
+			return ModelInfo{}, fmt.Errorf("invalid model number. Please choose 1-%d", len(models))
+		}
+		return models[n-1], nil
+	}
+
+	for _, model := range models {
+		if strings.EqualFold(model.Name, arg) || strings.EqualFold(strings.TrimSuffix(model.Name, ".gguf"), arg) {
+			return model, nil
+		}
+	}
+
+	parsed, err := url.Parse(arg)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ModelInfo{}, fmt.Errorf("unknown model %q: pass a model number, a listed model name or an http(s) URL", arg)
+	}
+
+	if name == "" {
+		name = path.Base(parsed.Path)
+	}
+	if name == "" || name == "." || name == "/" {
+		return ModelInfo{}, fmt.Errorf("can't derive a file name from %s, pass -name", arg)
+	}
+
+	return ModelInfo{
+		Name:        filepath.Base(name),
+		URL:         arg,
+		Size:        "unknown size",
+		Description: "Custom model URL",
+	}, nil
+}
+
 // getProjectRoot finds the project root directory
 func getProjectRoot() (string, error) {
 	// Start from current directory and go up to find backend directory
@@ -150,45 +196,179 @@ func getProjectRoot() (string, error) {
 	return currentDir, nil
 }
 
-// downloadFileWithProgress downloads file with progress indication
-func downloadFileWithProgress(url, filepath string) error {
-	resp, err := http.Get(url)
+// partialSuffix marks an incomplete download that the next run resumes
+const partialSuffix = ".part"
+
+// downloadWithRetry downloads into a .part file, resuming after failures, and renames it once complete
+func downloadWithRetry(downloadURL, destination string, timeout time.Duration, retries int) error {
+	if retries < 1 {
+		retries = 1
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: timeout}).DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+		},
+	}
+
+	partial := destination + partialSuffix
+	var lastErr error
+	for attempt := 1; attempt <= retries; attempt++ {
+		if attempt > 1 {
+			delay := time.Duration(1<<uint(attempt-2)) * time.Second
+			fmt.Printf("\n🔁 Retrying in %v (attempt %d/%d): %v\n", delay, attempt, retries, lastErr)
+			time.Sleep(delay)
+		}
+
+		complete, err := downloadAttempt(client, downloadURL, partial, timeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if complete {
+			return os.Rename(partial, destination)
+		}
+	}
+
+	return fmt.Errorf("giving up after %d attempts: %w", retries, lastErr)
+}
+
+// downloadAttempt fetches the rest of the file after what is already in partial.
+// It reports whether the file is complete.
+func downloadAttempt(client *http.Client, downloadURL, partial string, timeout time.Duration) (bool, error) {
+	var offset int64
+	if stat, err := os.Stat(partial); err == nil {
+		offset = stat.Size()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("bad status: %s", resp.Status)
+	flags := os.O_CREATE | os.O_WRONLY
+	var total int64
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		fmt.Printf("⏩ Resuming from %s\n", utils.FormatFileSize(offset))
+		flags |= os.O_APPEND
+		if resp.ContentLength >= 0 {
+			total = offset + resp.ContentLength
+		}
+	case http.StatusOK:
+		// The server ignored the range, start over
+		offset = 0
+		flags |= os.O_TRUNC
+		total = resp.ContentLength
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Everything was already downloaded
+		return offset > 0, nil
+	default:
+		return false, fmt.Errorf("bad status: %s", resp.Status)
 	}
 
-	out, err := os.Create(filepath)
+	out, err := os.OpenFile(partial, flags, 0644)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer out.Close()
 
-	// Create a progress reader
-	fileSize := resp.ContentLength
-	if fileSize > 0 {
-		fmt.Printf("📊 File size: %s\n", formatFileSize(fileSize))
+	if total > 0 {
+		fmt.Printf("📊 File size: %s\n", utils.FormatFileSize(total))
 	}
 
-	// Copy with progress (simplified - no progress bar for now)
-	_, err = io.Copy(out, resp.Body)
-	return err
+	// Abort the attempt when the connection stalls
+	stall := time.AfterFunc(timeout, cancel)
+	defer stall.Stop()
+
+	progress := &progressWriter{written: offset, total: total, start: time.Now(), resumedAt: offset}
+	buf := make([]byte, 256*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			stall.Reset(timeout)
+			if _, err := out.Write(buf[:n]); err != nil {
+				return false, err
+			}
+			progress.Add(int64(n))
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			if ctx.Err() != nil {
+				return false, fmt.Errorf("no data received for %v", timeout)
+			}
+			return false, readErr
+		}
+	}
+	progress.Finish()
+
+	if total > 0 && progress.written != total {
+		return false, fmt.Errorf("incomplete download: got %s of %s", utils.FormatFileSize(progress.written), utils.FormatFileSize(total))
+	}
+	return true, nil
+}
+
+// progressWriter renders a single-line progress bar
+type progressWriter struct {
+	written   int64
+	total     int64 // 0 when the server didn't send a length
+	start     time.Time
+	resumedAt int64
+	lastDraw  time.Time
 }
 
-// formatFileSize formats bytes into human readable format
-func formatFileSize(bytes int64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
+const progressBarWidth = 30
+
+// Add records n more bytes and redraws at most a few times per second
+func (p *progressWriter) Add(n int64) {
+	p.written += n
+	if time.Since(p.lastDraw) >= 200*time.Millisecond {
+		p.draw()
 	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
+}
+
+// Finish draws the final state and ends the line
+func (p *progressWriter) Finish() {
+	p.draw()
+	fmt.Println()
+}
+
+func (p *progressWriter) draw() {
+	p.lastDraw = time.Now()
+
+	speed := ""
+	if elapsed := time.Since(p.start).Seconds(); elapsed > 0 {
+		speed = utils.FormatFileSize(int64(float64(p.written-p.resumedAt)/elapsed)) + "/s"
+	}
+
+	if p.total <= 0 {
+		fmt.Printf("\r📥 %s  %s    ", utils.FormatFileSize(p.written), speed)
+		return
+	}
+
+	ratio := float64(p.written) / float64(p.total)
+	if ratio > 1 {
+		ratio = 1
 	}
-	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+	filled := int(ratio * progressBarWidth)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+	fmt.Printf("\r[%s] %5.1f%%  %s / %s  %s    ", bar, ratio*100,
+		utils.FormatFileSize(p.written), utils.FormatFileSize(p.total), speed)
 }